@@ -2,13 +2,16 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"time"
 
+	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/getsentry/sentry-go"
 	"github.com/mavolin/adam/pkg/bot"
 	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
 	"github.com/mavolin/adam/pkg/impl/command/help"
 	"github.com/mavolin/disstate/v3/pkg/state"
 	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
@@ -17,17 +20,31 @@ import (
 
 	"github.com/mavolin/levin/internal/config"
 	"github.com/mavolin/levin/internal/errhandler"
+	"github.com/mavolin/levin/internal/eventlog"
 	"github.com/mavolin/levin/internal/i18nwrapper"
+	"github.com/mavolin/levin/internal/metrics"
+	"github.com/mavolin/levin/internal/plugins/languages"
+	"github.com/mavolin/levin/internal/plugins/moderation"
+	"github.com/mavolin/levin/internal/repository"
 	sentryadam "github.com/mavolin/levin/internal/sentry"
 	"github.com/mavolin/levin/internal/zaplog"
 )
 
 var (
 	debug = flag.Bool("debug", false,
-		"Sets the log-level to debug and uses human-readable logs. Additionally, it disables sentry error capturing.")
+		"Uses the debug log-level, human-readable logs, and the development sentry environment by default.")
+	logFormat = flag.String("log-format", "",
+		"The format of the logs, either json or console. Overrides the log format set in the config.")
+	logLevel = flag.String("log-level", "",
+		"The minimum level of the logs, e.g. debug or warn. Overrides the log level set in the config.")
+	sentryEnabled = flag.Bool("sentry", true,
+		"Whether to capture errors using sentry. Overrides the sentry enabled setting in the config.")
 	configPath       = flag.String("config", "", "A custom path to the configuration file.")
 	translationsPath = flag.String("translations", "",
 		"A path to a directory containing additional translation files.")
+	pseudoLocale = flag.Bool("pseudo-locale", false,
+		"Localizes all responses to the pseudo-locale "+i18nwrapper.PseudoLocale+
+			", to find hard-coded and truncated strings.")
 )
 
 var log *zap.SugaredLogger
@@ -35,37 +52,101 @@ var log *zap.SugaredLogger
 func init() {
 	flag.Parse()
 
-	zaplog.Init(*debug)
-	log = zap.S().Named("startup")
+	// until the config is loaded, only the flags and the debug defaults are
+	// used
+	format, level := zaplog.JSONFormat, "info"
+	if *debug {
+		format, level = zaplog.ConsoleFormat, "debug"
+	}
+
+	initLogger(format, level)
 	errors.Log = errhandler.CommandError()
 
 	log.With("custom_path", *configPath).
 		Info("reading config")
 
-	if err := config.Load(*configPath); err != nil {
+	if err := config.Load(*configPath, *debug); err != nil {
 		log.With("err", err).
 			Fatal("unable to load config")
 	}
 
-	if !(*debug) {
+	overrideConfig()
+	initLogger(zaplog.Format(config.C.Log.Format), config.C.Log.Level)
+
+	if config.C.Sentry.Enabled {
 		if err := sentryadam.Init(); err != nil {
 			log.With("err", err).
 				Fatal("unable to initialize sentry")
 		}
 	} else {
-		log.Info("debug mode: disabling sentry capturing")
+		log.Info("sentry capturing is disabled")
+	}
+}
+
+// initLogger initializes the global logger using the passed format and
+// level, overridden by the log flags, if set.
+func initLogger(format zaplog.Format, level string) {
+	if len(*logFormat) > 0 {
+		format = zaplog.Format(*logFormat)
+	}
+
+	if len(*logLevel) > 0 {
+		level = *logLevel
 	}
+
+	if err := zaplog.Init(format, level); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to initialize logger:", err)
+		os.Exit(1)
+	}
+
+	log = zap.S().Named("startup")
+}
+
+// overrideConfig overrides the config using the flags that were set.
+func overrideConfig() {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "log-format":
+			config.C.Log.Format = *logFormat
+		case "log-level":
+			config.C.Log.Level = *logLevel
+		case "sentry":
+			config.C.Sentry.Enabled = *sentryEnabled
+		}
+	})
 }
 
 func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run runs levin until it receives SIGINT.
+// Errors are logged before they are returned, and sentry is flushed and all
+// resources are released before run returns.
+func run() (err error) {
 	defer zap.S().Sync() //nolint:errcheck
 	defer sentry.Flush(3 * time.Second)
+	defer func() { sentryadam.EndSession(err != nil) }()
+	// report the summaries of suppressed errors before flushing sentry
+	defer errhandler.Stop()
+
+	defer func() {
+		if err != nil {
+			log.With("err", err).
+				Error("exiting due to error")
+			sentry.CaptureException(err)
+		}
+	}()
+
+	if flag.Arg(0) == "translations" {
+		return errors.Wrap(runTranslations(flag.Args()[1:]), "unable to run translations command")
+	}
 
 	bundle := i18nimpl.NewBundle(language.English)
-	err := i18nwrapper.Load(bundle, *translationsPath)
-	if err != nil {
-		log.With("err", err).
-			Fatal("unable to load translation files")
+	if err = i18nwrapper.Load(bundle, *translationsPath); err != nil {
+		return errors.Wrap(err, "unable to load translation files")
 	}
 
 	b, err := bot.New(bot.Options{
@@ -73,17 +154,36 @@ func main() {
 		Owners:              config.C.Owners,
 		EditAge:             config.C.EditAge,
 		AllowBot:            config.C.AllowBot,
+		ErrorHandler:        errhandler.ErrorHandler(),
 		GatewayErrorHandler: errhandler.Gateway(zap.S(), sentry.CurrentHub()),
 		StateErrorHandler:   errhandler.StateError(zap.S(), sentry.CurrentHub()),
 		StatePanicHandler:   errhandler.StatePanic(zap.S(), sentry.CurrentHub()),
 	})
 	if err != nil {
-		log.With("err", err).
-			Fatal("unable to create bot")
+		return errors.Wrap(err, "unable to create bot")
 	}
 
+	localizer := newLocalizerFunc(b.State, bundle, *pseudoLocale)
+	b.SettingsProvider = newSettingsProvider(localizer)
+
 	addMiddlewares(b)
-	addPlugins(b)
+	repo, err := repository.Open(config.C.DatabasePath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open database at %s", config.C.DatabasePath)
+	}
+
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.With("err", err).
+				Error("unable to close database")
+		}
+	}()
+
+	if err = addPlugins(b, bundle, repo, localizer); err != nil {
+		return err
+	}
+
+	metrics.Serve(config.C.MetricsAddr)
 
 	log.Info("starting bot")
 
@@ -91,9 +191,8 @@ func main() {
 		log.Infof("serving as %s#%s", e.User.Username, e.User.Discriminator)
 	})
 
-	if err := b.Open(); err != nil {
-		log.With("err", err).
-			Fatal(err)
+	if err = b.Open(); err != nil {
+		return errors.Wrap(err, "unable to open bot")
 	}
 
 	sig := make(chan os.Signal, 1)
@@ -107,19 +206,89 @@ func main() {
 		log.With("err", err).
 			Error("unable to close bot")
 	}
+
+	return nil
 }
 
 func addMiddlewares(b *bot.Bot) {
 	sentryMiddlewares := sentryadam.NewMiddlewares(sentry.CurrentHub())
 
+	sentryadam.InstrumentClient(b.State.Client.Client)
+	b.State.MustAddHandler(sentryadam.RecordEvents())
+
 	b.MessageCreateMiddlewares = append(b.MessageCreateMiddlewares, sentryMiddlewares.MessageCreateMiddleware)
 	b.MessageUpdateMiddlewares = append(b.MessageUpdateMiddlewares, sentryMiddlewares.MessageUpdateMiddleware)
 	b.MustAddMiddleware(sentryMiddlewares.Middleware)
 	b.MustAddPostMiddleware(sentryMiddlewares.PostMiddleware)
 
 	b.MustAddMiddleware(zaplog.NewMiddlewares(zap.S()))
+	b.MustAddMiddleware(errhandler.PanicMiddleware())
 }
 
-func addPlugins(b *bot.Bot) {
+func addPlugins(
+	b *bot.Bot, bundle *i18nimpl.Bundle, repo *repository.Repository, localizer func(discord.GuildID) *i18n.Localizer,
+) error {
 	b.AddCommand(help.New(help.Options{}))
+
+	coverage, err := i18nwrapper.Coverage(bundle, *translationsPath)
+	if err != nil {
+		return errors.Wrap(err, "unable to compute translation coverage")
+	}
+
+	b.AddCommand(languages.New(bundle, coverage))
+
+	caseLog := moderation.NewCaseLog(b.State, repo, localizer)
+	b.State.MustAddHandler(caseLog.IngestAuditLog())
+
+	expirer := moderation.NewExpirer(b.State, repo, caseLog)
+	if err := expirer.Start(); err != nil {
+		return errors.Wrap(err, "unable to schedule temporary punishments")
+	}
+
+	raidGuard := moderation.NewRaidGuard(b.State, repo, caseLog, localizer)
+	if err := raidGuard.Start(); err != nil {
+		return errors.Wrap(err, "unable to restore raid modes")
+	}
+
+	b.State.MustAddHandler(raidGuard.Handler())
+
+	rolePersistence := moderation.NewRolePersistence(b.State, repo)
+	for _, h := range rolePersistence.Handlers() {
+		b.State.MustAddHandler(h)
+	}
+
+	b.AddModule(moderation.New(expirer, caseLog, raidGuard))
+
+	verificationGate, err := moderation.NewVerificationGate(b.State, repo, raidGuard, caseLog, localizer)
+	if err != nil {
+		return errors.Wrap(err, "unable to create verification gate")
+	}
+
+	if err := verificationGate.Start(); err != nil {
+		return errors.Wrap(err, "unable to restore pending verifications")
+	}
+
+	for _, h := range verificationGate.Handlers() {
+		b.State.MustAddHandler(h)
+	}
+
+	// captcha codes must neither be traced nor routed
+	b.MessageCreateMiddlewares = append([]interface{}{verificationGate.Middleware()}, b.MessageCreateMiddlewares...)
+
+	automod, err := moderation.NewAutomod(b.State, expirer, caseLog)
+	if err != nil {
+		return errors.Wrap(err, "unable to create automod")
+	}
+
+	// automod must run before all other middlewares, so that deleted
+	// messages are neither traced nor routed
+	b.MessageCreateMiddlewares = append([]interface{}{automod.Middleware()}, b.MessageCreateMiddlewares...)
+	b.MessageUpdateMiddlewares = append([]interface{}{automod.UpdateMiddleware()}, b.MessageUpdateMiddlewares...)
+
+	eventLogger := eventlog.New(b.State, localizer)
+	for _, h := range eventLogger.Handlers() {
+		b.State.MustAddHandler(h)
+	}
+
+	return nil
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/bot"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/i18nwrapper"
+)
+
+// newSettingsProvider creates the bot.SettingsProvider used by levin.
+// It uses the default prefixes from the config, and the passed function to
+// obtain the localizer of the guild.
+func newSettingsProvider(localizer func(discord.GuildID) *i18n.Localizer) bot.SettingsProvider {
+	return func(_ *state.Base, m *discord.Message) ([]string, *i18n.Localizer) {
+		return config.C.DefaultPrefixes, localizer(m.GuildID)
+	}
+}
+
+// newLocalizerFunc creates a function that returns the *i18n.Localizer used
+// for the guild with the passed id.
+//
+// If pseudo is true, all localizers localize to i18nwrapper.PseudoLocale.
+// Otherwise, the language is the first available of the language configured
+// for the guild in config.C.Languages.Guilds, the preferred locale of the
+// guild and config.C.Languages.Default.
+func newLocalizerFunc(
+	s *state.State, bundle *i18nimpl.Bundle, pseudo bool,
+) func(discord.GuildID) *i18n.Localizer {
+	var funcs sync.Map // map[string]i18n.Func
+
+	return func(guildID discord.GuildID) *i18n.Localizer {
+		lang := i18nwrapper.PseudoLocale
+		if !pseudo {
+			lang = guildLanguage(s, guildID)
+		}
+
+		f, ok := funcs.Load(lang)
+		if !ok {
+			f, _ = funcs.LoadOrStore(lang, i18nwrapper.FuncForBundle(bundle, lang))
+		}
+
+		return i18n.NewLocalizer(lang, f.(i18n.Func))
+	}
+}
+
+// guildLanguage returns the language used in the guild with the passed id.
+func guildLanguage(s *state.State, guildID discord.GuildID) string {
+	if !guildID.IsValid() {
+		return config.C.Languages.Default
+	}
+
+	if lang := config.C.Languages.Guilds[guildID]; len(lang) > 0 {
+		return lang
+	}
+
+	if g, err := s.Guild(guildID); err == nil && len(g.PreferredLocale) > 0 {
+		return g.PreferredLocale
+	}
+
+	return config.C.Languages.Default
+}
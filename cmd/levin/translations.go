@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mavolin/levin/internal/i18nwrapper"
+)
+
+const translationsUsage = "usage: levin translations export|import [flags]"
+
+// runTranslations runs the translations subcommand, used to convert levin's
+// translation files from and to the exchange formats used by translation
+// platforms.
+//
+//	levin translations export --format po|xliff --lang de [--out de.po]
+//	levin translations import --format po|xliff [--in de.po] [--out dir]
+func runTranslations(args []string) error {
+	if len(args) == 0 {
+		return errors.New(translationsUsage)
+	}
+
+	fs := flag.NewFlagSet("translations "+args[0], flag.ExitOnError)
+	format := fs.String("format", string(i18nwrapper.PO), "The exchange format, either po or xliff.")
+	customPath := fs.String("translations", *translationsPath,
+		"A path to a directory containing additional translation files.")
+
+	switch args[0] {
+	case "export":
+		lang := fs.String("lang", "", "The language to export.")
+		out := fs.String("out", "", "The file to write to. If not set, the export is written to stdout.")
+
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if len(*lang) == 0 {
+			return errors.New("translations export: missing -lang")
+		}
+
+		return exportTranslations(i18nwrapper.Format(*format), *lang, *customPath, *out)
+	case "import":
+		in := fs.String("in", "", "The file to import. If not set, it is read from stdin.")
+		out := fs.String("out", "", "The directory to write the translation file to. "+
+			"If not set, the -translations directory is used.")
+
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if len(*out) == 0 {
+			*out = *customPath
+		}
+
+		if len(*out) == 0 {
+			return errors.New("translations import: missing -out")
+		}
+
+		return importTranslations(i18nwrapper.Format(*format), *in, *out)
+	default:
+		return errors.New(translationsUsage)
+	}
+}
+
+func exportTranslations(format i18nwrapper.Format, lang, customPath, out string) error {
+	var w io.Writer = os.Stdout
+
+	if len(out) > 0 {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		w = f
+	}
+
+	return i18nwrapper.Export(w, format, lang, customPath)
+}
+
+func importTranslations(format i18nwrapper.Format, in, outDir string) error {
+	var r io.Reader = os.Stdin
+
+	if len(in) > 0 {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	var buf bytes.Buffer
+
+	// keep the translations of terms that aren't part of the import
+	lang, fuzzy, err := i18nwrapper.Import(&buf, r, format, outDir)
+	if err != nil {
+		return err
+	}
+
+	for _, term := range fuzzy {
+		log.With("term", term).
+			Warn("skipping fuzzy translation, review it and import again")
+	}
+
+	path := filepath.Join(outDir, lang.String()+".json")
+
+	log.With("path", path).
+		Info("writing imported translations")
+
+	return os.WriteFile(path, buf.Bytes(), 0o644) //nolint:gosec
+}
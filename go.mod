@@ -12,6 +12,7 @@ require (
 	github.com/nicksnyder/go-i18n/v2 v2.1.2
 	github.com/spf13/jwalterweatherman v1.0.0
 	github.com/spf13/viper v1.7.1
+	go.etcd.io/bbolt v1.3.5
 	go.uber.org/zap v1.10.0
 	golang.org/x/text v0.3.3
 )
@@ -33,15 +33,281 @@ type config struct { //nolint:maligned
 	EditAge  time.Duration `mapstructure:"edit_age"`
 	AllowBot bool          `mapstructure:"allow_bot"`
 
+	Languages struct {
+		Default   string
+		Fallbacks map[string][]string
+		Guilds    map[discord.GuildID]string
+	}
+
+	Moderation struct {
+		MuteRoles    map[discord.GuildID]discord.RoleID `mapstructure:"mute_roles"`
+		MuteRoleName string                             `mapstructure:"mute_role_name"`
+
+		LogChannels map[discord.GuildID]discord.ChannelID `mapstructure:"log_channels"`
+
+		Warnings map[discord.GuildID]WarningPolicy
+		Automod  map[discord.GuildID]AutomodConfig
+		Raids    map[discord.GuildID]RaidConfig
+
+		RolePersistence map[discord.GuildID]RolePersistenceConfig `mapstructure:"role_persistence"`
+		Verification    map[discord.GuildID]VerificationConfig
+	}
+
+	EventLog struct {
+		// MessageCacheSize is the maximum number of messages cached, to show
+		// the content of edited and deleted messages.
+		MessageCacheSize int `mapstructure:"message_cache_size"`
+		Guilds           map[discord.GuildID]EventLogConfig
+	} `mapstructure:"event_log"`
+
+	Log struct {
+		Format string
+		Level  string
+	}
+
 	Sentry struct {
+		Enabled bool
+
 		DSN         string
 		Environment string
 
+		Debug            bool
+		AttachStacktrace bool `mapstructure:"attach_stacktrace"`
+
 		SampleRate       float64 `mapstructure:"sample_rate"`
 		TracesSampleRate float64 `mapstructure:"traces_sample_rate"`
+
+		MaxBreadcrumbs int      `mapstructure:"max_breadcrumbs"`
+		IgnoreErrors   []string `mapstructure:"ignore_errors"`
+		InAppInclude   []string `mapstructure:"in_app_include"`
+		BeforeSend     []struct {
+			ExceptionType string `mapstructure:"exception_type"`
+			Message       string
+			Tags          map[string]string
+		} `mapstructure:"before_send"`
+
+		// CaptureErrors are the error classes, that are captured in addition
+		// to internal errors, which are always captured.
+		CaptureErrors []string `mapstructure:"capture_errors"`
+
+		DedupWindow        time.Duration `mapstructure:"dedup_window"`
+		MaxEventsPerMinute int           `mapstructure:"max_events_per_minute"`
+
+		SendUsernames bool `mapstructure:"send_usernames"`
+
+		// Sessions enables release health sessions, which require levin to
+		// be built with a version.
+		Sessions bool
 	}
 
-	ServerName string `mapstructure:"server_name"`
+	StackTraceDepth int `mapstructure:"stack_trace_depth"`
+
+	DatabasePath string `mapstructure:"database_path"`
+
+	ServerName  string `mapstructure:"server_name"`
+	MetricsAddr string `mapstructure:"metrics_addr"`
+}
+
+// WarningPolicy is the warning policy of a guild.
+type WarningPolicy struct {
+	// Expiry is the time after which warnings expire, and are no longer
+	// counted towards escalations.
+	// If Expiry is 0, warnings never expire.
+	Expiry time.Duration
+	// Escalations are the actions taken automatically, once a member
+	// reaches a certain number of warnings.
+	Escalations []Escalation
+}
+
+// Escalation is an action taken automatically, once a member reaches a
+// certain number of warnings.
+type Escalation struct {
+	// Warnings is the number of warnings needed to trigger the escalation.
+	Warnings int
+	// Period is the period in which the warnings must have been issued.
+	// If Period is 0, all warnings that haven't expired are counted.
+	Period time.Duration
+	// Action is the action taken, either mute, kick, or ban.
+	Action string
+	// Duration is the duration of a mute or ban.
+	// If Duration is 0, the mute or ban is permanent.
+	Duration time.Duration
+}
+
+// AutomodConfig is the automod configuration of a guild.
+// Filters that are not set are disabled.
+type AutomodConfig struct {
+	// ExemptRoles are the roles whose members are exempt from automod.
+	ExemptRoles []discord.RoleID `mapstructure:"exempt_roles"`
+	// ExemptChannels are the channels exempt from automod.
+	ExemptChannels []discord.ChannelID `mapstructure:"exempt_channels"`
+
+	Spam     *SpamFilter
+	Mentions *MentionFilter
+	// Invites filters Discord invites by their code.
+	Invites *ListFilter
+	// Links filters links by their domain, including its subdomains.
+	Links *ListFilter
+	Words []WordFilter
+}
+
+// AutomodActions are the actions taken, if an automod filter is triggered.
+type AutomodActions struct {
+	// Actions are the actions taken, any of delete, warn, timeout, and log.
+	Actions []string
+	// TimeoutDuration is the duration of timeouts.
+	// If TimeoutDuration is 0, timeouts are permanent.
+	TimeoutDuration time.Duration `mapstructure:"timeout_duration"`
+}
+
+// SpamFilter filters users sending too many messages in a channel.
+type SpamFilter struct {
+	AutomodActions `mapstructure:",squash"`
+	// Messages is the maximum number of messages a user may send in a
+	// channel within Interval.
+	Messages int
+	Interval time.Duration
+}
+
+// MentionFilter filters messages mentioning too many users and roles.
+type MentionFilter struct {
+	AutomodActions `mapstructure:",squash"`
+	// Max is the maximum number of users and roles a message may mention.
+	// Mentions of @everyone and @here count as one mention.
+	Max int
+}
+
+// ListFilter filters items using an allow and a deny list.
+// If Deny is empty, all items not in Allow are filtered.
+// Otherwise, only items in Deny that are not in Allow are filtered.
+type ListFilter struct {
+	AutomodActions `mapstructure:",squash"`
+	Allow          []string
+	Deny           []string
+}
+
+// WordFilter filters messages containing words.
+// Both the message and the words are normalized, i.e. lowercased and
+// translated from leetspeak, before matching.
+type WordFilter struct {
+	AutomodActions `mapstructure:",squash"`
+	// Name is the name of the filter, used in logs.
+	Name string
+	// Wildcards are the filtered words, in which '*' matches any number of
+	// non-space characters.
+	Wildcards []string
+	// Regexps are filtered regular expressions.
+	// They are matched against both the original and the normalized
+	// message.
+	Regexps []string
+}
+
+// RaidConfig is the anti-raid configuration of a guild.
+// Checks whose threshold is 0 are disabled.
+type RaidConfig struct {
+	// Interval is the interval in which joins are counted.
+	Interval time.Duration
+	// Joins is the maximum number of members that may join within Interval.
+	Joins int
+	// MinAccountAge is the minimum age of an account, for it not to be
+	// considered new.
+	MinAccountAge time.Duration `mapstructure:"min_account_age"`
+	// NewAccountJoins is the maximum number of members with new accounts
+	// that may join within Interval.
+	NewAccountJoins int `mapstructure:"new_account_joins"`
+	// SimilarJoins is the maximum number of members with similar usernames
+	// or the same avatar that may join within Interval.
+	SimilarJoins int `mapstructure:"similar_joins"`
+
+	// Verification is the verification level set during raid mode, from 1
+	// (low) to 4 (very high).
+	// If Verification is 0, the verification level is not changed.
+	Verification discord.Verification
+	// Action is the action taken against members joining during raid mode,
+	// either kick or quarantine.
+	// If Action is empty, no action is taken.
+	Action string
+	// QuarantineRole is the role given to quarantined members.
+	QuarantineRole discord.RoleID `mapstructure:"quarantine_role"`
+
+	// AlertChannel is the channel moderators are alerted in.
+	// It defaults to the mod-log channel.
+	AlertChannel discord.ChannelID `mapstructure:"alert_channel"`
+	// AlertRoles are the roles mentioned in alerts.
+	AlertRoles []discord.RoleID `mapstructure:"alert_roles"`
+
+	// Cooldown is the time without joins, after which automatically enabled
+	// raid mode is disabled.
+	Cooldown time.Duration
+}
+
+// RolePersistenceConfig is the role persistence configuration of a guild.
+// Mute and quarantine roles are always restored, if a member rejoins within
+// the retention period.
+type RolePersistenceConfig struct {
+	// Retention is the time after leaving, during which the roles of a member
+	// are restored, if they rejoin.
+	// It defaults to 30 days.
+	Retention time.Duration
+	// AllRoles specifies whether all roles are restored.
+	AllRoles bool `mapstructure:"all_roles"`
+	// Roles are the roles restored in addition to mute and quarantine roles.
+	Roles []discord.RoleID
+}
+
+// VerificationConfig is the configuration of the verification gate of a
+// guild.
+// New members get UnverifiedRole, and must pass a challenge to be verified.
+type VerificationConfig struct {
+	// Challenge is the challenge new members must pass, either reaction,
+	// button or captcha.
+	// Button challenges are not supported yet, and fall back to reaction.
+	// It defaults to reaction.
+	Challenge string
+	// RaidChallenge is the challenge used during raid mode.
+	// It defaults to Challenge.
+	RaidChallenge string `mapstructure:"raid_challenge"`
+	// Channel is the channel challenges are sent in.
+	// If Channel is not set, challenges are sent via DM.
+	Channel discord.ChannelID
+	// UnverifiedRole is the role given to new members, until they pass the
+	// challenge.
+	UnverifiedRole discord.RoleID `mapstructure:"unverified_role"`
+	// VerifiedRole is the role given to members that passed the challenge.
+	// If VerifiedRole is not set, only UnverifiedRole is removed.
+	VerifiedRole discord.RoleID `mapstructure:"verified_role"`
+	// Timeout is the time new members have to pass the challenge, before
+	// they are kicked.
+	// It defaults to 10 minutes.
+	Timeout time.Duration
+	// Attempts is the number of attempts new members have to solve a
+	// captcha, before they are kicked.
+	// It defaults to 3.
+	Attempts int
+}
+
+// EventLogConfig is the event log configuration of a guild.
+// Events whose channel is not set are logged to the Default channel, or not
+// at all, if Default is not set either.
+type EventLogConfig struct {
+	Default discord.ChannelID
+
+	MessageEdits   discord.ChannelID `mapstructure:"message_edits"`
+	MessageDeletes discord.ChannelID `mapstructure:"message_deletes"`
+	BulkDeletes    discord.ChannelID `mapstructure:"bulk_deletes"`
+
+	Joins     discord.ChannelID
+	Leaves    discord.ChannelID
+	Bans      discord.ChannelID
+	Nicknames discord.ChannelID
+	Roles     discord.ChannelID
+
+	Channels discord.ChannelID
+	Voice    discord.ChannelID
+
+	// IgnoredChannels are the channels whose messages are not logged.
+	// Messages in log channels are never logged.
+	IgnoredChannels []discord.ChannelID `mapstructure:"ignored_channels"`
 }
 
 // Zero sets all config fields to their zero values.
@@ -50,7 +316,10 @@ func Zero() { C = config{} }
 // Load loads the config.
 // If configPath is not empty, the config at that path will be loaded, instead
 // of searching in the current directory, ./config and $CONFIG_DIR/
-func Load(configPath string) error {
+//
+// If debug is true, the defaults of the log and sentry settings are those
+// suited for development.
+func Load(configPath string, debug bool) error {
 	v := viper.New()
 
 	v.SetEnvPrefix("levin")
@@ -69,7 +338,7 @@ func Load(configPath string) error {
 		v.SetConfigName("levin")
 	}
 
-	loadDefaults(v)
+	loadDefaults(v, debug)
 
 	err := v.ReadInConfig()
 	if err != nil && (!errors.As(err, new(viper.ConfigFileNotFoundError)) || len(configPath) > 0) {
@@ -123,9 +392,33 @@ func bindEnvs(v *viper.Viper, val reflect.Type, base string) error {
 	return nil
 }
 
-func loadDefaults(v *viper.Viper) {
+func loadDefaults(v *viper.Viper, debug bool) {
 	v.SetDefault("allow_bot", false)
 	v.SetDefault("edit_age", 15 /* seconds */)
+	v.SetDefault("languages.default", "en")
+	v.SetDefault("moderation.mute_role_name", "Muted")
+	v.SetDefault("event_log.message_cache_size", 10000)
+
+	if debug {
+		v.SetDefault("log.format", "console")
+		v.SetDefault("log.level", "debug")
+		v.SetDefault("sentry.environment", "development")
+	} else {
+		v.SetDefault("log.format", "json")
+		v.SetDefault("log.level", "info")
+		v.SetDefault("sentry.environment", "production")
+	}
+
+	// sentry discards all events, if no dsn is set
+	v.SetDefault("sentry.enabled", true)
+	v.SetDefault("sentry.max_breadcrumbs", 30)
+	v.SetDefault("sentry.in_app_include", []string{"github.com/mavolin/levin"})
+	v.SetDefault("sentry.dedup_window", 60 /* seconds */)
+	v.SetDefault("sentry.max_events_per_minute", 30)
+	v.SetDefault("sentry.send_usernames", false)
+	v.SetDefault("sentry.sessions", true)
+	v.SetDefault("stack_trace_depth", 50)
+	v.SetDefault("database_path", "levin.db")
 }
 
 func unmarshal(v *viper.Viper) error {
@@ -134,6 +427,7 @@ func unmarshal(v *viper.Viper) error {
 	}
 
 	C.EditAge = time.Duration(v.GetInt("edit_age")) * time.Second
+	C.Sentry.DedupWindow = time.Duration(v.GetInt("sentry.dedup_window")) * time.Second
 	C.ActivityType, C.ActivtyName = parseActivity(v.GetString("activity"))
 	C.Status = validateStatus(gateway.Status(v.GetString("status")))
 
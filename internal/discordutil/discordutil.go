@@ -0,0 +1,20 @@
+// Package discordutil provides utilities for formatting discord entities.
+package discordutil
+
+import "github.com/diamondburned/arikawa/v2/discord"
+
+// Truncate truncates s to max runes, replacing the last rune with an
+// ellipsis, if s is longer.
+func Truncate(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+
+	return s
+}
+
+// UserTag returns the tag of the passed user, i.e. their username and
+// discriminator.
+func UserTag(u discord.User) string {
+	return u.Username + "#" + u.Discriminator
+}
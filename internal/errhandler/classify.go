@@ -0,0 +1,97 @@
+package errhandler
+
+import (
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/plugin"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// ErrorClass is the class of an error returned by a command.
+type ErrorClass string
+
+const (
+	// ClassInternal is the class of internal errors, i.e. all errors that
+	// aren't caused by the user.
+	ClassInternal ErrorClass = "internal"
+	// ClassArgument is the class of *plugin.ArgumentErrors.
+	ClassArgument ErrorClass = "argument"
+	// ClassRestriction is the class of *plugin.RestrictionErrors and
+	// *plugin.ChannelTypeErrors.
+	ClassRestriction ErrorClass = "restriction"
+	// ClassThrottling is the class of *plugin.ThrottlingErrors.
+	ClassThrottling ErrorClass = "throttling"
+	// ClassBotPermissions is the class of *plugin.BotPermissionsErrors.
+	ClassBotPermissions ErrorClass = "bot_permissions"
+	// ClassUser is the class of *errors.UserErrors, *errors.UserInfos, and
+	// *errors.InformationalErrors.
+	ClassUser ErrorClass = "user"
+)
+
+// Classify returns the ErrorClass of the passed error.
+func Classify(err error) ErrorClass {
+	switch {
+	case errors.As(err, new(*plugin.ArgumentError)):
+		return ClassArgument
+	case errors.As(err, new(*plugin.RestrictionError)), errors.As(err, new(*plugin.ChannelTypeError)):
+		return ClassRestriction
+	case errors.As(err, new(*plugin.ThrottlingError)):
+		return ClassThrottling
+	case errors.As(err, new(*plugin.BotPermissionsError)):
+		return ClassBotPermissions
+	case errors.As(err, new(*errors.UserError)), errors.As(err, new(*errors.UserInfo)),
+		errors.As(err, new(*errors.InformationalError)):
+		return ClassUser
+	default:
+		return ClassInternal
+	}
+}
+
+// SentryPolicy specifies how errors are reported to sentry.
+type SentryPolicy uint8
+
+const (
+	// SentrySkip doesn't report the error.
+	SentrySkip SentryPolicy = iota
+	// SentryBreadcrumb adds a breadcrumb to the hub of the invoke, so that the
+	// error is reported, if another error is captured during the same invoke.
+	SentryBreadcrumb
+	// SentryCapture captures the error.
+	SentryCapture
+)
+
+type classPolicy struct {
+	level  zapcore.Level
+	sentry SentryPolicy
+}
+
+// defaultPolicies are the policies used for the error classes, if the class
+// isn't listed in config.C.Sentry.CaptureErrors.
+//
+// Internal errors, including panics, are always captured.
+var defaultPolicies = map[ErrorClass]classPolicy{
+	ClassInternal:       {level: zapcore.ErrorLevel, sentry: SentryCapture},
+	ClassArgument:       {level: zapcore.DebugLevel, sentry: SentrySkip},
+	ClassRestriction:    {level: zapcore.DebugLevel, sentry: SentrySkip},
+	ClassThrottling:     {level: zapcore.InfoLevel, sentry: SentryBreadcrumb},
+	ClassBotPermissions: {level: zapcore.WarnLevel, sentry: SentryBreadcrumb},
+	ClassUser:           {level: zapcore.DebugLevel, sentry: SentrySkip},
+}
+
+// policyFor returns the policy for the passed ErrorClass.
+func policyFor(class ErrorClass) classPolicy {
+	p, ok := defaultPolicies[class]
+	if !ok {
+		p = defaultPolicies[ClassInternal]
+	}
+
+	for _, c := range config.C.Sentry.CaptureErrors {
+		if ErrorClass(c) == class {
+			p.sentry = SentryCapture
+			break
+		}
+	}
+
+	return p
+}
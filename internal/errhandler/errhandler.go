@@ -2,15 +2,14 @@
 package errhandler
 
 import (
-	"runtime"
-	"strconv"
-	"strings"
-
 	"github.com/getsentry/sentry-go"
 	"github.com/mavolin/adam/pkg/bot"
 	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"github.com/mavolin/levin/internal/metrics"
 	sentryadam "github.com/mavolin/levin/internal/sentry"
 	"github.com/mavolin/levin/internal/zaplog"
 )
@@ -18,48 +17,101 @@ import (
 // CommandError returns the logger function used for errors.Log.
 // It logs the error using the passed *zap.SugaredLogger, and extracts the
 // assigned *sentry.Hub and *zap.SugaredLogger from the context using sentryadam.GetHub.
+//
+// Since errors.Log is only called for internal and silent errors, all errors
+// are reported as ClassInternal.
 func CommandError() func(error, *plugin.Context) {
 	return func(err error, ctx *plugin.Context) {
-		sentryadam.GetHub(ctx).CaptureException(err)
-
-		l := zaplog.Get(ctx).With("err", err)
+		report(ClassInternal, err, ctx)
+	}
+}
 
-		if serr, ok := err.(interface{ StackTrace() []uintptr }); ok {
-			l.With("stack_trace", writtenStack(serr.StackTrace()))
+// ErrorHandler returns the error handler used for bot.Options.ErrorHandler.
+//
+// It reports all errors that are not of ClassInternal according to the
+// policy of their class, and then hands them to bot.DefaultErrorHandler.
+// Internal errors are reported by CommandError, once they are logged by
+// bot.DefaultErrorHandler.
+func ErrorHandler() func(error, *state.State, *plugin.Context) {
+	return func(err error, s *state.State, ctx *plugin.Context) {
+		if class := Classify(err); class != ClassInternal {
+			report(class, err, ctx)
+		} else {
+			ctx.Set(errKey, err)
 		}
 
-		l.Error("error during command execution")
+		bot.DefaultErrorHandler(err, s, ctx)
 	}
 }
 
-func writtenStack(callers []uintptr) string {
-	frames := runtime.CallersFrames(callers)
+// report logs and reports the passed error according to the policy of its
+// class, and records it in metrics.CommandErrors.
+func report(class ErrorClass, err error, ctx *plugin.Context) {
+	metrics.CommandErrors.Add(string(class), 1)
+
+	p := policyFor(class)
+	stack := stackTrace(err, ctx)
+
+	switch p.sentry {
+	case SentrySkip:
+	case SentryBreadcrumb:
+		sentryadam.GetHub(ctx).AddBreadcrumb(&sentry.Breadcrumb{
+			Type:     "error",
+			Category: "command.error",
+			Message:  err.Error(),
+			Data:     map[string]interface{}{"class": class},
+			Level:    sentryLevel(p.level),
+		}, nil)
+	case SentryCapture:
+		captureException(sentryadam.GetHub(ctx), err, stack)
+	}
 
-	first := true
+	l := zaplog.Get(ctx).With("err", err, "err_class", class, zap.Array("err_chain", newErrorChain(err)))
 
-	var b strings.Builder
-	b.Grow(1024)
+	if len(stack) > 0 {
+		l = l.With(zap.Array("stack_trace", newStackFrames(stack)))
+	}
 
-	// the last frame is skipped, as it only contains runtime information
-	for frame, more := frames.Next(); more; frame, more = frames.Next() {
-		if first {
-			b.WriteByte('\n')
-			first = false
-		}
+	if class == ClassInternal {
+		logAt(l, p.level, "error during command execution")
+	} else {
+		logAt(l, p.level, "user error during command execution")
+	}
+}
 
-		b.WriteString(frame.Function)
-		b.WriteRune('\n')
-		b.WriteRune('\t')
-		b.WriteString(frame.File)
-		b.WriteRune(':')
-		b.WriteString(strconv.Itoa(frame.Line))
+func logAt(l *zap.SugaredLogger, lvl zapcore.Level, msg string) {
+	switch lvl {
+	case zapcore.DebugLevel:
+		l.Debug(msg)
+	case zapcore.InfoLevel:
+		l.Info(msg)
+	case zapcore.WarnLevel:
+		l.Warn(msg)
+	default:
+		l.Error(msg)
 	}
+}
 
-	return b.String()
+func sentryLevel(lvl zapcore.Level) sentry.Level {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return sentry.LevelDebug
+	case zapcore.InfoLevel:
+		return sentry.LevelInfo
+	case zapcore.WarnLevel:
+		return sentry.LevelWarning
+	default:
+		return sentry.LevelError
+	}
 }
 
 // Gateway returns the error handler function used for the
 // bot.Options.GatwayErrorHandler.
+//
+// Errors are deduplicated and rate limited using the limits from
+// config.C.Sentry.
+// Suppressed occurrences are summarized once their window expired, and when
+// Stop is called.
 func Gateway(l *zap.SugaredLogger, h *sentry.Hub) func(error) {
 	l = l.Named("gateway")
 
@@ -68,16 +120,23 @@ func Gateway(l *zap.SugaredLogger, h *sentry.Hub) func(error) {
 		s.SetTag("err_source", "gateway")
 	})
 
+	lim := newLimiter()
+	startFlushing(l, h, lim)
+
 	return func(err error) {
 		if bot.FilterGatewayError(err) {
-			h.CaptureException(err)
-			l.Error(err)
+			reportLimited(l, h, lim, err)
 		}
 	}
 }
 
 // StateError returns the logger function used for the
 // bot.Option.StateErrorHandler.
+//
+// Errors are deduplicated and rate limited using the limits from
+// config.C.Sentry.
+// Suppressed occurrences are summarized once their window expired, and when
+// Stop is called.
 func StateError(l *zap.SugaredLogger, h *sentry.Hub) func(error) {
 	l = l.Named("state")
 
@@ -86,9 +145,11 @@ func StateError(l *zap.SugaredLogger, h *sentry.Hub) func(error) {
 		s.SetTag("err_source", "state")
 	})
 
+	lim := newLimiter()
+	startFlushing(l, h, lim)
+
 	return func(err error) {
-		h.CaptureException(err)
-		l.Error(err)
+		reportLimited(l, h, lim, err)
 	}
 }
 
@@ -0,0 +1,289 @@
+package errhandler
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// numberRegexp matches numbers in error messages, such as ids, sequence
+// numbers, or ports, that would otherwise make equal errors distinct.
+var numberRegexp = regexp.MustCompile(`\d+`)
+
+// fingerprint returns the fingerprint of the passed error, consisting of its
+// type and its normalized message.
+func fingerprint(err error) string {
+	return fmt.Sprintf("%T: %s", err, numberRegexp.ReplaceAllString(err.Error(), "N"))
+}
+
+type (
+	// limiter deduplicates errors by their fingerprint, and limits the number
+	// of reported errors using a token bucket.
+	limiter struct {
+		mutex sync.Mutex
+
+		window time.Duration
+		seen   map[string]*occurrence
+
+		// capacity is the size of the token bucket, and the number of tokens
+		// refilled per minute.
+		capacity   float64
+		tokens     float64
+		lastRefill time.Time
+	}
+
+	occurrence struct {
+		// reported is the time the error was last reported.
+		reported time.Time
+		// suppressed is the number of occurrences since the error was last
+		// reported.
+		suppressed int
+	}
+
+	// suppressedSummary is the summary of the occurrences of an error, that
+	// were suppressed during a window.
+	suppressedSummary struct {
+		fingerprint string
+		count       int
+	}
+)
+
+// newLimiter creates a new limiter using the limits from config.C.Sentry.
+func newLimiter() *limiter {
+	capacity := float64(config.C.Sentry.MaxEventsPerMinute)
+
+	return &limiter{
+		window:     config.C.Sentry.DedupWindow,
+		seen:       make(map[string]*occurrence),
+		capacity:   capacity,
+		tokens:     capacity,
+		lastRefill: time.Now(),
+	}
+}
+
+// allow checks if the passed error should be reported.
+// If so, it returns the number of suppressed occurrences of the error since
+// it was last reported.
+//
+// Additionally, it returns the summaries of all errors whose window expired
+// with suppressed occurrences, and which haven't occurred since.
+func (l *limiter) allow(err error) (ok bool, suppressed int, expired []suppressedSummary) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	fp := fingerprint(err)
+
+	expired = l.expire(now, fp)
+
+	o, seen := l.seen[fp]
+	if seen && now.Sub(o.reported) < l.window {
+		o.suppressed++
+		return false, 0, expired
+	}
+
+	if !l.take(now) {
+		if !seen {
+			// count it, so that it gets summarized
+			l.seen[fp] = &occurrence{reported: now, suppressed: 1}
+		} else {
+			o.suppressed++
+		}
+
+		return false, 0, expired
+	}
+
+	if seen {
+		suppressed = o.suppressed
+	}
+
+	l.seen[fp] = &occurrence{reported: now}
+
+	return true, suppressed, expired
+}
+
+// expire removes all occurrences whose window expired, except the one with
+// the passed fingerprint, and returns the summaries of those with suppressed
+// occurrences.
+func (l *limiter) expire(now time.Time, except string) (expired []suppressedSummary) {
+	for fp, o := range l.seen {
+		if fp == except || now.Sub(o.reported) < l.window {
+			continue
+		}
+
+		if o.suppressed > 0 {
+			expired = append(expired, suppressedSummary{fingerprint: fp, count: o.suppressed})
+		}
+
+		delete(l.seen, fp)
+	}
+
+	return expired
+}
+
+// take refills the token bucket and attempts to take a token from it.
+// If the limiter has a capacity of 0, take always succeeds.
+func (l *limiter) take(now time.Time) bool {
+	if l.capacity <= 0 {
+		return true
+	}
+
+	l.tokens += now.Sub(l.lastRefill).Minutes() * l.capacity
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+
+	l.lastRefill = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// flushInterval returns the interval in which summaries of expired windows
+// are flushed.
+func (l *limiter) flushInterval() time.Duration {
+	if l.window > 0 {
+		return l.window
+	}
+
+	// without deduplication, occurrences are only suppressed by the token
+	// bucket, which is refilled per minute
+	return time.Minute
+}
+
+// flush removes all occurrences whose window expired, and returns the
+// summaries of those with suppressed occurrences.
+// If all is true, the windows of all occurrences are considered expired.
+func (l *limiter) flush(all bool) []suppressedSummary {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	if all {
+		now = now.Add(l.window)
+	}
+
+	return l.expire(now, "")
+}
+
+// allowSummaries checks if summaries may be reported, by taking a token from
+// the token bucket.
+func (l *limiter) allowSummaries() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.take(time.Now())
+}
+
+var (
+	// stopFlushing is closed by Stop, to stop flushing summaries.
+	stopFlushing = make(chan struct{})
+	stopOnce     sync.Once
+	// flushing tracks the running flushSummaries goroutines.
+	flushing sync.WaitGroup
+)
+
+// startFlushing starts flushing the summaries of the passed limiter, until
+// Stop is called.
+func startFlushing(l *zap.SugaredLogger, h *sentry.Hub, lim *limiter) {
+	flushing.Add(1)
+	go flushSummaries(l, h, lim)
+}
+
+// Stop stops flushing the summaries of suppressed errors, and reports the
+// summaries of all errors with suppressed occurrences, regardless of whether
+// their window expired.
+// It blocks until all summaries are reported.
+//
+// Stop should be called before exiting, and before flushing sentry.
+func Stop() {
+	stopOnce.Do(func() { close(stopFlushing) })
+	flushing.Wait()
+}
+
+// flushSummaries periodically reports the summaries of the errors whose
+// window expired, so that suppressed occurrences are reported, even if no
+// other error occurs afterwards.
+//
+// It returns once Stop is called, after reporting all remaining summaries.
+func flushSummaries(l *zap.SugaredLogger, h *sentry.Hub, lim *limiter) {
+	defer flushing.Done()
+
+	t := time.NewTicker(lim.flushInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			reportSummaries(l, h, lim, lim.flush(false))
+		case <-stopFlushing:
+			reportSummaries(l, h, lim, lim.flush(true))
+			return
+		}
+	}
+}
+
+// reportSummaries logs the passed summaries, and captures them as a single
+// event, if the passed limiter allows it.
+func reportSummaries(l *zap.SugaredLogger, h *sentry.Hub, lim *limiter, summaries []suppressedSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	var total int
+
+	counts := make(map[string]int, len(summaries))
+
+	for _, s := range summaries {
+		l.With("fingerprint", s.fingerprint, "suppressed_occurrences", s.count).
+			Errorf("%d more occurrences of error", s.count)
+
+		counts[s.fingerprint] = s.count
+		total += s.count
+	}
+
+	if !lim.allowSummaries() {
+		return
+	}
+
+	sh := h.Clone()
+	sh.Scope().SetLevel(sentry.LevelError)
+	sh.Scope().SetFingerprint([]string{"suppressed_occurrences"})
+	sh.Scope().SetExtra("suppressed_occurrences", counts)
+	sh.CaptureMessage(fmt.Sprintf("%d more occurrences of %d errors", total, len(summaries)))
+}
+
+// reportLimited logs and captures the passed error, if the passed limiter
+// allows it.
+func reportLimited(l *zap.SugaredLogger, h *sentry.Hub, lim *limiter, err error) {
+	ok, suppressed, expired := lim.allow(err)
+
+	reportSummaries(l, h, lim, expired)
+
+	if !ok {
+		return
+	}
+
+	if suppressed == 0 {
+		h.CaptureException(err)
+		l.Error(err)
+		return
+	}
+
+	h = h.Clone()
+	h.Scope().SetExtra("suppressed_occurrences", suppressed)
+	h.CaptureException(err)
+
+	l.With("suppressed_occurrences", suppressed).
+		Errorf("%s (%d more occurrences)", err, suppressed)
+}
@@ -0,0 +1,72 @@
+package errhandler
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/mavolin/adam/pkg/bot"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	sentryadam "github.com/mavolin/levin/internal/sentry"
+	"github.com/mavolin/levin/internal/zaplog"
+)
+
+// panicError is the error used to report a recovered panic.
+type panicError struct {
+	recovered interface{}
+	stack     []uintptr
+}
+
+func (e *panicError) Error() string { return fmt.Sprintf("panic: %+v", e.recovered) }
+
+// Unwrap returns the recovered value, if it is an error.
+func (e *panicError) Unwrap() error {
+	err, _ := e.recovered.(error)
+	return err
+}
+
+func (e *panicError) StackTrace() []uintptr { return e.stack }
+
+// PanicMiddleware returns a bot.MiddlewareFunc that recovers from panics in
+// the middlewares added after it, and in the command itself.
+//
+// Recovered panics are reported as ClassInternal errors using the *sentry.Hub
+// and the logger of the invoke, and the user is sent an internal error
+// message.
+// Therefore, PanicMiddleware must be added after the middlewares of
+// sentryadam and zaplog.
+func PanicMiddleware() bot.MiddlewareFunc {
+	return func(next bot.CommandFunc) bot.CommandFunc {
+		return func(s *state.State, ctx *plugin.Context) error {
+			defer func() {
+				if rec := recover(); rec != nil {
+					handlePanic(rec, ctx)
+				}
+			}()
+
+			return next(s, ctx)
+		}
+	}
+}
+
+func handlePanic(rec interface{}, ctx *plugin.Context) {
+	// skip runtime.Callers, handlePanic, and the deferred func
+	stack := make([]uintptr, 64)
+	stack = stack[:runtime.Callers(3, stack)]
+
+	sentryadam.FinishPanicked(ctx)
+
+	report(ClassInternal, &panicError{recovered: rec, stack: stack}, ctx)
+
+	embed := errors.NewErrorEmbed().
+		WithSimpleTitlel(internalErrorTitle).
+		WithDescriptionl(internalErrorDescription)
+
+	if _, err := ctx.ReplyEmbedBuilder(embed); err != nil {
+		zaplog.Get(ctx).
+			With("err", err).
+			Warn("unable to send internal error message after recovering from panic")
+	}
+}
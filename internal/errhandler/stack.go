@@ -0,0 +1,190 @@
+package errhandler
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/plugin"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// errKey is the key under which ErrorHandler stores internal errors, so that
+// CommandError can access their stack trace.
+const errKey = "errhandler.err"
+
+// maxChainLength is the maximum number of causes followed when unwrapping an
+// error, to prevent error cycles.
+const maxChainLength = 16
+
+type stackTracer interface {
+	StackTrace() []uintptr
+}
+
+// stackTrace returns the stack trace of the passed error.
+//
+// errors.Log only receives the causes of *errors.InternalErrors and
+// *errors.SilentErrors, which usually don't have stack traces.
+// Therefore, if err has no stack trace, the stack trace of the error stored
+// by ErrorHandler is used.
+func stackTrace(err error, ctx *plugin.Context) []uintptr {
+	var st stackTracer
+	if errors.As(err, &st) {
+		return st.StackTrace()
+	}
+
+	if orig, ok := ctx.Get(errKey).(error); ok && errors.As(orig, &st) {
+		return st.StackTrace()
+	}
+
+	return nil
+}
+
+type (
+	stackFrame struct {
+		Function string
+		File     string
+		Line     int
+	}
+
+	stackFrames []stackFrame
+)
+
+var (
+	_ zapcore.ObjectMarshaler = stackFrame{}
+	_ zapcore.ArrayMarshaler  = stackFrames{}
+)
+
+// newStackFrames resolves the passed callers, starting with the most recent
+// call.
+// At most config.C.StackTraceDepth frames are returned, unless
+// StackTraceDepth is 0.
+func newStackFrames(callers []uintptr) stackFrames {
+	frames := runtime.CallersFrames(callers)
+
+	var fs stackFrames
+
+	// the last frame is skipped, as it only contains runtime information
+	for frame, more := frames.Next(); more; frame, more = frames.Next() {
+		if config.C.StackTraceDepth > 0 && len(fs) >= config.C.StackTraceDepth {
+			break
+		}
+
+		fs = append(fs, stackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+	}
+
+	return fs
+}
+
+func (f stackFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("function", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+
+	return nil
+}
+
+func (fs stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range fs {
+		if err := enc.AppendObject(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sentryStacktrace converts the frames to a *sentry.Stacktrace.
+func (fs stackFrames) sentryStacktrace() *sentry.Stacktrace {
+	st := &sentry.Stacktrace{Frames: make([]sentry.Frame, len(fs))}
+
+	// sentry expects the most recent call last
+	for i, f := range fs {
+		st.Frames[len(fs)-1-i] = sentry.NewFrame(runtime.Frame{Function: f.Function, File: f.File, Line: f.Line})
+	}
+
+	return st
+}
+
+type (
+	errorLink struct {
+		Type    string
+		Message string
+	}
+
+	// errorChain is the chain of causes of an error, starting with the error
+	// itself.
+	errorChain []errorLink
+)
+
+var (
+	_ zapcore.ObjectMarshaler = errorLink{}
+	_ zapcore.ArrayMarshaler  = errorChain{}
+)
+
+func newErrorChain(err error) errorChain {
+	var chain errorChain
+
+	for ; err != nil && len(chain) < maxChainLength; err = errors.Unwrap(err) {
+		chain = append(chain, errorLink{Type: fmt.Sprintf("%T", err), Message: err.Error()})
+	}
+
+	return chain
+}
+
+func (l errorLink) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", l.Type)
+	enc.AddString("message", l.Message)
+
+	return nil
+}
+
+func (c errorChain) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, l := range c {
+		if err := enc.AppendObject(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// captureException captures the passed error using the passed hub.
+// Unlike hub.CaptureException, it reports the passed stack trace, limited to
+// config.C.StackTraceDepth frames, and uses the types of the causes as
+// exception types.
+func captureException(h *sentry.Hub, err error, stack []uintptr) {
+	client := h.Client()
+	if client == nil {
+		return
+	}
+
+	chain := newErrorChain(err)
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Exception = make([]sentry.Exception, len(chain))
+
+	for i, cause := 0, err; i < len(chain); i, cause = i+1, errors.Unwrap(cause) {
+		e := sentry.Exception{Type: chain[i].Type, Value: chain[i].Message}
+
+		var callers []uintptr
+		if st, ok := cause.(stackTracer); ok { //nolint:errorlint
+			callers = st.StackTrace()
+		} else if i == 0 {
+			callers = stack
+		}
+
+		if len(callers) > 0 {
+			e.Stacktrace = newStackFrames(callers).sentryStacktrace()
+		}
+
+		// sentry expects the most recent error last
+		event.Exception[len(chain)-1-i] = e
+	}
+
+	client.CaptureEvent(event, &sentry.EventHint{OriginalException: err}, h.Scope())
+}
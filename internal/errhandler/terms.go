@@ -0,0 +1,15 @@
+package errhandler
+
+import "github.com/mavolin/adam/pkg/i18n"
+
+// =============================================================================
+// Panic
+// =====================================================================================
+
+// the terms of adam's internal error are reused, so that the message is
+// identical to the one sent for internal errors
+var (
+	internalErrorTitle       = i18n.NewFallbackConfig("error.internal.title", "Internal Error")
+	internalErrorDescription = i18n.NewFallbackConfig("error.internal.description.default",
+		"Oh no! Something went wrong and I couldn't finish executing your command. Try again in a bit.")
+)
@@ -0,0 +1,95 @@
+package eventlog
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+// messageCache is a least recently used cache of messages, used to show the
+// content of edited and deleted messages.
+type messageCache struct {
+	mutex sync.Mutex
+	size  int
+	// order holds the cached messages, with the most recently used at its
+	// front.
+	order    *list.List
+	messages map[discord.MessageID]*list.Element
+}
+
+func newMessageCache(size int) *messageCache {
+	return &messageCache{
+		size:     size,
+		order:    list.New(),
+		messages: make(map[discord.MessageID]*list.Element, size),
+	}
+}
+
+// put adds the passed message to the cache, replacing the cached version, if
+// there is one.
+// If the cache is full, the least recently used message is evicted.
+func (c *messageCache) put(m discord.Message) {
+	if c.size <= 0 {
+		return
+	}
+
+	// only keep what is needed in logs
+	m = discord.Message{
+		ID:          m.ID,
+		ChannelID:   m.ChannelID,
+		GuildID:     m.GuildID,
+		Author:      m.Author,
+		Content:     m.Content,
+		Attachments: m.Attachments,
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.messages[m.ID]; ok {
+		e.Value = m
+		c.order.MoveToFront(e)
+
+		return
+	}
+
+	c.messages[m.ID] = c.order.PushFront(m)
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.messages, oldest.Value.(discord.Message).ID)
+	}
+}
+
+// get returns the cached message with the passed id.
+func (c *messageCache) get(id discord.MessageID) (discord.Message, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.messages[id]
+	if !ok {
+		return discord.Message{}, false
+	}
+
+	c.order.MoveToFront(e)
+	return e.Value.(discord.Message), true
+}
+
+// remove removes the message with the passed id from the cache, and returns
+// it, if it was cached.
+func (c *messageCache) remove(id discord.MessageID) (discord.Message, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.messages[id]
+	if !ok {
+		return discord.Message{}, false
+	}
+
+	c.order.Remove(e)
+	delete(c.messages, id)
+
+	return e.Value.(discord.Message), true
+}
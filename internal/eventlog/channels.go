@@ -0,0 +1,237 @@
+package eventlog
+
+import (
+	"strconv"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+// =============================================================================
+// Channels
+// =====================================================================================
+
+func (l *Logger) channelCreate(_ *state.State, e *state.ChannelCreateEvent) {
+	if !e.GuildID.IsValid() {
+		return
+	}
+
+	l.send(e.GuildID, channelChange, func(loc *localizer) discord.Embed {
+		return discord.Embed{
+			Title:       loc.localize(channelCreateTitle),
+			Description: channelField(e.Channel),
+			Color:       createColor,
+			Timestamp:   discord.NowTimestamp(),
+		}
+	})
+}
+
+func (l *Logger) channelUpdate(_ *state.State, e *state.ChannelUpdateEvent) {
+	if !e.GuildID.IsValid() || e.Old == nil || !channelChanged(*e.Old, e.Channel) {
+		return
+	}
+
+	old := *e.Old
+
+	l.send(e.GuildID, channelChange, func(loc *localizer) discord.Embed {
+		var fields []discord.EmbedField
+
+		change := func(name, before, after string) {
+			if len(before) == 0 {
+				before = "-"
+			}
+
+			if len(after) == 0 {
+				after = "-"
+			}
+
+			fields = append(fields, discord.EmbedField{
+				Name:  name,
+				Value: discordutil.Truncate(before+" → "+after, maxFieldLength),
+			})
+		}
+
+		if old.Name != e.Name {
+			change(loc.localize(nameField), old.Name, e.Name)
+		}
+
+		if old.Topic != e.Topic {
+			change(loc.localize(topicField), old.Topic, e.Topic)
+		}
+
+		if old.NSFW != e.NSFW {
+			change(loc.localize(nsfwField), strconv.FormatBool(old.NSFW), strconv.FormatBool(e.NSFW))
+		}
+
+		if old.UserRateLimit != e.UserRateLimit {
+			change(loc.localize(slowmodeField), seconds(old.UserRateLimit), seconds(e.UserRateLimit))
+		}
+
+		if old.CategoryID != e.CategoryID {
+			change(loc.localize(categoryField), channelMention(old.CategoryID), channelMention(e.CategoryID))
+		}
+
+		if !equalOverwrites(old.Permissions, e.Permissions) {
+			fields = append(fields, discord.EmbedField{
+				Name:  loc.localize(permissionsField),
+				Value: loc.localize(permissionsChanged),
+			})
+		}
+
+		return discord.Embed{
+			Title:       loc.localize(channelUpdateTitle),
+			Description: channelField(e.Channel),
+			Color:       updateColor,
+			Timestamp:   discord.NowTimestamp(),
+			Fields:      fields,
+		}
+	})
+}
+
+func (l *Logger) channelDelete(_ *state.State, e *state.ChannelDeleteEvent) {
+	if !e.GuildID.IsValid() {
+		return
+	}
+
+	l.send(e.GuildID, channelChange, func(loc *localizer) discord.Embed {
+		return discord.Embed{
+			Title:       loc.localize(channelDeleteTitle),
+			Description: "#" + e.Name + " (" + e.ID.String() + ")",
+			Color:       deleteColor,
+			Timestamp:   discord.NowTimestamp(),
+		}
+	})
+}
+
+// channelChanged checks if any of the logged properties of the channel
+// changed.
+// Position changes are not logged, since moving a channel changes the
+// positions of all channels below it.
+func channelChanged(old, c discord.Channel) bool {
+	return old.Name != c.Name || old.Topic != c.Topic || old.NSFW != c.NSFW ||
+		old.UserRateLimit != c.UserRateLimit || old.CategoryID != c.CategoryID ||
+		!equalOverwrites(old.Permissions, c.Permissions)
+}
+
+func equalOverwrites(a, b []discord.Overwrite) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, oa := range a {
+		found := false
+
+		for _, ob := range b {
+			if oa == ob {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func channelField(c discord.Channel) string {
+	return c.ID.Mention() + " (" + c.Name + ")"
+}
+
+func channelMention(id discord.ChannelID) string {
+	if !id.IsValid() {
+		return ""
+	}
+
+	return id.Mention()
+}
+
+func seconds(s discord.Seconds) string {
+	if s == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(int(s)) + "s"
+}
+
+// =============================================================================
+// Voice
+// =====================================================================================
+
+func (l *Logger) guildCreate(_ *state.State, e *state.GuildCreateEvent) {
+	l.voiceMutex.Lock()
+	defer l.voiceMutex.Unlock()
+
+	for _, vs := range e.VoiceStates {
+		if vs.ChannelID.IsValid() {
+			l.voice[voiceKey{guildID: e.ID, userID: vs.UserID}] = vs.ChannelID
+		}
+	}
+}
+
+func (l *Logger) voiceStateUpdate(_ *state.State, e *state.VoiceStateUpdateEvent) {
+	if !e.GuildID.IsValid() {
+		return
+	}
+
+	key := voiceKey{guildID: e.GuildID, userID: e.UserID}
+
+	l.voiceMutex.Lock()
+
+	old := l.voice[key]
+	if e.ChannelID.IsValid() {
+		l.voice[key] = e.ChannelID
+	} else {
+		delete(l.voice, key)
+	}
+
+	l.voiceMutex.Unlock()
+
+	// mutes, deafens, and streams also cause voice state updates
+	if old == e.ChannelID {
+		return
+	}
+
+	var user discord.User
+	if e.Member != nil {
+		user = e.Member.User
+	} else {
+		user.ID = e.UserID
+	}
+
+	l.send(e.GuildID, voiceChange, func(loc *localizer) discord.Embed {
+		embed := discord.Embed{
+			Color:     voiceColor,
+			Timestamp: discord.NowTimestamp(),
+			Footer:    idFooter(e.UserID),
+		}
+
+		if e.Member != nil {
+			embed.Author = authorOf(user)
+		}
+
+		placeholders := voicePlaceholders{
+			User:   user.Mention(),
+			Before: channelMention(old),
+			After:  channelMention(e.ChannelID),
+		}
+
+		switch {
+		case !old.IsValid():
+			embed.Title = loc.localize(voiceJoinTitle)
+			embed.Description = loc.localize(voiceJoinDescription.WithPlaceholders(placeholders))
+		case !e.ChannelID.IsValid():
+			embed.Title = loc.localize(voiceLeaveTitle)
+			embed.Description = loc.localize(voiceLeaveDescription.WithPlaceholders(placeholders))
+		default:
+			embed.Title = loc.localize(voiceMoveTitle)
+			embed.Description = loc.localize(voiceMoveDescription.WithPlaceholders(placeholders))
+		}
+
+		return embed
+	})
+}
@@ -0,0 +1,206 @@
+// Package eventlog provides logging of message, member, channel, and voice
+// events to the log channels configured in config.C.EventLog.
+package eventlog
+
+import (
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/sendpart"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// eventType is the type of a logged event, used to route it to its log
+// channel.
+type eventType uint8
+
+const (
+	messageEdit eventType = iota
+	messageDelete
+	bulkDelete
+	memberJoin
+	memberLeave
+	memberBan
+	nicknameChange
+	roleChange
+	channelChange
+	voiceChange
+)
+
+// The colors of the logged events.
+const (
+	createColor discord.Color = 0x2ecc71
+	updateColor discord.Color = 0xf1c40f
+	deleteColor discord.Color = 0xe74c3c
+	banColor    discord.Color = 0x992d22
+	voiceColor  discord.Color = 0x3498db
+)
+
+type (
+	// Logger logs events to the log channels of their guild.
+	Logger struct {
+		s         *state.State
+		localizer func(discord.GuildID) *i18n.Localizer
+
+		messages *messageCache
+
+		voiceMutex sync.Mutex
+		// voice are the voice channels members are connected to.
+		voice map[voiceKey]discord.ChannelID
+	}
+
+	voiceKey struct {
+		guildID discord.GuildID
+		userID  discord.UserID
+	}
+)
+
+// New creates a new *Logger, that uses the passed function to get the
+// *i18n.Localizer of a guild.
+func New(s *state.State, localizer func(discord.GuildID) *i18n.Localizer) *Logger {
+	return &Logger{
+		s:         s,
+		localizer: localizer,
+		messages:  newMessageCache(config.C.EventLog.MessageCacheSize),
+		voice:     make(map[voiceKey]discord.ChannelID),
+	}
+}
+
+// Handlers returns the event handlers of the Logger, which must all be added
+// to the State.
+func (l *Logger) Handlers() []interface{} {
+	return []interface{}{
+		l.messageCreate, l.messageUpdate, l.messageDelete, l.messageDeleteBulk,
+		l.memberAdd, l.memberRemove, l.memberUpdate, l.banAdd,
+		l.channelCreate, l.channelUpdate, l.channelDelete,
+		l.guildCreate, l.voiceStateUpdate,
+	}
+}
+
+func log(guildID discord.GuildID) *zap.SugaredLogger {
+	return zap.S().Named("eventlog").With("guild_id", guildID)
+}
+
+// channelFor returns the log channel events of the passed type are logged
+// to.
+func channelFor(guildID discord.GuildID, t eventType) (discord.ChannelID, bool) {
+	cfg, ok := config.C.EventLog.Guilds[guildID]
+	if !ok {
+		return 0, false
+	}
+
+	var channelID discord.ChannelID
+
+	switch t {
+	case messageEdit:
+		channelID = cfg.MessageEdits
+	case messageDelete:
+		channelID = cfg.MessageDeletes
+	case bulkDelete:
+		channelID = cfg.BulkDeletes
+	case memberJoin:
+		channelID = cfg.Joins
+	case memberLeave:
+		channelID = cfg.Leaves
+	case memberBan:
+		channelID = cfg.Bans
+	case nicknameChange:
+		channelID = cfg.Nicknames
+	case roleChange:
+		channelID = cfg.Roles
+	case channelChange:
+		channelID = cfg.Channels
+	case voiceChange:
+		channelID = cfg.Voice
+	}
+
+	if !channelID.IsValid() {
+		channelID = cfg.Default
+	}
+
+	return channelID, channelID.IsValid()
+}
+
+// ignored checks if messages sent in the passed channel are not logged,
+// either because the channel is ignored, or because it is a log channel.
+func ignored(guildID discord.GuildID, channelID discord.ChannelID) bool {
+	cfg, ok := config.C.EventLog.Guilds[guildID]
+	if !ok {
+		return true
+	}
+
+	for _, id := range cfg.IgnoredChannels {
+		if id == channelID {
+			return true
+		}
+	}
+
+	logChannels := []discord.ChannelID{
+		cfg.Default, cfg.MessageEdits, cfg.MessageDeletes, cfg.BulkDeletes, cfg.Joins, cfg.Leaves, cfg.Bans,
+		cfg.Nicknames, cfg.Roles, cfg.Channels, cfg.Voice,
+	}
+
+	for _, id := range logChannels {
+		if id == channelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// send sends the embed created by the passed function to the log channel of
+// the passed event type.
+// Errors are logged, as there is no one to report them to.
+func (l *Logger) send(guildID discord.GuildID, t eventType, f func(*localizer) discord.Embed) {
+	l.sendComplex(guildID, t, f, nil)
+}
+
+// sendComplex is the same as send, but also uploads the passed files.
+func (l *Logger) sendComplex(
+	guildID discord.GuildID, t eventType, f func(*localizer) discord.Embed, files []sendpart.File,
+) {
+	channelID, ok := channelFor(guildID, t)
+	if !ok {
+		return
+	}
+
+	loc := &localizer{l: l.localizer(guildID)}
+
+	embed := f(loc)
+	if loc.err != nil {
+		log(guildID).With("err", loc.err).
+			Error("unable to localize event log")
+		return
+	}
+
+	_, err := l.s.SendMessageComplex(channelID, api.SendMessageData{Embed: &embed, Files: files})
+	if err != nil {
+		log(guildID).With("err", err, "channel_id", channelID).
+			Warn("unable to send event log")
+	}
+}
+
+// localizer wraps an *i18n.Localizer, and remembers the first error that
+// occurred during localization, so that embeds can be built without
+// checking every term.
+type localizer struct {
+	l   *i18n.Localizer
+	err error
+}
+
+func (l *localizer) localize(cfg *i18n.Config) string {
+	if l.err != nil {
+		return ""
+	}
+
+	var s string
+	s, l.err = l.l.Localize(cfg)
+
+	return s
+}
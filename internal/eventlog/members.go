@@ -0,0 +1,195 @@
+package eventlog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+func (l *Logger) memberAdd(_ *state.State, e *state.GuildMemberAddEvent) {
+	l.send(e.GuildID, memberJoin, func(loc *localizer) discord.Embed {
+		created := e.User.ID.Time()
+
+		return discord.Embed{
+			Title:       loc.localize(memberJoinTitle),
+			Description: userField(e.User),
+			Color:       createColor,
+			Timestamp:   discord.NowTimestamp(),
+			Author:      authorOf(e.User),
+			Thumbnail:   &discord.EmbedThumbnail{URL: e.User.AvatarURL()},
+			Fields: []discord.EmbedField{
+				{
+					Name: loc.localize(accountCreatedField),
+					Value: loc.localize(accountCreatedValue.WithPlaceholders(accountCreatedPlaceholders{
+						Date: created.UTC().Format("2006-01-02 15:04"),
+						Age:  duration.Format(time.Since(created).Truncate(time.Minute)),
+					})),
+				},
+			},
+			Footer: idFooter(e.User.ID),
+		}
+	})
+}
+
+func (l *Logger) memberRemove(_ *state.State, e *state.GuildMemberRemoveEvent) {
+	l.send(e.GuildID, memberLeave, func(loc *localizer) discord.Embed {
+		embed := discord.Embed{
+			Title:       loc.localize(memberLeaveTitle),
+			Description: userField(e.User),
+			Color:       deleteColor,
+			Timestamp:   discord.NowTimestamp(),
+			Author:      authorOf(e.User),
+			Footer:      idFooter(e.User.ID),
+		}
+
+		if e.Old != nil && len(e.Old.RoleIDs) > 0 {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  loc.localize(rolesField),
+				Value: discordutil.Truncate(roleList(e.Old.RoleIDs), maxFieldLength),
+			})
+		}
+
+		return embed
+	})
+}
+
+func (l *Logger) banAdd(_ *state.State, e *state.GuildBanAddEvent) {
+	l.send(e.GuildID, memberBan, func(loc *localizer) discord.Embed {
+		return discord.Embed{
+			Title:       loc.localize(memberBanTitle),
+			Description: userField(e.User),
+			Color:       banColor,
+			Timestamp:   discord.NowTimestamp(),
+			Author:      authorOf(e.User),
+			Footer:      idFooter(e.User.ID),
+		}
+	})
+}
+
+func (l *Logger) memberUpdate(_ *state.State, e *state.GuildMemberUpdateEvent) {
+	// without the old member, there is nothing to compare with
+	if e.Old == nil {
+		return
+	}
+
+	if e.Old.Nick != e.Nick {
+		l.logNickname(e, e.Old.Nick)
+	}
+
+	added, removed := diffRoles(e.Old.RoleIDs, e.RoleIDs)
+	if len(added) > 0 || len(removed) > 0 {
+		l.logRoles(e, added, removed)
+	}
+}
+
+func (l *Logger) logNickname(e *state.GuildMemberUpdateEvent, old string) {
+	l.send(e.GuildID, nicknameChange, func(loc *localizer) discord.Embed {
+		none := loc.localize(noNickname)
+
+		before, after := old, e.Nick
+		if len(before) == 0 {
+			before = none
+		}
+
+		if len(after) == 0 {
+			after = none
+		}
+
+		return discord.Embed{
+			Title:       loc.localize(nicknameTitle),
+			Description: userField(e.User),
+			Color:       updateColor,
+			Timestamp:   discord.NowTimestamp(),
+			Author:      authorOf(e.User),
+			Fields: []discord.EmbedField{
+				{Name: loc.localize(beforeField), Value: before, Inline: true},
+				{Name: loc.localize(afterField), Value: after, Inline: true},
+			},
+			Footer: idFooter(e.User.ID),
+		}
+	})
+}
+
+func (l *Logger) logRoles(e *state.GuildMemberUpdateEvent, added, removed []discord.RoleID) {
+	l.send(e.GuildID, roleChange, func(loc *localizer) discord.Embed {
+		embed := discord.Embed{
+			Title:       loc.localize(rolesTitle),
+			Description: userField(e.User),
+			Color:       updateColor,
+			Timestamp:   discord.NowTimestamp(),
+			Author:      authorOf(e.User),
+			Footer:      idFooter(e.User.ID),
+		}
+
+		if len(added) > 0 {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  loc.localize(addedRolesField),
+				Value: discordutil.Truncate(roleList(added), maxFieldLength),
+			})
+		}
+
+		if len(removed) > 0 {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  loc.localize(removedRolesField),
+				Value: discordutil.Truncate(roleList(removed), maxFieldLength),
+			})
+		}
+
+		return embed
+	})
+}
+
+// diffRoles returns the roles that are in b but not in a, and those that
+// are in a but not in b.
+func diffRoles(a, b []discord.RoleID) (added, removed []discord.RoleID) {
+	contains := func(ids []discord.RoleID, id discord.RoleID) bool {
+		for _, cmp := range ids {
+			if cmp == id {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, id := range b {
+		if !contains(a, id) {
+			added = append(added, id)
+		}
+	}
+
+	for _, id := range a {
+		if !contains(b, id) {
+			removed = append(removed, id)
+		}
+	}
+
+	return added, removed
+}
+
+func roleList(ids []discord.RoleID) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = id.Mention()
+	}
+
+	return strings.Join(mentions, " ")
+}
+
+// userField returns the mention of the passed user, followed by their tag.
+func userField(u discord.User) string {
+	return u.Mention() + " (" + discordutil.UserTag(u) + ")"
+}
+
+func authorOf(u discord.User) *discord.EmbedAuthor {
+	return &discord.EmbedAuthor{Name: discordutil.UserTag(u), Icon: u.AvatarURL()}
+}
+
+func idFooter(id discord.UserID) *discord.EmbedFooter {
+	return &discord.EmbedFooter{Text: "ID: " + id.String()}
+}
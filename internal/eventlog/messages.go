@@ -0,0 +1,200 @@
+package eventlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/sendpart"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+// maxFieldLength is the maximum length of the value of an embed field.
+const maxFieldLength = 1024
+
+// cacheable checks if the passed message is cached, and its edits and
+// deletion are logged.
+func cacheable(m discord.Message) bool {
+	return m.GuildID.IsValid() && !m.Author.Bot && !m.WebhookID.IsValid() && !ignored(m.GuildID, m.ChannelID)
+}
+
+func (l *Logger) messageCreate(_ *state.State, e *state.MessageCreateEvent) {
+	if cacheable(e.Message) {
+		l.messages.put(e.Message)
+	}
+}
+
+func (l *Logger) messageUpdate(_ *state.State, e *state.MessageUpdateEvent) {
+	old, ok := l.messages.get(e.ID)
+	if !ok {
+		if e.Old == nil {
+			return
+		}
+
+		old = *e.Old
+	}
+
+	if !cacheable(old) {
+		return
+	}
+
+	// updates without content, e.g. those caused by embeds being added, are
+	// not edits
+	if len(e.Content) == 0 || e.Content == old.Content {
+		return
+	}
+
+	updated := old
+	updated.Content = e.Content
+	l.messages.put(updated)
+
+	// messages consisting only of attachments or embeds have no content
+	before := old.Content
+	if len(before) == 0 {
+		before = "-"
+	}
+
+	l.send(old.GuildID, messageEdit, func(loc *localizer) discord.Embed {
+		return discord.Embed{
+			Title: loc.localize(messageEditTitle),
+			Description: loc.localize(messageEditDescription.WithPlaceholders(messagePlaceholders{
+				User:    old.Author.Mention(),
+				Channel: old.ChannelID.Mention(),
+				URL:     messageURL(old),
+			})),
+			Color:     updateColor,
+			Timestamp: discord.NowTimestamp(),
+			Author:    authorOf(old.Author),
+			Fields: []discord.EmbedField{
+				{Name: loc.localize(beforeField), Value: discordutil.Truncate(before, maxFieldLength)},
+				{Name: loc.localize(afterField), Value: discordutil.Truncate(e.Content, maxFieldLength)},
+			},
+			Footer: idFooter(old.Author.ID),
+		}
+	})
+}
+
+func (l *Logger) messageDelete(_ *state.State, e *state.MessageDeleteEvent) {
+	m, ok := l.messages.remove(e.ID)
+	if !ok {
+		if e.Old == nil {
+			return
+		}
+
+		m = *e.Old
+	}
+
+	if !cacheable(m) {
+		return
+	}
+
+	l.send(m.GuildID, messageDelete, func(loc *localizer) discord.Embed {
+		embed := discord.Embed{
+			Title: loc.localize(messageDeleteTitle),
+			Description: loc.localize(messageDeleteDescription.WithPlaceholders(messagePlaceholders{
+				User:    m.Author.Mention(),
+				Channel: m.ChannelID.Mention(),
+			})),
+			Color:     deleteColor,
+			Timestamp: discord.NowTimestamp(),
+			Author:    authorOf(m.Author),
+			Footer:    idFooter(m.Author.ID),
+		}
+
+		if len(m.Content) > 0 {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  loc.localize(contentField),
+				Value: discordutil.Truncate(m.Content, maxFieldLength),
+			})
+		}
+
+		if len(m.Attachments) > 0 {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  loc.localize(attachmentsField),
+				Value: discordutil.Truncate(attachmentList(m.Attachments), maxFieldLength),
+			})
+		}
+
+		return embed
+	})
+}
+
+func (l *Logger) messageDeleteBulk(_ *state.State, e *state.MessageDeleteBulkEvent) {
+	if !e.GuildID.IsValid() || ignored(e.GuildID, e.ChannelID) {
+		return
+	}
+
+	old := make(map[discord.MessageID]discord.Message, len(e.Old))
+	for _, m := range e.Old {
+		old[m.ID] = m
+	}
+
+	ids := make([]discord.MessageID, len(e.IDs))
+	copy(ids, e.IDs)
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var b strings.Builder
+
+	for _, id := range ids {
+		m, ok := l.messages.remove(id)
+		if !ok {
+			m, ok = old[id]
+		}
+
+		writeTranscriptLine(&b, id, m, ok)
+	}
+
+	file := sendpart.File{
+		Name:   fmt.Sprintf("transcript-%d.txt", e.ChannelID),
+		Reader: strings.NewReader(b.String()),
+	}
+
+	l.sendComplex(e.GuildID, bulkDelete, func(loc *localizer) discord.Embed {
+		return discord.Embed{
+			Title: loc.localize(bulkDeleteTitle),
+			Description: loc.localize(bulkDeleteDescription.WithPlaceholders(bulkDeletePlaceholders{
+				Count:   len(e.IDs),
+				Channel: e.ChannelID.Mention(),
+			})),
+			Color:     deleteColor,
+			Timestamp: discord.NowTimestamp(),
+		}
+	}, []sendpart.File{file})
+}
+
+// writeTranscriptLine writes the transcript line of the message with the
+// passed id to b.
+// If the message is not known, only its id and time are written.
+func writeTranscriptLine(b *strings.Builder, id discord.MessageID, m discord.Message, known bool) {
+	b.WriteString("[" + id.Time().UTC().Format("2006-01-02 15:04:05") + "] ")
+
+	if !known {
+		b.WriteString("(" + id.String() + ", not cached)\n")
+		return
+	}
+
+	b.WriteString(discordutil.UserTag(m.Author) + " (" + m.Author.ID.String() + "): " + m.Content)
+
+	for _, a := range m.Attachments {
+		b.WriteString("\n    " + a.URL)
+	}
+
+	b.WriteByte('\n')
+}
+
+func messageURL(m discord.Message) string {
+	return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", m.GuildID, m.ChannelID, m.ID)
+}
+
+func attachmentList(as []discord.Attachment) string {
+	names := make([]string, len(as))
+	for i, a := range as {
+		names[i] = "[" + a.Filename + "](" + a.URL + ")"
+	}
+
+	return strings.Join(names, "\n")
+}
@@ -0,0 +1,108 @@
+package eventlog
+
+import "github.com/mavolin/adam/pkg/i18n"
+
+// =============================================================================
+// Messages
+// =====================================================================================
+
+var (
+	messageEditTitle       = i18n.NewFallbackConfig("eventlog.message_edit.title", "Message Edited")
+	messageEditDescription = i18n.NewFallbackConfig("eventlog.message_edit.description",
+		"{{.user}} edited [a message]({{.url}}) in {{.channel}}.")
+
+	messageDeleteTitle       = i18n.NewFallbackConfig("eventlog.message_delete.title", "Message Deleted")
+	messageDeleteDescription = i18n.NewFallbackConfig("eventlog.message_delete.description",
+		"A message by {{.user}} was deleted in {{.channel}}.")
+
+	bulkDeleteTitle       = i18n.NewFallbackConfig("eventlog.bulk_delete.title", "Messages Deleted")
+	bulkDeleteDescription = i18n.NewFallbackConfig("eventlog.bulk_delete.description",
+		"{{.count}} messages were deleted in {{.channel}}. The attached transcript contains the cached ones.")
+
+	beforeField      = i18n.NewFallbackConfig("eventlog.field.before", "Before")
+	afterField       = i18n.NewFallbackConfig("eventlog.field.after", "After")
+	contentField     = i18n.NewFallbackConfig("eventlog.field.content", "Content")
+	attachmentsField = i18n.NewFallbackConfig("eventlog.field.attachments", "Attachments")
+)
+
+type (
+	messagePlaceholders struct {
+		User    string
+		Channel string
+		URL     string
+	}
+
+	bulkDeletePlaceholders struct {
+		Count   int
+		Channel string
+	}
+)
+
+// =============================================================================
+// Members
+// =====================================================================================
+
+var (
+	memberJoinTitle  = i18n.NewFallbackConfig("eventlog.member_join.title", "Member Joined")
+	memberLeaveTitle = i18n.NewFallbackConfig("eventlog.member_leave.title", "Member Left")
+	memberBanTitle   = i18n.NewFallbackConfig("eventlog.member_ban.title", "Member Banned")
+	nicknameTitle    = i18n.NewFallbackConfig("eventlog.nickname.title", "Nickname Changed")
+	rolesTitle       = i18n.NewFallbackConfig("eventlog.roles.title", "Roles Changed")
+
+	accountCreatedField = i18n.NewFallbackConfig("eventlog.field.account_created", "Account Created")
+	accountCreatedValue = i18n.NewFallbackConfig("eventlog.field.account_created.value",
+		"{{.date}} UTC ({{.age}} ago)")
+	rolesField        = i18n.NewFallbackConfig("eventlog.field.roles", "Roles")
+	addedRolesField   = i18n.NewFallbackConfig("eventlog.field.added_roles", "Added Roles")
+	removedRolesField = i18n.NewFallbackConfig("eventlog.field.removed_roles", "Removed Roles")
+
+	noNickname = i18n.NewFallbackConfig("eventlog.nickname.none", "*none*")
+)
+
+type accountCreatedPlaceholders struct {
+	Date string
+	Age  string
+}
+
+// =============================================================================
+// Channels
+// =====================================================================================
+
+var (
+	channelCreateTitle = i18n.NewFallbackConfig("eventlog.channel_create.title", "Channel Created")
+	channelUpdateTitle = i18n.NewFallbackConfig("eventlog.channel_update.title", "Channel Updated")
+	channelDeleteTitle = i18n.NewFallbackConfig("eventlog.channel_delete.title", "Channel Deleted")
+
+	nameField          = i18n.NewFallbackConfig("eventlog.field.name", "Name")
+	topicField         = i18n.NewFallbackConfig("eventlog.field.topic", "Topic")
+	nsfwField          = i18n.NewFallbackConfig("eventlog.field.nsfw", "NSFW")
+	slowmodeField      = i18n.NewFallbackConfig("eventlog.field.slowmode", "Slowmode")
+	categoryField      = i18n.NewFallbackConfig("eventlog.field.category", "Category")
+	permissionsField   = i18n.NewFallbackConfig("eventlog.field.permissions", "Permissions")
+	permissionsChanged = i18n.NewFallbackConfig("eventlog.field.permissions.changed",
+		"The permission overwrites were changed.")
+)
+
+// =============================================================================
+// Voice
+// =====================================================================================
+
+var (
+	voiceJoinTitle       = i18n.NewFallbackConfig("eventlog.voice_join.title", "Voice Channel Joined")
+	voiceJoinDescription = i18n.NewFallbackConfig("eventlog.voice_join.description",
+		"{{.user}} joined {{.after}}.")
+
+	voiceLeaveTitle       = i18n.NewFallbackConfig("eventlog.voice_leave.title", "Voice Channel Left")
+	voiceLeaveDescription = i18n.NewFallbackConfig("eventlog.voice_leave.description",
+		"{{.user}} left {{.before}}.")
+
+	voiceMoveTitle       = i18n.NewFallbackConfig("eventlog.voice_move.title", "Voice Channel Switched")
+	voiceMoveDescription = i18n.NewFallbackConfig("eventlog.voice_move.description",
+		"{{.user}} moved from {{.before}} to {{.after}}.")
+)
+
+type voicePlaceholders struct {
+	User   string
+	Before string
+	After  string
+}
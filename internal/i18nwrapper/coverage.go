@@ -0,0 +1,62 @@
+package i18nwrapper
+
+import (
+	"sort"
+
+	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+// LanguageCoverage is the translation coverage of a single language.
+type LanguageCoverage struct {
+	Lang language.Tag
+	// Translated is the number of English terms translated to Lang.
+	Translated int
+	// Total is the number of English terms.
+	Total int
+}
+
+// Percent returns the percentage of translated terms, rounded down.
+func (c LanguageCoverage) Percent() int {
+	if c.Total == 0 {
+		return 100
+	}
+
+	return c.Translated * 100 / c.Total
+}
+
+// Coverage computes the LanguageCoverage of all languages loaded into the
+// passed bundle, sorted by their tag.
+// customPath is the path to the custom translations passed to Load.
+func Coverage(b *i18nimpl.Bundle, customPath string) ([]LanguageCoverage, error) {
+	source, err := readDefinitions(sourceLanguage, customPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := b.LanguageTags()
+	coverage := make([]LanguageCoverage, 0, len(tags))
+
+	for _, tag := range tags {
+		defs, err := readDefinitions(tag, customPath)
+		if err != nil {
+			return nil, err
+		}
+
+		c := LanguageCoverage{Lang: tag, Total: len(source)}
+
+		for term := range source {
+			if _, ok := defs[term]; ok {
+				c.Translated++
+			}
+		}
+
+		coverage = append(coverage, c)
+	}
+
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].Lang.String() < coverage[j].Lang.String()
+	})
+
+	return coverage, nil
+}
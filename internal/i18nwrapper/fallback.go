@@ -0,0 +1,79 @@
+package i18nwrapper
+
+import (
+	"strings"
+
+	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// FallbackChain returns the languages that are tried in order, when
+// localizing a term in the passed language.
+//
+// If config.C.Languages.Fallbacks contains an entry for lang, the chain
+// consists of lang followed by the languages from that entry.
+// Otherwise, the chain consists of lang, followed by its parent languages,
+// e.g. de-AT → de.
+// In both cases, the default language of the bundle is appended, if it isn't
+// already part of the chain.
+//
+// All languages the bundle has no translations for are removed from the
+// chain.
+func FallbackChain(b *i18nimpl.Bundle, lang string) []language.Tag {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		tag = language.Und
+	}
+
+	chain := []language.Tag{tag}
+
+	if fallbacks, ok := configFallbacks(tag); ok {
+		for _, fallback := range fallbacks {
+			if t, err := language.Parse(fallback); err == nil {
+				chain = append(chain, t)
+			}
+		}
+	} else {
+		for p := tag.Parent(); p != language.Und; p = p.Parent() {
+			chain = append(chain, p)
+		}
+	}
+
+	bundleTags := b.LanguageTags()
+	// the first tag of a bundle is always its default language
+	chain = append(chain, bundleTags[0])
+
+	filtered := make([]language.Tag, 0, len(chain))
+
+	for _, t := range chain {
+		if containsTag(bundleTags, t) && !containsTag(filtered, t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered
+}
+
+// configFallbacks returns the fallbacks configured for the passed tag.
+func configFallbacks(tag language.Tag) ([]string, bool) {
+	for lang, fallbacks := range config.C.Languages.Fallbacks {
+		// viper lowercases all keys, so we can't compare directly
+		if strings.EqualFold(lang, tag.String()) {
+			return fallbacks, true
+		}
+	}
+
+	return nil, false
+}
+
+func containsTag(tags []language.Tag, t language.Tag) bool {
+	for _, cmp := range tags {
+		if cmp == t {
+			return true
+		}
+	}
+
+	return false
+}
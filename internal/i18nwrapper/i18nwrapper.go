@@ -3,23 +3,81 @@
 package i18nwrapper
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/mavolin/adam/pkg/i18n"
 	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
 	"go.uber.org/zap"
+	"golang.org/x/text/language"
+
+	"github.com/mavolin/levin/internal/metrics"
 )
 
 func log() *zap.SugaredLogger { return zap.S().Named("startup") }
 
+func i18nLog() *zap.SugaredLogger { return zap.S().Named("i18n") }
+
 // FuncForBundle returns a i18n.Func that localizes to the passed language,
 // using the passed *i18nimpl.Bundle.
+//
+// If a term is missing in the passed language, the languages of the
+// FallbackChain are tried in order.
+// Every fallback is recorded in metrics.TranslationFallbacks.
+//
+// Terms defined using the ICU MessageFormat syntax are rendered using the
+// plural rules and number formatting of the language they were found in.
+// The plural count is available as argument plural_count, unless a
+// placeholder with that name exists.
+//
+// If lang is PseudoLocale, the returned i18n.Func pseudo-localizes the
+// strings of the default language of the bundle.
 func FuncForBundle(b *i18nimpl.Bundle, lang string) i18n.Func {
-	l := i18nimpl.NewLocalizer(b, lang)
-
-	return func(term i18n.Term, placeholders map[string]interface{}, plural interface{}) (string, error) {
-		return l.Localize(&i18nimpl.LocalizeConfig{
-			MessageID:    string(term),
-			TemplateData: placeholders,
-			PluralCount:  plural,
-		})
+	if lang == PseudoLocale {
+		return pseudoFunc(b)
+	}
+
+	chain := FallbackChain(b, lang)
+	requested, _ := language.Parse(lang)
+
+	localizers := make([]*i18nimpl.Localizer, len(chain))
+	for i, tag := range chain {
+		localizers[i] = i18nimpl.NewLocalizer(b, tag.String())
 	}
+
+	return func(term i18n.Term, placeholders map[string]interface{}, plural interface{}) (s string, err error) {
+		for i, l := range localizers {
+			s, err = l.Localize(&i18nimpl.LocalizeConfig{
+				MessageID:    string(term),
+				TemplateData: placeholders,
+				PluralCount:  plural,
+			})
+			if err == nil {
+				if chain[i] != requested {
+					recordFallback(term, lang, chain[i])
+				}
+
+				if strings.HasPrefix(s, icuMarker) {
+					return renderICU(chain[i], s[len(icuMarker):], placeholders, plural)
+				}
+
+				return s, nil
+			}
+
+			var nfErr *i18nimpl.MessageNotFoundErr
+			if !errors.As(err, &nfErr) {
+				return s, err
+			}
+		}
+
+		return s, err
+	}
+}
+
+func recordFallback(term i18n.Term, requested string, used language.Tag) {
+	metrics.TranslationFallbacks.Add(requested+">"+used.String(), 1)
+
+	i18nLog().
+		With("term", term, "requested_lang", requested, "used_lang", used.String()).
+		Debug("term missing in requested language, used fallback")
 }
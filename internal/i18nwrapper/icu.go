@@ -0,0 +1,738 @@
+package i18nwrapper
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// ICU MessageFormat messages are stored in the bundle prefixed with
+// icuMarker, and with delimiters that never occur in a message, so that
+// go-i18n returns them verbatim.
+// FuncForBundle then renders them using the placeholders.
+const (
+	icuMarker     = "\x00icu\x00"
+	icuLeftDelim  = "\x00\x01"
+	icuRightDelim = "\x01\x00"
+)
+
+// icuPluralCountArg is the name of the argument the plural count is
+// available under, if no placeholder with that name exists.
+const icuPluralCountArg = "plural_count"
+
+var (
+	// icuRegexp matches the arguments of ICU MessageFormat messages.
+	icuRegexp = regexp.MustCompile(
+		`\{\s*[\pL\d_]+\s*(?:,\s*(?:plural|select|selectordinal|number|date|time)\s*[,}]|\})`)
+
+	// icuCache caches the parsed icuMessages by their source.
+	icuCache sync.Map // map[string]icuMessage
+)
+
+// isICU checks if the passed definition is an ICU MessageFormat message.
+// Definitions using Go template actions are never ICU messages.
+func isICU(s string) bool {
+	return !strings.Contains(s, "{{") && icuRegexp.MatchString(s)
+}
+
+// parseICUCached parses the passed ICU message, or returns the cached parsed
+// message, if it was parsed before.
+func parseICUCached(src string) (icuMessage, error) {
+	if msg, ok := icuCache.Load(src); ok {
+		return msg.(icuMessage), nil
+	}
+
+	msg, err := parseICU(src)
+	if err != nil {
+		return nil, err
+	}
+
+	icuCache.Store(src, msg)
+	return msg, nil
+}
+
+// renderICU renders the passed ICU message source in the passed language.
+func renderICU(
+	tag language.Tag, src string, placeholders map[string]interface{}, pluralCount interface{},
+) (string, error) {
+	msg, err := parseICUCached(src)
+	if err != nil {
+		return "", err
+	}
+
+	if pluralCount != nil {
+		if _, ok := placeholders[icuPluralCountArg]; !ok {
+			args := make(map[string]interface{}, len(placeholders)+1)
+			for k, v := range placeholders {
+				args[k] = v
+			}
+
+			args[icuPluralCountArg] = pluralCount
+			placeholders = args
+		}
+	}
+
+	ctx := &icuContext{tag: tag, args: placeholders, printer: message.NewPrinter(tag)}
+
+	var b strings.Builder
+	if err := msg.render(&b, ctx); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// =============================================================================
+// AST
+// =====================================================================================
+
+type (
+	icuMessage []icuNode
+
+	icuNode interface {
+		render(b *strings.Builder, ctx *icuContext) error
+	}
+
+	icuContext struct {
+		tag     language.Tag
+		args    map[string]interface{}
+		printer *message.Printer
+		// pound is the number # is replaced with, if inside a plural.
+		pound *float64
+	}
+
+	icuText string
+
+	// icuPound is the # inside a plural sub-message.
+	icuPound struct{}
+
+	icuArg struct{ name string }
+
+	icuNumber struct{ name, style string }
+
+	icuDate struct {
+		name string
+		time bool
+		// style is either short, medium, long or full.
+		style string
+	}
+
+	icuSelect struct {
+		name  string
+		cases map[string]icuMessage
+	}
+
+	icuPlural struct {
+		name    string
+		ordinal bool
+		offset  float64
+		// exact are the cases for exact values, e.g. =0.
+		exact map[float64]icuMessage
+		cases map[string]icuMessage
+	}
+)
+
+func (m icuMessage) render(b *strings.Builder, ctx *icuContext) error {
+	for _, n := range m {
+		if err := n.render(b, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t icuText) render(b *strings.Builder, _ *icuContext) error {
+	b.WriteString(string(t))
+	return nil
+}
+
+func (icuPound) render(b *strings.Builder, ctx *icuContext) error {
+	if ctx.pound == nil {
+		b.WriteByte('#')
+		return nil
+	}
+
+	b.WriteString(ctx.printer.Sprint(number.Decimal(*ctx.pound)))
+	return nil
+}
+
+func (a icuArg) render(b *strings.Builder, ctx *icuContext) error {
+	v, err := ctx.arg(a.name)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		b.WriteString(v)
+	case time.Time:
+		b.WriteString(formatICUDate(ctx.tag, v, false, "medium"))
+	default:
+		if n, ok := toFloat(v); ok {
+			b.WriteString(ctx.printer.Sprint(number.Decimal(n)))
+		} else {
+			b.WriteString(fmt.Sprint(v))
+		}
+	}
+
+	return nil
+}
+
+func (n icuNumber) render(b *strings.Builder, ctx *icuContext) error {
+	v, err := ctx.number(n.name)
+	if err != nil {
+		return err
+	}
+
+	switch n.style {
+	case "integer":
+		b.WriteString(ctx.printer.Sprint(number.Decimal(v, number.MaxFractionDigits(0))))
+	case "percent":
+		b.WriteString(ctx.printer.Sprint(number.Percent(v)))
+	default:
+		b.WriteString(ctx.printer.Sprint(number.Decimal(v)))
+	}
+
+	return nil
+}
+
+func (d icuDate) render(b *strings.Builder, ctx *icuContext) error {
+	v, err := ctx.arg(d.name)
+	if err != nil {
+		return err
+	}
+
+	var t time.Time
+
+	switch v := v.(type) {
+	case time.Time:
+		t = v
+	case interface{ Time() time.Time }:
+		t = v.Time()
+	default:
+		return fmt.Errorf("i18nwrapper: argument %s is not a time", d.name)
+	}
+
+	b.WriteString(formatICUDate(ctx.tag, t, d.time, d.style))
+	return nil
+}
+
+func (s icuSelect) render(b *strings.Builder, ctx *icuContext) error {
+	v, err := ctx.arg(s.name)
+	if err != nil {
+		return err
+	}
+
+	// placeholder values might be marked for pseudo-localization
+	key := strings.Trim(fmt.Sprint(v), string([]rune{placeholderStart, placeholderEnd}))
+
+	msg, ok := s.cases[key]
+	if !ok {
+		msg = s.cases["other"]
+	}
+
+	return msg.render(b, ctx)
+}
+
+func (p icuPlural) render(b *strings.Builder, ctx *icuContext) error {
+	n, err := ctx.number(p.name)
+	if err != nil {
+		return err
+	}
+
+	msg, ok := p.exact[n]
+	if !ok {
+		rules := plural.Cardinal
+		if p.ordinal {
+			rules = plural.Ordinal
+		}
+
+		msg, ok = p.cases[pluralFormName(matchPlural(rules, ctx.tag, n-p.offset))]
+		if !ok {
+			msg = p.cases["other"]
+		}
+	}
+
+	pound := n - p.offset
+
+	outer := ctx.pound
+	ctx.pound = &pound
+
+	err = msg.render(b, ctx)
+
+	ctx.pound = outer
+
+	return err
+}
+
+func (ctx *icuContext) arg(name string) (interface{}, error) {
+	v, ok := ctx.args[name]
+	if !ok {
+		return nil, fmt.Errorf("i18nwrapper: missing argument %s", name)
+	}
+
+	return v, nil
+}
+
+func (ctx *icuContext) number(name string) (float64, error) {
+	v, err := ctx.arg(name)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := toFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("i18nwrapper: argument %s is not a number", name)
+	}
+
+	return n, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		// placeholder values might be marked for pseudo-localization
+		v = strings.Trim(v, string([]rune{placeholderStart, placeholderEnd}))
+
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// matchPlural returns the plural form of n in the passed language.
+func matchPlural(rules *plural.Rules, tag language.Tag, n float64) plural.Form {
+	s := strconv.FormatFloat(math.Abs(n), 'f', -1, 64)
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	i, _ := strconv.Atoi(intPart)
+	f, _ := strconv.Atoi(fracPart)
+
+	// since FormatFloat uses the minimal number of digits, there are no
+	// trailing zeros
+	return rules.MatchPlural(tag, i, len(fracPart), len(fracPart), f, f)
+}
+
+func pluralFormName(f plural.Form) string {
+	switch f {
+	case plural.Zero:
+		return "zero"
+	case plural.One:
+		return "one"
+	case plural.Two:
+		return "two"
+	case plural.Few:
+		return "few"
+	case plural.Many:
+		return "many"
+	default:
+		return "other"
+	}
+}
+
+// =============================================================================
+// Parser
+// =====================================================================================
+
+// icuParser parses ICU MessageFormat messages.
+type icuParser struct {
+	src []rune
+	pos int
+}
+
+// parseICU parses the passed ICU MessageFormat message.
+func parseICU(src string) (icuMessage, error) {
+	p := &icuParser{src: []rune(src)}
+
+	msg, err := p.parseMessage(false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected }")
+	}
+
+	return msg, nil
+}
+
+func (p *icuParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("i18nwrapper: invalid ICU message at position %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+func (p *icuParser) peek() (rune, bool) {
+	if p.pos >= len(p.src) {
+		return 0, false
+	}
+
+	return p.src[p.pos], true
+}
+
+func (p *icuParser) skipWhitespace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseMessage parses a (sub-)message.
+// If nested is true, the message ends before the closing brace.
+func (p *icuParser) parseMessage(nested, inPlural bool) (icuMessage, error) {
+	var (
+		msg  icuMessage
+		text strings.Builder
+	)
+
+	flushText := func() {
+		if text.Len() > 0 {
+			msg = append(msg, icuText(text.String()))
+			text.Reset()
+		}
+	}
+
+	for {
+		r, ok := p.peek()
+		if !ok {
+			if nested {
+				return nil, p.errorf("unclosed sub-message")
+			}
+
+			flushText()
+			return msg, nil
+		}
+
+		switch {
+		case r == '}':
+			flushText()
+			return msg, nil
+		case r == '{':
+			flushText()
+
+			n, err := p.parseArgument(inPlural)
+			if err != nil {
+				return nil, err
+			}
+
+			msg = append(msg, n)
+		case r == '#' && inPlural:
+			flushText()
+			msg = append(msg, icuPound{})
+			p.pos++
+		case r == '\'':
+			p.parseApostrophe(&text, inPlural)
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+}
+
+// parseApostrophe parses ICU's apostrophe quoting:
+// Two apostrophes are a literal apostrophe, and a single apostrophe starts a
+// quoted literal, if it is followed by a syntax character.
+// Otherwise, it is a literal apostrophe.
+func (p *icuParser) parseApostrophe(text *strings.Builder, inPlural bool) {
+	p.pos++ // skip '
+
+	r, ok := p.peek()
+	switch {
+	case ok && r == '\'':
+		text.WriteRune('\'')
+		p.pos++
+	case ok && (r == '{' || r == '}' || (r == '#' && inPlural)):
+		for p.pos < len(p.src) {
+			r := p.src[p.pos]
+			p.pos++
+
+			if r != '\'' {
+				text.WriteRune(r)
+				continue
+			}
+
+			if next, ok := p.peek(); ok && next == '\'' { // escaped ' in quote
+				text.WriteRune('\'')
+				p.pos++
+				continue
+			}
+
+			return
+		}
+	default:
+		text.WriteRune('\'')
+	}
+}
+
+func (p *icuParser) parseIdentifier() string {
+	start := p.pos
+
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+
+		p.pos++
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+// expect consumes the passed rune, after skipping whitespace.
+func (p *icuParser) expect(r rune) error {
+	p.skipWhitespace()
+
+	if cur, ok := p.peek(); !ok || cur != r {
+		return p.errorf("expected %c", r)
+	}
+
+	p.pos++
+	return nil
+}
+
+// parseArgument parses an argument.
+// If inPlural is true, the argument is inside a plural sub-message, and #
+// keeps referring to its number inside select sub-messages.
+func (p *icuParser) parseArgument(inPlural bool) (icuNode, error) {
+	p.pos++ // skip {
+	p.skipWhitespace()
+
+	name := p.parseIdentifier()
+	if len(name) == 0 {
+		return nil, p.errorf("missing argument name")
+	}
+
+	p.skipWhitespace()
+
+	if r, ok := p.peek(); ok && r == '}' {
+		p.pos++
+		return icuArg{name: name}, nil
+	}
+
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+
+	switch typ := p.parseIdentifier(); typ {
+	case "number":
+		style, err := p.parseStyle()
+		return icuNumber{name: name, style: style}, err
+	case "date", "time":
+		style, err := p.parseStyle()
+		if len(style) == 0 {
+			style = "medium"
+		}
+
+		return icuDate{name: name, time: typ == "time", style: style}, err
+	case "select":
+		return p.parseSelect(name, inPlural)
+	case "plural", "selectordinal":
+		return p.parsePlural(name, typ == "selectordinal")
+	default:
+		return nil, p.errorf("unknown argument type %q", typ)
+	}
+}
+
+// parseStyle parses the optional style of a simple argument, including the
+// closing brace.
+func (p *icuParser) parseStyle() (string, error) {
+	p.skipWhitespace()
+
+	if r, ok := p.peek(); ok && r == ',' {
+		p.pos++
+		p.skipWhitespace()
+
+		style := p.parseIdentifier()
+
+		return style, p.expect('}')
+	}
+
+	return "", p.expect('}')
+}
+
+func (p *icuParser) parseSelect(name string, inPlural bool) (icuNode, error) {
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+
+	s := icuSelect{name: name, cases: make(map[string]icuMessage)}
+
+	for {
+		p.skipWhitespace()
+
+		if r, ok := p.peek(); ok && r == '}' {
+			p.pos++
+			break
+		}
+
+		key := p.parseIdentifier()
+		if len(key) == 0 {
+			return nil, p.errorf("missing select case")
+		}
+
+		msg, err := p.parseSubMessage(inPlural)
+		if err != nil {
+			return nil, err
+		}
+
+		s.cases[key] = msg
+	}
+
+	if _, ok := s.cases["other"]; !ok {
+		return nil, p.errorf("select %s has no other case", name)
+	}
+
+	return s, nil
+}
+
+func (p *icuParser) parsePlural(name string, ordinal bool) (icuNode, error) {
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+
+	pl := icuPlural{
+		name:    name,
+		ordinal: ordinal,
+		exact:   make(map[float64]icuMessage),
+		cases:   make(map[string]icuMessage),
+	}
+
+	p.skipWhitespace()
+
+	if strings.HasPrefix(string(p.src[p.pos:]), "offset:") {
+		p.pos += len("offset:")
+		p.skipWhitespace()
+
+		offset, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+
+		pl.offset = offset
+	}
+
+	for {
+		p.skipWhitespace()
+
+		r, ok := p.peek()
+		if !ok {
+			return nil, p.errorf("unclosed plural")
+		}
+
+		if r == '}' {
+			p.pos++
+			break
+		}
+
+		if r == '=' {
+			p.pos++
+
+			n, err := p.parseNumber()
+			if err != nil {
+				return nil, err
+			}
+
+			msg, err := p.parseSubMessage(true)
+			if err != nil {
+				return nil, err
+			}
+
+			pl.exact[n] = msg
+
+			continue
+		}
+
+		key := p.parseIdentifier()
+		switch key {
+		case "zero", "one", "two", "few", "many", "other":
+		default:
+			return nil, p.errorf("invalid plural category %q", key)
+		}
+
+		msg, err := p.parseSubMessage(true)
+		if err != nil {
+			return nil, err
+		}
+
+		pl.cases[key] = msg
+	}
+
+	if _, ok := pl.cases["other"]; !ok {
+		return nil, p.errorf("plural %s has no other case", name)
+	}
+
+	return pl, nil
+}
+
+func (p *icuParser) parseNumber() (float64, error) {
+	start := p.pos
+
+	for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.' || p.src[p.pos] == '-') {
+		p.pos++
+	}
+
+	n, err := strconv.ParseFloat(string(p.src[start:p.pos]), 64)
+	if err != nil {
+		return 0, p.errorf("invalid number")
+	}
+
+	return n, nil
+}
+
+// parseSubMessage parses a sub-message enclosed in braces.
+func (p *icuParser) parseSubMessage(inPlural bool) (icuMessage, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	msg, err := p.parseMessage(true, inPlural)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect('}'); err != nil {
+		return nil, p.errorf("unclosed sub-message")
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,467 @@
+package i18nwrapper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+func TestParseICU_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		src    string
+		expect string
+	}{
+		{
+			name:   "unexpected closing brace",
+			src:    "abc}",
+			expect: "invalid ICU message at position 3: unexpected }",
+		},
+		{
+			name:   "missing argument name",
+			src:    "{, number}",
+			expect: "invalid ICU message at position 1: missing argument name",
+		},
+		{
+			name:   "unclosed argument",
+			src:    "{name",
+			expect: "invalid ICU message at position 5: expected ,",
+		},
+		{
+			name:   "unknown argument type",
+			src:    "{name, duration}",
+			expect: `invalid ICU message at position 15: unknown argument type "duration"`,
+		},
+		{
+			name:   "select without other",
+			src:    "{gender, select, female {She}}",
+			expect: "invalid ICU message at position 30: select gender has no other case",
+		},
+		{
+			name:   "missing select case",
+			src:    "{gender, select, {She} other {They}}",
+			expect: "invalid ICU message at position 17: missing select case",
+		},
+		{
+			name:   "unclosed sub-message",
+			src:    "{gender, select, other {They",
+			expect: "invalid ICU message at position 28: unclosed sub-message",
+		},
+		{
+			name:   "unclosed plural",
+			src:    "{count, plural, other {#}",
+			expect: "invalid ICU message at position 25: unclosed plural",
+		},
+		{
+			name:   "invalid plural category",
+			src:    "{count, plural, some {#} other {#}}",
+			expect: `invalid ICU message at position 20: invalid plural category "some"`,
+		},
+		{
+			name:   "plural without other",
+			src:    "{count, plural, one {#}}",
+			expect: "invalid ICU message at position 24: plural count has no other case",
+		},
+		{
+			name:   "invalid exact value",
+			src:    "{count, plural, =x {#} other {#}}",
+			expect: "invalid ICU message at position 17: invalid number",
+		},
+		{
+			name:   "invalid offset",
+			src:    "{count, plural, offset:x other {#}}",
+			expect: "invalid ICU message at position 23: invalid number",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseICU(c.src)
+			if err == nil {
+				t.Fatalf("expected error %q, but got none", c.expect)
+			}
+
+			if !strings.HasSuffix(err.Error(), c.expect) {
+				t.Errorf("expected error %q, but got %q", c.expect, err.Error())
+			}
+		})
+	}
+}
+
+func TestRenderICU(t *testing.T) {
+	// a Friday afternoon
+	date := time.Date(2021, 3, 5, 14, 7, 9, 0, time.UTC)
+
+	testCases := []struct {
+		name   string
+		tag    language.Tag
+		src    string
+		args   map[string]interface{}
+		expect string
+	}{
+		// select
+		{
+			name:   "select case",
+			tag:    language.English,
+			src:    "{gender, select, female {She} male {He} other {They}} replied.",
+			args:   map[string]interface{}{"gender": "female"},
+			expect: "She replied.",
+		},
+		{
+			name:   "select other",
+			tag:    language.English,
+			src:    "{gender, select, female {She} male {He} other {They}} replied.",
+			args:   map[string]interface{}{"gender": "unknown"},
+			expect: "They replied.",
+		},
+		{
+			name:   "nested select",
+			tag:    language.English,
+			src:    "{a, select, x {{b, select, y {xy} other {x?}}} other {?}}",
+			args:   map[string]interface{}{"a": "x", "b": "z"},
+			expect: "x?",
+		},
+		// plural
+		{
+			name:   "plural exact",
+			tag:    language.English,
+			src:    "{count, plural, =0 {no files} one {# file} other {# files}}",
+			args:   map[string]interface{}{"count": 0},
+			expect: "no files",
+		},
+		{
+			name:   "plural one",
+			tag:    language.English,
+			src:    "{count, plural, =0 {no files} one {# file} other {# files}}",
+			args:   map[string]interface{}{"count": 1},
+			expect: "1 file",
+		},
+		{
+			name:   "plural other",
+			tag:    language.English,
+			src:    "{count, plural, =0 {no files} one {# file} other {# files}}",
+			args:   map[string]interface{}{"count": 1500},
+			expect: "1,500 files",
+		},
+		{
+			name:   "plural polish few",
+			tag:    language.Polish,
+			src:    "{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}",
+			args:   map[string]interface{}{"count": 3},
+			expect: "3 pliki",
+		},
+		{
+			name:   "plural polish many",
+			tag:    language.Polish,
+			src:    "{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}",
+			args:   map[string]interface{}{"count": 12},
+			expect: "12 plików",
+		},
+		{
+			name:   "plural polish fraction",
+			tag:    language.Polish,
+			src:    "{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}",
+			args:   map[string]interface{}{"count": 1.5},
+			expect: "1,5 pliku",
+		},
+		{
+			name: "plural offset exact",
+			tag:  language.English,
+			src: "{count, plural, offset:1 =0 {nobody} =1 {{name}} " +
+				"one {{name} and # other} other {{name} and # others}}",
+			args:   map[string]interface{}{"count": 1, "name": "Alice"},
+			expect: "Alice",
+		},
+		{
+			name: "plural offset one",
+			tag:  language.English,
+			src: "{count, plural, offset:1 =0 {nobody} =1 {{name}} " +
+				"one {{name} and # other} other {{name} and # others}}",
+			args:   map[string]interface{}{"count": 2, "name": "Alice"},
+			expect: "Alice and 1 other",
+		},
+		{
+			name: "plural offset other",
+			tag:  language.English,
+			src: "{count, plural, offset:1 =0 {nobody} =1 {{name}} " +
+				"one {{name} and # other} other {{name} and # others}}",
+			args:   map[string]interface{}{"count": 4, "name": "Alice"},
+			expect: "Alice and 3 others",
+		},
+		{
+			name:   "plural count",
+			tag:    language.English,
+			src:    "{plural_count, plural, one {# item} other {# items}}",
+			args:   map[string]interface{}{"plural_count": "2"},
+			expect: "2 items",
+		},
+		// selectordinal
+		{
+			name:   "selectordinal one",
+			tag:    language.English,
+			src:    "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args:   map[string]interface{}{"n": 21},
+			expect: "21st",
+		},
+		{
+			name:   "selectordinal two",
+			tag:    language.English,
+			src:    "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args:   map[string]interface{}{"n": 2},
+			expect: "2nd",
+		},
+		{
+			name:   "selectordinal few",
+			tag:    language.English,
+			src:    "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args:   map[string]interface{}{"n": 3},
+			expect: "3rd",
+		},
+		{
+			name:   "selectordinal other",
+			tag:    language.English,
+			src:    "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args:   map[string]interface{}{"n": 11},
+			expect: "11th",
+		},
+		{
+			name:   "selectordinal exact and offset",
+			tag:    language.English,
+			src:    "{n, selectordinal, offset:1 =1 {first} one {#st} two {#nd} few {#rd} other {#th}}",
+			args:   map[string]interface{}{"n": 3},
+			expect: "2nd",
+		},
+		// # and apostrophes
+		{
+			name:   "pound outside plural",
+			tag:    language.English,
+			src:    "# {name}",
+			args:   map[string]interface{}{"name": "Bob"},
+			expect: "# Bob",
+		},
+		{
+			name:   "pound in nested select",
+			tag:    language.English,
+			src:    "{count, plural, other {{gender, select, other {# people}}}}",
+			args:   map[string]interface{}{"count": 3, "gender": "x"},
+			expect: "3 people",
+		},
+		{
+			name:   "escaped apostrophe",
+			tag:    language.English,
+			src:    "It''s {name}",
+			args:   map[string]interface{}{"name": "Bob"},
+			expect: "It's Bob",
+		},
+		{
+			name:   "literal apostrophe",
+			tag:    language.English,
+			src:    "{name}'s turn, don't wait",
+			args:   map[string]interface{}{"name": "Bob"},
+			expect: "Bob's turn, don't wait",
+		},
+		{
+			name:   "quoted braces",
+			tag:    language.English,
+			src:    "'{name}' is {name}",
+			args:   map[string]interface{}{"name": "Bob"},
+			expect: "{name} is Bob",
+		},
+		{
+			name:   "quoted pound",
+			tag:    language.English,
+			src:    "{count, plural, other {'#' is #, '{''}'}}",
+			args:   map[string]interface{}{"count": 3},
+			expect: "# is 3, {'}",
+		},
+		// number
+		{
+			name:   "number en",
+			tag:    language.English,
+			src:    "{n, number}",
+			args:   map[string]interface{}{"n": 1234.5},
+			expect: "1,234.5",
+		},
+		{
+			name:   "number de",
+			tag:    language.German,
+			src:    "{n, number}",
+			args:   map[string]interface{}{"n": 1234.5},
+			expect: "1.234,5",
+		},
+		{
+			name:   "number integer en",
+			tag:    language.English,
+			src:    "{n, number, integer}",
+			args:   map[string]interface{}{"n": 1234.4},
+			expect: "1,234",
+		},
+		{
+			name:   "number integer de",
+			tag:    language.German,
+			src:    "{n, number, integer}",
+			args:   map[string]interface{}{"n": 1234.4},
+			expect: "1.234",
+		},
+		{
+			name:   "number percent en",
+			tag:    language.English,
+			src:    "{n, number, percent}",
+			args:   map[string]interface{}{"n": 0.25},
+			expect: "25%",
+		},
+		{
+			name:   "simple argument number de",
+			tag:    language.German,
+			src:    "{n}",
+			args:   map[string]interface{}{"n": 1234567},
+			expect: "1.234.567",
+		},
+		// date and time
+		{
+			name:   "date short en",
+			tag:    language.English,
+			src:    "{d, date, short}",
+			args:   map[string]interface{}{"d": date},
+			expect: "3/5/21",
+		},
+		{
+			name:   "date medium en",
+			tag:    language.English,
+			src:    "{d, date}",
+			args:   map[string]interface{}{"d": date},
+			expect: "Mar 5, 2021",
+		},
+		{
+			name:   "date long en",
+			tag:    language.English,
+			src:    "{d, date, long}",
+			args:   map[string]interface{}{"d": date},
+			expect: "March 5, 2021",
+		},
+		{
+			name:   "date full en",
+			tag:    language.English,
+			src:    "{d, date, full}",
+			args:   map[string]interface{}{"d": date},
+			expect: "Friday, March 5, 2021",
+		},
+		{
+			name:   "time short en",
+			tag:    language.English,
+			src:    "{d, time, short}",
+			args:   map[string]interface{}{"d": date},
+			expect: "2:07 PM",
+		},
+		{
+			name:   "time long en",
+			tag:    language.English,
+			src:    "{d, time, long}",
+			args:   map[string]interface{}{"d": date},
+			expect: "2:07:09 PM UTC",
+		},
+		{
+			name:   "date short de",
+			tag:    language.German,
+			src:    "{d, date, short}",
+			args:   map[string]interface{}{"d": date},
+			expect: "05.03.21",
+		},
+		{
+			name:   "date medium de",
+			tag:    language.German,
+			src:    "{d, date, medium}",
+			args:   map[string]interface{}{"d": date},
+			expect: "05.03.2021",
+		},
+		{
+			name:   "date long de",
+			tag:    language.German,
+			src:    "{d, date, long}",
+			args:   map[string]interface{}{"d": date},
+			expect: "5. März 2021",
+		},
+		{
+			name:   "date full de",
+			tag:    language.German,
+			src:    "{d, date, full}",
+			args:   map[string]interface{}{"d": date},
+			expect: "Freitag, 5. März 2021",
+		},
+		{
+			name:   "time medium de",
+			tag:    language.German,
+			src:    "{d, time}",
+			args:   map[string]interface{}{"d": date},
+			expect: "14:07:09",
+		},
+		{
+			name:   "date unsupported language",
+			tag:    language.Finnish,
+			src:    "{d, date, full} {d, time, short}",
+			args:   map[string]interface{}{"d": date},
+			expect: "2021-03-05 14:07",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := renderICU(c.tag, c.src, c.args, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actual != c.expect {
+				t.Errorf("expected %q, but got %q", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestRenderICU_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		src    string
+		args   map[string]interface{}
+		expect string
+	}{
+		{
+			name:   "missing argument",
+			src:    "Hello {name}",
+			expect: "i18nwrapper: missing argument name",
+		},
+		{
+			name:   "not a number",
+			src:    "{count, plural, other {#}}",
+			args:   map[string]interface{}{"count": "many"},
+			expect: "i18nwrapper: argument count is not a number",
+		},
+		{
+			name:   "not a time",
+			src:    "{d, date}",
+			args:   map[string]interface{}{"d": 3},
+			expect: "i18nwrapper: argument d is not a time",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			_, err := renderICU(language.English, c.src, c.args, nil)
+			if err == nil {
+				t.Fatalf("expected error %q, but got none", c.expect)
+			}
+
+			if err.Error() != c.expect {
+				t.Errorf("expected error %q, but got %q", c.expect, err.Error())
+			}
+		})
+	}
+}
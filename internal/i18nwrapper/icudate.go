@@ -0,0 +1,220 @@
+package i18nwrapper
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+type (
+	// dateFormat contains the layouts and symbols used to format dates and
+	// times in a language.
+	//
+	// Layouts are time.Format layouts, that may additionally contain the
+	// following verbs, which are replaced with the localized symbols:
+	//
+	//	%B	full month name
+	//	%b	abbreviated month name
+	//	%A	full weekday name
+	//	%p	day period, i.e. AM or PM
+	dateFormat struct {
+		// date are the date layouts of the short, medium, long and full
+		// styles.
+		date [4]string
+		// time are the time layouts of the short, medium and long styles.
+		// The full style uses the long layout.
+		time [3]string
+
+		// months are the month names, starting with January.
+		// In languages that decline month names, these are the names in the
+		// form used in dates, e.g. the genitive.
+		months [12]string
+		// monthsShort are the abbreviated month names, starting with January.
+		monthsShort [12]string
+		// weekdays are the weekday names, starting with Sunday.
+		weekdays [7]string
+		// dayPeriods are the names of the day periods before and after noon.
+		dayPeriods [2]string
+	}
+)
+
+// defaultDateFormat is the dateFormat used for languages not found in
+// dateFormats.
+// It uses ISO 8601 layouts, and therefore needs no symbols.
+var defaultDateFormat = dateFormat{
+	date: [4]string{"2006-01-02", "2006-01-02", "2006-01-02", "2006-01-02"},
+	time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+}
+
+// dateFormats are the dateFormats of the supported base languages.
+var dateFormats = map[string]dateFormat{
+	"en": {
+		date: [4]string{"1/2/06", "%b 2, 2006", "%B 2, 2006", "%A, %B 2, 2006"},
+		time: [3]string{"3:04 %p", "3:04:05 %p", "3:04:05 %p MST"},
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July", "August",
+			"September", "October", "November", "December"},
+		monthsShort: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		dayPeriods:  [2]string{"AM", "PM"},
+	},
+	"de": {
+		date: [4]string{"02.01.06", "02.01.2006", "2. %B 2006", "%A, 2. %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August",
+			"September", "Oktober", "November", "Dezember"},
+		monthsShort: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.",
+			"Nov.", "Dez."},
+		weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	},
+	"es": {
+		date: [4]string{"2/1/06", "2 %b 2006", "2 de %B de 2006", "%A, 2 de %B de 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto",
+			"septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:    [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	},
+	"fr": {
+		date: [4]string{"02/01/2006", "2 %b 2006", "2 %B 2006", "%A 2 %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août",
+			"septembre", "octobre", "novembre", "décembre"},
+		monthsShort: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.",
+			"oct.", "nov.", "déc."},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	},
+	"it": {
+		date: [4]string{"02/01/06", "2 %b 2006", "2 %B 2006", "%A 2 %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto",
+			"settembre", "ottobre", "novembre", "dicembre"},
+		monthsShort: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		weekdays:    [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+	},
+	"ja": {
+		date:     [4]string{"2006/01/02", "2006/01/02", "2006年1月2日", "2006年1月2日%A"},
+		time:     [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		weekdays: [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+	},
+	"ko": {
+		date:       [4]string{"06. 1. 2.", "2006. 1. 2.", "2006년 1월 2일", "2006년 1월 2일 %A"},
+		time:       [3]string{"%p 3:04", "%p 3:04:05", "%p 3시 4분 5초 MST"},
+		weekdays:   [7]string{"일요일", "월요일", "화요일", "수요일", "목요일", "금요일", "토요일"},
+		dayPeriods: [2]string{"오전", "오후"},
+	},
+	"nl": {
+		date: [4]string{"02-01-2006", "2 %b 2006", "2 %B 2006", "%A 2 %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus",
+			"september", "oktober", "november", "december"},
+		monthsShort: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		weekdays:    [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+	},
+	"pl": {
+		date: [4]string{"02.01.2006", "2 %b 2006", "2 %B 2006", "%A, 2 %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia",
+			"września", "października", "listopada", "grudnia"},
+		monthsShort: [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		weekdays: [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek",
+			"sobota"},
+	},
+	"pt": {
+		date: [4]string{"02/01/2006", "2 de %b de 2006", "2 de %B de 2006", "%A, 2 de %B de 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto",
+			"setembro", "outubro", "novembro", "dezembro"},
+		monthsShort: [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.",
+			"nov.", "dez."},
+		weekdays: [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira",
+			"sexta-feira", "sábado"},
+	},
+	"ru": {
+		date: [4]string{"02.01.2006", "2 %b 2006 г.", "2 %B 2006 г.", "%A, 2 %B 2006 г."},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа",
+			"сентября", "октября", "ноября", "декабря"},
+		monthsShort: [12]string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.",
+			"окт.", "нояб.", "дек."},
+		weekdays: [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница",
+			"суббота"},
+	},
+	"sv": {
+		date: [4]string{"2006-01-02", "2 %b 2006", "2 %B 2006", "%A 2 %B 2006"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti",
+			"september", "oktober", "november", "december"},
+		monthsShort: [12]string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.",
+			"nov.", "dec."},
+		weekdays: [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+	},
+	"tr": {
+		date: [4]string{"2.01.2006", "2 %b 2006", "2 %B 2006", "2 %B 2006 %A"},
+		time: [3]string{"15:04", "15:04:05", "15:04:05 MST"},
+		months: [12]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül",
+			"Ekim", "Kasım", "Aralık"},
+		monthsShort: [12]string{"Oca", "Şub", "Mar", "Nis", "May", "Haz", "Tem", "Ağu", "Eyl", "Eki", "Kas", "Ara"},
+		weekdays:    [7]string{"Pazar", "Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma", "Cumartesi"},
+	},
+}
+
+// formatICUDate formats the passed time in the passed style, using the
+// dateFormat of the base language of the passed tag.
+// If isTime is true, the time of day is formatted, otherwise the date.
+func formatICUDate(tag language.Tag, t time.Time, isTime bool, style string) string {
+	base, _ := tag.Base()
+
+	f, ok := dateFormats[base.String()]
+	if !ok {
+		f = defaultDateFormat
+	}
+
+	var layout string
+
+	if isTime {
+		switch style {
+		case "short":
+			layout = f.time[0]
+		case "long", "full":
+			layout = f.time[2]
+		default:
+			layout = f.time[1]
+		}
+	} else {
+		switch style {
+		case "short":
+			layout = f.date[0]
+		case "long":
+			layout = f.date[2]
+		case "full":
+			layout = f.date[3]
+		default:
+			layout = f.date[1]
+		}
+	}
+
+	return f.replaceSymbols(t.Format(layout), t)
+}
+
+// replaceSymbols replaces the verbs in the passed formatted time with the
+// symbols of t.
+// Verbs are only replaced after formatting, as the symbols may contain
+// characters that time.Format interprets.
+func (f dateFormat) replaceSymbols(s string, t time.Time) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	period := 0
+	if t.Hour() >= 12 {
+		period = 1
+	}
+
+	return strings.NewReplacer(
+		"%B", f.months[t.Month()-1],
+		"%b", f.monthsShort[t.Month()-1],
+		"%A", f.weekdays[t.Weekday()],
+		"%p", f.dayPeriods[period],
+	).Replace(s)
+}
@@ -3,8 +3,10 @@ package i18nwrapper
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
 
 	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
@@ -18,13 +20,15 @@ import (
 // found int the passed path.
 // All non-translation files will be skipped.
 func Load(b *i18nimpl.Bundle, customPath string) error {
-	err := loadEmbeddedTranslations(b)
+	load := func(tag language.Tag, f fs.File) error { return loadTranslation(b, tag, f) }
+
+	err := walkEmbeddedTranslations(load)
 	if err != nil {
 		return err
 	}
 
 	if len(customPath) > 0 {
-		return loadCustomTranslations(b, customPath)
+		return walkCustomTranslations(customPath, load)
 	}
 
 	return nil
@@ -39,12 +43,12 @@ type (
 
 	// definition is the type used, if there are multiple definitions.
 	definition struct {
-		Zero  string `json:"zero"`
-		One   string `json:"one"`
-		Two   string `json:"two"`
-		Few   string `json:"few"`
-		Many  string `json:"many"`
-		Other string `json:"other"`
+		Zero  string `json:"zero,omitempty"`
+		One   string `json:"one,omitempty"`
+		Two   string `json:"two,omitempty"`
+		Few   string `json:"few,omitempty"`
+		Many  string `json:"many,omitempty"`
+		Other string `json:"other,omitempty"`
 	}
 )
 
@@ -53,64 +57,102 @@ func (d *definition) isEmpty() bool {
 		len(d.Few) == 0 && len(d.Many) == 0 && len(d.Other) == 0
 }
 
+// isPlural checks if the definition uses any other plural category than
+// 'other'.
+func (d *definition) isPlural() bool {
+	return len(d.Zero) > 0 || len(d.One) > 0 || len(d.Two) > 0 || len(d.Few) > 0 || len(d.Many) > 0
+}
+
+// parseDefinition parses the passed raw definition, which is either a string
+// or a definition object.
+func parseDefinition(raw json.RawMessage) (def definition, err error) {
+	if bytes.HasPrefix(raw, []byte(`"`)) { // just once
+		err = json.Unmarshal(raw, &def.Other)
+	} else { // definition object
+		err = json.Unmarshal(raw, &def)
+	}
+
+	return def, err
+}
+
+// rawDefinition is the inverse of parseDefinition.
+// If only def.Other is set, the definition is encoded as a plain string.
+func rawDefinition(def definition) (json.RawMessage, error) {
+	if !def.isPlural() {
+		return json.Marshal(def.Other)
+	}
+
+	return json.Marshal(def)
+}
+
 var (
 	defaultFileRegexp = regexp.MustCompile(`^(?P<lang>.+?)(?:_(?:adam|levin))?\.json$`)
 	customFileRegexp  = regexp.MustCompile(`^(?P<lang>.+?)\.json$`)
 )
 
-func loadEmbeddedTranslations(b *i18nimpl.Bundle) error {
+// walkEmbeddedTranslations calls f for every embedded translation file.
+func walkEmbeddedTranslations(f func(language.Tag, fs.File) error) error {
 	dir, err := assets.Translations.ReadDir("translations")
 	if err != nil {
 		return err
 	}
 
-	for _, f := range dir {
-		if f.IsDir() {
+	for _, e := range dir {
+		if e.IsDir() {
 			continue
 		}
 
-		matches := defaultFileRegexp.FindStringSubmatch(f.Name())
+		matches := defaultFileRegexp.FindStringSubmatch(e.Name())
 		if len(matches) < 2 {
-			log().With("file_name", f.Name()).
+			log().With("file_name", e.Name()).
 				Warn("found non-translation file in embedded translations, skipping")
+			continue
 		}
 
 		tag, err := language.Parse(matches[1])
 		if err != nil {
 			log().With("lang", matches[1]).
 				Warn("embedded translations contain a translation file for invalid language, skipping")
+			continue
 		}
 
-		f, err := assets.Translations.Open("translations/" + f.Name())
+		file, err := assets.Translations.Open("translations/" + e.Name())
 		if err != nil {
 			return err
 		}
 
-		if err = loadTranslation(b, tag, f); err != nil {
+		err = f(tag, file)
+		file.Close()
+
+		if err != nil {
 			return err
 		}
 	}
 
-	return err
+	return nil
 }
 
-func loadCustomTranslations(b *i18nimpl.Bundle, customPath string) error {
+// walkCustomTranslations calls f for every translation file in the directory
+// at customPath.
+func walkCustomTranslations(customPath string, f func(language.Tag, fs.File) error) error {
 	dir, err := os.Open(customPath)
 	if err != nil {
 		return err
 	}
 
+	defer dir.Close()
+
 	files, err := dir.Readdir(0)
 	if err != nil {
 		return err
 	}
 
-	for _, f := range files {
-		if f.IsDir() {
+	for _, e := range files {
+		if e.IsDir() {
 			continue
 		}
 
-		matches := customFileRegexp.FindStringSubmatch(f.Name())
+		matches := customFileRegexp.FindStringSubmatch(e.Name())
 		if len(matches) < 2 {
 			continue
 		}
@@ -120,12 +162,15 @@ func loadCustomTranslations(b *i18nimpl.Bundle, customPath string) error {
 			continue
 		}
 
-		f, err := os.Open(customPath + f.Name())
+		file, err := os.Open(filepath.Join(customPath, e.Name()))
 		if err != nil {
 			return err
 		}
 
-		if err = loadTranslation(b, tag, f); err != nil {
+		err = f(tag, file)
+		file.Close()
+
+		if err != nil {
 			return err
 		}
 	}
@@ -133,31 +178,29 @@ func loadCustomTranslations(b *i18nimpl.Bundle, customPath string) error {
 	return nil
 }
 
-func loadTranslation(b *i18nimpl.Bundle, tag language.Tag, f fs.File) error {
-	var messages []translation
+// decodeTranslations decodes the translation file f.
+func decodeTranslations(f fs.File) ([]translation, error) {
+	var translations []translation
+	return translations, json.NewDecoder(f).Decode(&translations)
+}
 
-	if err := json.NewDecoder(f).Decode(&messages); err != nil {
+func loadTranslation(b *i18nimpl.Bundle, tag language.Tag, f fs.File) error {
+	messages, err := decodeTranslations(f)
+	if err != nil {
 		return err
 	}
 
 	for _, m := range messages {
-		var def definition
-
-		if bytes.HasPrefix(m.Definition, []byte(`"`)) { // just once
-			if err := json.Unmarshal(m.Definition, &def.Other); err != nil {
-				return err
-			}
-		} else { // definition object
-			if err := json.Unmarshal(m.Definition, &def); err != nil {
-				return err
-			}
+		def, err := parseDefinition(m.Definition)
+		if err != nil {
+			return err
 		}
 
 		if len(m.Term) == 0 || def.isEmpty() {
 			continue
 		}
 
-		err := b.AddMessages(tag, &i18nimpl.Message{
+		msg := &i18nimpl.Message{
 			ID:    m.Term,
 			Zero:  def.Zero,
 			One:   def.One,
@@ -165,11 +208,43 @@ func loadTranslation(b *i18nimpl.Bundle, tag language.Tag, f fs.File) error {
 			Few:   def.Few,
 			Many:  def.Many,
 			Other: def.Other,
-		})
-		if err != nil {
+		}
+
+		if !def.isPlural() && isICU(def.Other) {
+			if msg, err = icuMessageFor(m.Term, def.Other); err != nil {
+				return err
+			}
+		}
+
+		if err = b.AddMessages(tag, msg); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// icuMessageFor validates the passed ICU MessageFormat message and returns
+// the *i18nimpl.Message to store it in the bundle.
+//
+// Since ICU messages handle plurals themselves, all plural forms are set to
+// the source, so that go-i18n returns it regardless of the plural count.
+func icuMessageFor(term, src string) (*i18nimpl.Message, error) {
+	if _, err := parseICUCached(src); err != nil {
+		return nil, fmt.Errorf("term %s: %w", term, err)
+	}
+
+	src = icuMarker + src
+
+	return &i18nimpl.Message{
+		ID:         term,
+		LeftDelim:  icuLeftDelim,
+		RightDelim: icuRightDelim,
+		Zero:       src,
+		One:        src,
+		Two:        src,
+		Few:        src,
+		Many:       src,
+		Other:      src,
+	}, nil
+}
@@ -0,0 +1,312 @@
+package i18nwrapper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// pluralCategoriesHeader is the PO header used to store the plural categories
+// the msgstr indexes map to.
+const pluralCategoriesHeader = "X-Plural-Categories"
+
+// writePO writes the passed exchangeFile in the gettext PO format.
+// Terms are stored as msgctxt.
+func writePO(w io.Writer, f *exchangeFile) error {
+	bw := bufio.NewWriter(w)
+
+	header := "Language: " + f.Lang.String() + "\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: text/plain; charset=UTF-8\n" +
+		"Content-Transfer-Encoding: 8bit\n" +
+		"Plural-Forms: " + pluralFormsHeader(f.Lang, f.Categories) + "\n" +
+		pluralCategoriesHeader + ": " + strings.Join(f.Categories, ", ") + "\n"
+
+	writePOString(bw, "msgid", "")
+	writePOString(bw, "msgstr", header)
+
+	for _, e := range f.Entries {
+		bw.WriteByte('\n')
+
+		writePOString(bw, "msgctxt", e.Term)
+
+		if !e.Plural {
+			writePOString(bw, "msgid", e.Source.Other)
+			writePOString(bw, "msgstr", e.Target.Other)
+
+			continue
+		}
+
+		singular := e.Source.One
+		if len(singular) == 0 {
+			singular = e.Source.Other
+		}
+
+		writePOString(bw, "msgid", singular)
+		writePOString(bw, "msgid_plural", e.Source.Other)
+
+		for i, c := range f.Categories {
+			writePOString(bw, "msgstr["+strconv.Itoa(i)+"]", e.Target.get(c))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writePOString writes the passed keyword followed by the quoted string.
+// Strings containing line breaks are split into multiple lines.
+func writePOString(w *bufio.Writer, keyword, s string) {
+	w.WriteString(keyword)
+
+	if !strings.Contains(s, "\n") || s == "\n" {
+		w.WriteString(" " + quotePO(s) + "\n")
+		return
+	}
+
+	w.WriteString(` ""` + "\n")
+
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			i = len(s) - 1
+		}
+
+		w.WriteString(quotePO(s[:i+1]) + "\n")
+		s = s[i+1:]
+	}
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func quotePO(s string) string { return `"` + poEscaper.Replace(s) + `"` }
+
+var poUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t", `\r`, "\r")
+
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("i18nwrapper: invalid PO string %s", s)
+	}
+
+	return poUnescaper.Replace(s[1 : len(s)-1]), nil
+}
+
+// poEntry is a single raw entry of a PO file.
+type poEntry struct {
+	fuzzy       bool
+	msgctxt     string
+	msgid       string
+	msgidPlural string
+	// plural indicates whether the entry has a msgid_plural or indexed
+	// msgstrs.
+	plural bool
+	// msgstr maps the msgstr indexes to the strings.
+	// Pointers are used to allow appending continuation lines.
+	msgstr map[int]*string
+}
+
+func (e *poEntry) str(i int) string {
+	if s := e.msgstr[i]; s != nil {
+		return *s
+	}
+
+	return ""
+}
+
+// readPO reads a gettext PO file written by writePO, or by a translation
+// platform from a file written by writePO.
+func readPO(r io.Reader) (*exchangeFile, error) {
+	entries, err := parsePO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(exchangeFile)
+
+	for _, e := range entries {
+		if len(e.msgctxt) == 0 && len(e.msgid) == 0 { // header
+			if err := f.parsePOHeader(e.str(0)); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if len(e.msgctxt) == 0 {
+			continue
+		}
+
+		entry := exchangeEntry{Term: e.msgctxt, Plural: e.plural, Fuzzy: e.fuzzy}
+
+		if !entry.Plural {
+			entry.Target.Other = e.str(0)
+		} else {
+			for i := range e.msgstr {
+				if i < 0 || i >= len(f.Categories) {
+					return nil, fmt.Errorf("i18nwrapper: msgstr[%d] of %s has no plural category", i, e.msgctxt)
+				}
+
+				entry.Target.set(f.Categories[i], e.str(i))
+			}
+		}
+
+		f.Entries = append(f.Entries, entry)
+	}
+
+	if f.Lang == language.Und {
+		return nil, errors.New("i18nwrapper: PO file has no Language header")
+	}
+
+	return f, nil
+}
+
+func (f *exchangeFile) parsePOHeader(header string) error {
+	for _, line := range strings.Split(header, "\n") {
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+
+		key, val := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "Language":
+			tag, err := language.Parse(strings.ReplaceAll(val, "_", "-"))
+			if err != nil {
+				return err
+			}
+
+			f.Lang = tag
+		case pluralCategoriesHeader:
+			f.Categories = nil
+
+			for _, c := range strings.Split(val, ",") {
+				if c = strings.TrimSpace(c); len(c) > 0 {
+					f.Categories = append(f.Categories, c)
+				}
+			}
+		}
+	}
+
+	if f.Categories == nil && f.Lang != language.Und {
+		f.Categories = pluralCategories(f.Lang)
+	}
+
+	return nil
+}
+
+// parsePO parses the raw entries of a PO file.
+func parsePO(r io.Reader) ([]poEntry, error) { //nolint:gocognit
+	var (
+		entries []poEntry
+		cur     *poEntry
+		// target is the string continuation lines are appended to
+		target *string
+	)
+
+	flush := func() {
+		if cur != nil {
+			entries = append(entries, *cur)
+		}
+
+		cur = nil
+		target = nil
+	}
+
+	start := func() {
+		if cur == nil {
+			cur = &poEntry{msgstr: make(map[int]*string)}
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+
+		switch {
+		case len(line) == 0:
+			flush()
+		case strings.HasPrefix(line, "#,"):
+			if cur != nil && len(cur.msgstr) > 0 { // flags of the next entry
+				flush()
+			}
+
+			start()
+			cur.fuzzy = cur.fuzzy || strings.Contains(line, "fuzzy")
+		case strings.HasPrefix(line, "#"):
+			// other comments are irrelevant
+		case strings.HasPrefix(line, `"`):
+			if target == nil {
+				return nil, fmt.Errorf("i18nwrapper: unexpected string in line %d", lineNo)
+			}
+
+			str, err := unquotePO(line)
+			if err != nil {
+				return nil, fmt.Errorf("%w (line %d)", err, lineNo)
+			}
+
+			*target += str
+		default:
+			i := strings.IndexByte(line, ' ')
+			if i < 0 {
+				return nil, fmt.Errorf("i18nwrapper: invalid PO line %d", lineNo)
+			}
+
+			keyword := line[:i]
+
+			str, err := unquotePO(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("%w (line %d)", err, lineNo)
+			}
+
+			// a msgctxt or msgid after a msgstr starts a new entry, even if
+			// there is no blank line in between
+			if cur != nil && len(cur.msgstr) > 0 && (keyword == "msgctxt" || keyword == "msgid") {
+				flush()
+			}
+
+			start()
+
+			switch {
+			case keyword == "msgctxt":
+				cur.msgctxt = str
+				target = &cur.msgctxt
+			case keyword == "msgid":
+				cur.msgid = str
+				target = &cur.msgid
+			case keyword == "msgid_plural":
+				cur.msgidPlural = str
+				cur.plural = true
+				target = &cur.msgidPlural
+			case keyword == "msgstr":
+				cur.msgstr[0] = &str
+				target = &str
+			case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+				n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+				if err != nil {
+					return nil, fmt.Errorf("i18nwrapper: invalid msgstr index in line %d", lineNo)
+				}
+
+				cur.msgstr[n] = &str
+				cur.plural = true
+				target = &str
+			default:
+				return nil, fmt.Errorf("i18nwrapper: unknown PO keyword %s in line %d", keyword, lineNo)
+			}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	return entries, nil
+}
@@ -0,0 +1,145 @@
+package i18nwrapper
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mavolin/adam/pkg/i18n"
+	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// PseudoLocale is the locale used for pseudo-localization.
+// Strings localized to it are generated from the default language of the
+// bundle, but their letters are replaced by accented ones, they are expanded
+// by roughly a third of their length, and enclosed in brackets.
+//
+// This makes hard-coded strings, that don't go through the bundle, as well as
+// strings, that would be truncated in other languages, easy to spot.
+const PseudoLocale = "qps-ploc"
+
+const (
+	// placeholderStart and placeholderEnd are private-use runes used to mark
+	// the start and end of a placeholder value, so that pseudolocalize can
+	// skip it.
+	placeholderStart = '\uE000'
+	placeholderEnd   = '\uE001'
+
+	// expansionFactor is the factor by which pseudo-localized strings are
+	// expanded.
+	expansionFactor = 0.35
+)
+
+// pseudoFunc returns the i18n.Func used for PseudoLocale.
+func pseudoFunc(b *i18nimpl.Bundle) i18n.Func {
+	f := FuncForBundle(b, b.LanguageTags()[0].String())
+
+	return func(term i18n.Term, placeholders map[string]interface{}, plural interface{}) (string, error) {
+		s, err := f(term, markPlaceholders(placeholders), plural)
+		if err != nil {
+			return s, err
+		}
+
+		return pseudolocalize(s), nil
+	}
+}
+
+// markPlaceholders returns a copy of the passed placeholders, in which all
+// string values are enclosed in placeholderStart and placeholderEnd.
+func markPlaceholders(placeholders map[string]interface{}) map[string]interface{} {
+	if placeholders == nil {
+		return nil
+	}
+
+	marked := make(map[string]interface{}, len(placeholders))
+
+	for k, v := range placeholders {
+		if s, ok := v.(string); ok {
+			marked[k] = string(placeholderStart) + s + string(placeholderEnd)
+		} else {
+			marked[k] = v
+		}
+	}
+
+	return marked
+}
+
+// verbatimRegexp matches all parts of a message that must remain unchanged,
+// i.e. placeholder values, mentions, custom emojis, timestamps, links, code
+// and emoji shortcodes.
+var verbatimRegexp = regexp.MustCompile(
+	`\x{E000}[^\x{E001}]*\x{E001}` +
+		`|<(?:@[!&]?|#)\d+>|<a?:\w+:\d+>|<t:-?\d+(?::\w)?>` +
+		`|@everyone|@here` +
+		`|https?://\S+` +
+		"|```[\\s\\S]*?```|`[^`]*`" +
+		`|:\w+:`)
+
+// pseudolocalize pseudo-localizes the passed string.
+// Placeholder values marked by markPlaceholders will be unmarked, but
+// remain unchanged otherwise.
+func pseudolocalize(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+
+	b.WriteRune('[')
+
+	var letters int
+
+	last := 0
+
+	for _, loc := range verbatimRegexp.FindAllStringIndex(s, -1) {
+		letters += writeAccented(&b, s[last:loc[0]])
+
+		verbatim := s[loc[0]:loc[1]]
+		verbatim = strings.TrimPrefix(verbatim, string(placeholderStart))
+		verbatim = strings.TrimSuffix(verbatim, string(placeholderEnd))
+		b.WriteString(verbatim)
+
+		letters += utf8.RuneCountInString(verbatim)
+		last = loc[1]
+	}
+
+	letters += writeAccented(&b, s[last:])
+
+	if pad := int(float64(letters)*expansionFactor + 0.5); pad > 0 {
+		b.WriteRune(' ')
+		b.WriteString(strings.Repeat("·", pad))
+	}
+
+	b.WriteRune(']')
+
+	return b.String()
+}
+
+// writeAccented writes the accented version of s to b, and returns the number
+// of runes written.
+func writeAccented(b *strings.Builder, s string) (n int) {
+	for _, r := range s {
+		if r == placeholderStart || r == placeholderEnd { // stray marker
+			continue
+		}
+
+		if accented, ok := accents[r]; ok {
+			r = accented
+		}
+
+		b.WriteRune(r)
+		n++
+	}
+
+	return n
+}
+
+var accents = map[rune]rune{
+	'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'đ', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ', 'h': 'ĥ', 'i': 'í',
+	'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ', 'o': 'ó', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ',
+	's': 'š', 't': 'ţ', 'u': 'ú', 'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Đ', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Í',
+	'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ', 'O': 'Ó', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ',
+	'S': 'Š', 'T': 'Ţ', 'U': 'Ú', 'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
@@ -0,0 +1,407 @@
+package i18nwrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Format is a translation exchange format understood by translation
+// platforms.
+type Format string
+
+const (
+	// PO is the gettext PO format.
+	PO Format = "po"
+	// XLIFF is the XLIFF 1.2 format.
+	XLIFF Format = "xliff"
+)
+
+// sourceLanguage is the language source strings are taken from.
+var sourceLanguage = language.English
+
+// ErrUnknownFormat is the error returned, if a Format is neither PO nor
+// XLIFF.
+var ErrUnknownFormat = errors.New("i18nwrapper: unknown translation format")
+
+type (
+	// exchangeFile is the format-independent representation of a file in an
+	// exchange format.
+	exchangeFile struct {
+		Lang language.Tag
+		// Categories are the plural categories used by the file in order.
+		Categories []string
+		Entries    []exchangeEntry
+	}
+
+	exchangeEntry struct {
+		Term   string
+		Source definition
+		Target definition
+		// Plural indicates whether the entry uses plural forms.
+		Plural bool
+		// Fuzzy indicates whether the translation was marked as needing
+		// review.
+		Fuzzy bool
+	}
+)
+
+// Export writes the translations for the passed language in the passed
+// format to w.
+// The source strings are taken from the English translations.
+//
+// Translations are read from the embedded translation files, and, if
+// customPath isn't empty, from the translation files in that directory.
+func Export(w io.Writer, format Format, lang, customPath string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return err
+	}
+
+	source, err := readDefinitions(sourceLanguage, customPath)
+	if err != nil {
+		return err
+	}
+
+	target, err := readDefinitions(tag, customPath)
+	if err != nil {
+		return err
+	}
+
+	f := newExchangeFile(tag, source, target)
+
+	switch format {
+	case PO:
+		return writePO(w, f)
+	case XLIFF:
+		return writeXLIFF(w, f)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// Import reads a file in the passed format from r and writes it as levin
+// translation file to w.
+// It returns the target language of the imported file, and the terms whose
+// translations were skipped, because they are fuzzy, i.e. need review.
+// Empty translations are skipped as well, but not reported.
+//
+// If customPath isn't empty, the translations of the imported language in
+// the translation files in that directory are merged into the written file,
+// so that the translations of terms that were skipped or are missing from the
+// imported file are kept.
+func Import(w io.Writer, r io.Reader, format Format, customPath string) (_ language.Tag, fuzzy []string, err error) {
+	var f *exchangeFile
+
+	switch format {
+	case PO:
+		f, err = readPO(r)
+	case XLIFF:
+		f, err = readXLIFF(r)
+	default:
+		return language.Und, nil, ErrUnknownFormat
+	}
+
+	if err != nil {
+		return language.Und, nil, err
+	}
+
+	defs := make(map[string]definition, len(f.Entries))
+
+	if len(customPath) > 0 {
+		if err := walkCustomTranslations(customPath, readInto(f.Lang, defs)); err != nil {
+			return language.Und, nil, err
+		}
+	}
+
+	for _, e := range f.Entries {
+		if e.Target.isEmpty() {
+			continue
+		}
+
+		if e.Fuzzy {
+			fuzzy = append(fuzzy, e.Term)
+			continue
+		}
+
+		defs[e.Term] = e.Target
+	}
+
+	return f.Lang, fuzzy, encodeTranslations(w, defs)
+}
+
+// encodeTranslations writes the passed definitions as levin translation file
+// to w, sorted by their term.
+func encodeTranslations(w io.Writer, defs map[string]definition) error {
+	terms := make([]string, 0, len(defs))
+	for term := range defs {
+		terms = append(terms, term)
+	}
+
+	sort.Strings(terms)
+
+	translations := make([]translation, len(terms))
+
+	for i, term := range terms {
+		raw, err := rawDefinition(defs[term])
+		if err != nil {
+			return err
+		}
+
+		translations[i] = translation{Term: term, Definition: raw}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(translations)
+}
+
+// readDefinitions reads all definitions for the passed language, keyed by
+// their term.
+func readDefinitions(tag language.Tag, customPath string) (map[string]definition, error) {
+	defs := make(map[string]definition)
+
+	if err := walkEmbeddedTranslations(readInto(tag, defs)); err != nil {
+		return nil, err
+	}
+
+	if len(customPath) > 0 {
+		if err := walkCustomTranslations(customPath, readInto(tag, defs)); err != nil {
+			return nil, err
+		}
+	}
+
+	return defs, nil
+}
+
+// readInto returns a function that reads the definitions of the translation
+// files of the passed language into defs.
+// Translation files of other languages are ignored.
+func readInto(tag language.Tag, defs map[string]definition) func(language.Tag, fs.File) error {
+	return func(fileTag language.Tag, f fs.File) error {
+		if fileTag != tag {
+			return nil
+		}
+
+		translations, err := decodeTranslations(f)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range translations {
+			def, err := parseDefinition(t.Definition)
+			if err != nil {
+				return fmt.Errorf("term %s: %w", t.Term, err)
+			}
+
+			if len(t.Term) > 0 && !def.isEmpty() {
+				defs[t.Term] = def
+			}
+		}
+
+		return nil
+	}
+}
+
+// newExchangeFile creates a new exchangeFile containing all terms from source
+// and target.
+func newExchangeFile(lang language.Tag, source, target map[string]definition) *exchangeFile {
+	f := &exchangeFile{
+		Lang:    lang,
+		Entries: make([]exchangeEntry, 0, len(source)),
+	}
+
+	terms := make([]string, 0, len(source)+len(target))
+
+	for term := range source {
+		terms = append(terms, term)
+	}
+
+	for term := range target {
+		if _, ok := source[term]; !ok {
+			terms = append(terms, term)
+		}
+	}
+
+	sort.Strings(terms)
+
+	var usedCategories []string
+
+	for _, term := range terms {
+		e := exchangeEntry{
+			Term:   term,
+			Source: source[term],
+			Target: target[term],
+		}
+
+		if e.Source.isEmpty() { // the term only exists in the target language
+			e.Source.Other = term
+		}
+
+		e.Plural = e.Source.isPlural() || e.Target.isPlural()
+		if e.Plural {
+			usedCategories = mergeCategories(usedCategories, e.Target.categories())
+		}
+
+		f.Entries = append(f.Entries, e)
+	}
+
+	f.Categories = mergeCategories(pluralCategories(lang), usedCategories)
+
+	return f
+}
+
+// allCategories are all CLDR plural categories in canonical order.
+var allCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// get returns the form of the passed plural category.
+func (d *definition) get(category string) string {
+	switch category {
+	case "zero":
+		return d.Zero
+	case "one":
+		return d.One
+	case "two":
+		return d.Two
+	case "few":
+		return d.Few
+	case "many":
+		return d.Many
+	default:
+		return d.Other
+	}
+}
+
+// set sets the form of the passed plural category.
+func (d *definition) set(category, form string) {
+	switch category {
+	case "zero":
+		d.Zero = form
+	case "one":
+		d.One = form
+	case "two":
+		d.Two = form
+	case "few":
+		d.Few = form
+	case "many":
+		d.Many = form
+	default:
+		d.Other = form
+	}
+}
+
+// categories returns the plural categories the definition has forms for.
+func (d *definition) categories() []string {
+	categories := make([]string, 0, len(allCategories))
+
+	for _, c := range allCategories {
+		if len(d.get(c)) > 0 {
+			categories = append(categories, c)
+		}
+	}
+
+	return categories
+}
+
+// mergeCategories returns the union of a and b in canonical order.
+func mergeCategories(a, b []string) []string {
+	merged := make([]string, 0, len(allCategories))
+
+	for _, c := range allCategories {
+		if containsString(a, c) || containsString(b, c) {
+			merged = append(merged, c)
+		}
+	}
+
+	return merged
+}
+
+func containsString(s []string, target string) bool {
+	for _, elem := range s {
+		if elem == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+type pluralForm struct {
+	categories []string
+	// expression is the gettext plural expression mapping n to the index of
+	// the category.
+	expression string
+}
+
+var (
+	oneOther  = pluralForm{categories: []string{"one", "other"}, expression: "(n != 1)"}
+	onlyOther = pluralForm{categories: []string{"other"}, expression: "0"}
+
+	// pluralForms are the plural forms of the supported base languages.
+	pluralForms = map[string]pluralForm{
+		"en": oneOther, "de": oneOther, "nl": oneOther, "sv": oneOther, "da": oneOther,
+		"nb": oneOther, "no": oneOther, "it": oneOther, "es": oneOther, "fi": oneOther,
+		"et": oneOther, "el": oneOther, "hu": oneOther, "bg": oneOther, "tr": oneOther,
+		"fr": {categories: []string{"one", "other"}, expression: "(n > 1)"},
+		"pt": {categories: []string{"one", "other"}, expression: "(n > 1)"},
+		"ja": onlyOther, "zh": onlyOther, "ko": onlyOther, "vi": onlyOther, "th": onlyOther,
+		"id": onlyOther,
+		"pl": {
+			categories: []string{"one", "few", "many"},
+			expression: "(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)",
+		},
+		"ru": {
+			categories: []string{"one", "few", "many"},
+			expression: "(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)",
+		},
+		"uk": {
+			categories: []string{"one", "few", "many"},
+			expression: "(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)",
+		},
+		"cs": {categories: []string{"one", "few", "other"}, expression: "(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2)"},
+		"sk": {categories: []string{"one", "few", "other"}, expression: "(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2)"},
+		"ar": {
+			categories: allCategories,
+			expression: "(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5)",
+		},
+	}
+)
+
+// pluralCategories returns the plural categories used by the passed
+// language.
+func pluralCategories(lang language.Tag) []string {
+	base, _ := lang.Base()
+
+	if form, ok := pluralForms[base.String()]; ok {
+		return form.categories
+	}
+
+	return oneOther.categories
+}
+
+// pluralFormsHeader returns the gettext Plural-Forms header for the passed
+// language and categories.
+func pluralFormsHeader(lang language.Tag, categories []string) string {
+	expression := "0"
+
+	base, _ := lang.Base()
+	if form, ok := pluralForms[base.String()]; ok && equalCategories(form.categories, categories) {
+		expression = form.expression
+	} else if equalCategories(oneOther.categories, categories) {
+		expression = oneOther.expression
+	}
+
+	return fmt.Sprintf("nplurals=%d; plural=%s;", len(categories), expression)
+}
+
+func equalCategories(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
@@ -0,0 +1,288 @@
+package i18nwrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+// roundTripFixture returns an exchangeFile with plural forms, placeholders,
+// multi-line strings and characters that need escaping.
+func roundTripFixture() *exchangeFile {
+	source := map[string]definition{
+		"plain":       {Other: "Hello"},
+		"placeholder": {Other: "Hello {{.name}}, you have {{.count}} messages."},
+		"multiline":   {Other: "First line\nSecond line\n"},
+		"escaped":     {Other: "Say \"hi\"\tand use a \\ backslash"},
+		"plural":      {One: "{{.count}} warning", Other: "{{.count}} warnings"},
+		"icu":         {Other: "{count, plural, one {# file} other {# files}}"},
+	}
+
+	target := map[string]definition{
+		"plain":       {Other: "Cześć"},
+		"placeholder": {Other: "Cześć {{.name}}, masz {{.count}} wiadomości."},
+		"multiline":   {Other: "Pierwsza linia\nDruga linia\n\nCzwarta linia"},
+		"escaped":     {Other: "Powiedz \"cześć\"\ti użyj \\ ukośnika"},
+		"plural": {
+			One:  "{{.count}} ostrzeżenie",
+			Few:  "{{.count}} ostrzeżenia",
+			Many: "{{.count}} ostrzeżeń",
+		},
+		"icu": {Other: "{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}"},
+	}
+
+	return newExchangeFile(language.Polish, source, target)
+}
+
+// writeJSON writes the target definitions of the passed exchangeFile as
+// levin translation file.
+func writeJSON(b *bytes.Buffer, f *exchangeFile) error {
+	defs := make(map[string]definition, len(f.Entries))
+	for _, e := range f.Entries {
+		defs[e.Term] = e.Target
+	}
+
+	return encodeTranslations(b, defs)
+}
+
+// readJSON reads a levin translation file with the targets of the
+// roundTripFixture.
+func readJSON(b *bytes.Buffer) (*exchangeFile, error) {
+	var translations []translation
+	if err := json.Unmarshal(b.Bytes(), &translations); err != nil {
+		return nil, err
+	}
+
+	f := roundTripFixture()
+
+	entries := make(map[string]*exchangeEntry, len(f.Entries))
+	for i, e := range f.Entries {
+		f.Entries[i].Target = definition{}
+		entries[e.Term] = &f.Entries[i]
+	}
+
+	for _, t := range translations {
+		e, ok := entries[t.Term]
+		if !ok {
+			return nil, fmt.Errorf("unexpected term %s", t.Term)
+		}
+
+		def, err := parseDefinition(t.Definition)
+		if err != nil {
+			return nil, err
+		}
+
+		e.Target = def
+	}
+
+	return f, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name  string
+		write func(*bytes.Buffer, *exchangeFile) error
+		read  func(*bytes.Buffer) (*exchangeFile, error)
+	}{
+		{
+			name:  "po",
+			write: func(b *bytes.Buffer, f *exchangeFile) error { return writePO(b, f) },
+			read:  func(b *bytes.Buffer) (*exchangeFile, error) { return readPO(b) },
+		},
+		{
+			name:  "xliff",
+			write: func(b *bytes.Buffer, f *exchangeFile) error { return writeXLIFF(b, f) },
+			read:  func(b *bytes.Buffer) (*exchangeFile, error) { return readXLIFF(b) },
+		},
+		{
+			name:  "json",
+			write: writeJSON,
+			read:  readJSON,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			expect := roundTripFixture()
+
+			var b bytes.Buffer
+			if err := c.write(&b, expect); err != nil {
+				t.Fatalf("unable to write: %v", err)
+			}
+
+			actual, err := c.read(&b)
+			if err != nil {
+				t.Fatalf("unable to read: %v", err)
+			}
+
+			if actual.Lang != expect.Lang {
+				t.Errorf("expected language %s, but got %s", expect.Lang, actual.Lang)
+			}
+
+			if !reflect.DeepEqual(actual.Categories, expect.Categories) {
+				t.Errorf("expected categories %v, but got %v", expect.Categories, actual.Categories)
+			}
+
+			if len(actual.Entries) != len(expect.Entries) {
+				t.Fatalf("expected %d entries, but got %d", len(expect.Entries), len(actual.Entries))
+			}
+
+			for i, e := range expect.Entries {
+				a := actual.Entries[i]
+
+				if a.Term != e.Term || a.Plural != e.Plural || a.Target != e.Target || a.Fuzzy {
+					t.Errorf("entry %s: expected %+v, but got %+v", e.Term, e, a)
+				}
+			}
+		})
+	}
+}
+
+func TestImport_Fuzzy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format Format
+		in     string
+	}{
+		{
+			name:   "po",
+			format: PO,
+			in: `msgid ""
+msgstr "Language: de\n"
+
+msgctxt "translated"
+msgid "Hello"
+msgstr "Hallo"
+
+#, fuzzy
+msgctxt "fuzzy"
+msgid "Bye"
+msgstr "Tschüss"
+`,
+		},
+		{
+			name:   "xliff",
+			format: XLIFF,
+			in: `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file original="levin" source-language="en" target-language="de" datatype="plaintext">
+    <body>
+      <trans-unit id="translated" resname="translated">
+        <source>Hello</source>
+        <target>Hallo</target>
+      </trans-unit>
+      <trans-unit id="fuzzy" resname="fuzzy">
+        <source>Bye</source>
+        <target state="needs-review-translation">Tschüss</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			var b bytes.Buffer
+
+			lang, fuzzy, err := Import(&b, strings.NewReader(c.in), c.format, "")
+			if err != nil {
+				t.Fatalf("unable to import: %v", err)
+			}
+
+			if lang != language.German {
+				t.Errorf("expected language de, but got %s", lang)
+			}
+
+			if !reflect.DeepEqual(fuzzy, []string{"fuzzy"}) {
+				t.Errorf("expected fuzzy terms [fuzzy], but got %v", fuzzy)
+			}
+
+			var translations []translation
+			if err := json.Unmarshal(b.Bytes(), &translations); err != nil {
+				t.Fatalf("unable to decode imported translations: %v", err)
+			}
+
+			if len(translations) != 1 || translations[0].Term != "translated" {
+				t.Errorf("expected only the translated term to be imported, but got %+v", translations)
+			}
+		})
+	}
+}
+
+func TestImport_Merge(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := `[
+  {"term": "kept", "definition": "Behalten"},
+  {"term": "fuzzy", "definition": "Alt"},
+  {"term": "replaced", "definition": "Alt"}
+]`
+
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(existing), 0o644); err != nil {
+		t.Fatalf("unable to write existing translations: %v", err)
+	}
+
+	// translations of other languages must not be merged
+	other := `[{"term": "other", "definition": "Autre"}]`
+
+	if err := os.WriteFile(filepath.Join(dir, "fr.json"), []byte(other), 0o644); err != nil {
+		t.Fatalf("unable to write existing translations: %v", err)
+	}
+
+	in := `msgid ""
+msgstr "Language: de\n"
+
+msgctxt "replaced"
+msgid "New"
+msgstr "Neu"
+
+#, fuzzy
+msgctxt "fuzzy"
+msgid "Old"
+msgstr "Ungeprüft"
+
+msgctxt "added"
+msgid "Added"
+msgstr "Hinzugefügt"
+`
+
+	var b bytes.Buffer
+
+	if _, _, err := Import(&b, strings.NewReader(in), PO, dir); err != nil {
+		t.Fatalf("unable to import: %v", err)
+	}
+
+	var translations []translation
+	if err := json.Unmarshal(b.Bytes(), &translations); err != nil {
+		t.Fatalf("unable to decode imported translations: %v", err)
+	}
+
+	actual := make(map[string]string, len(translations))
+	for _, t := range translations {
+		actual[t.Term] = string(t.Definition)
+	}
+
+	expect := map[string]string{
+		"added":    `"Hinzugefügt"`,
+		"fuzzy":    `"Alt"`,
+		"kept":     `"Behalten"`,
+		"replaced": `"Neu"`,
+	}
+
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected translations %v, but got %v", expect, actual)
+	}
+}
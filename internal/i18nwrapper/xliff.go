@@ -0,0 +1,194 @@
+package i18nwrapper
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+type (
+	xliffDocument struct {
+		XMLName xml.Name  `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+		Version string    `xml:"version,attr"`
+		File    xliffFile `xml:"file"`
+	}
+
+	xliffFile struct {
+		Original       string    `xml:"original,attr"`
+		SourceLanguage string    `xml:"source-language,attr"`
+		TargetLanguage string    `xml:"target-language,attr"`
+		Datatype       string    `xml:"datatype,attr"`
+		Body           xliffBody `xml:"body"`
+	}
+
+	xliffBody struct {
+		Units  []xliffUnit  `xml:"trans-unit"`
+		Groups []xliffGroup `xml:"group"`
+	}
+
+	// xliffGroup is used for plural terms.
+	// Resname is the term, and the Resnames of the units are the plural
+	// categories.
+	xliffGroup struct {
+		ID      string      `xml:"id,attr"`
+		Resname string      `xml:"resname,attr"`
+		Restype string      `xml:"restype,attr"`
+		Units   []xliffUnit `xml:"trans-unit"`
+	}
+
+	xliffUnit struct {
+		ID      string       `xml:"id,attr"`
+		Resname string       `xml:"resname,attr"`
+		Source  string       `xml:"source"`
+		Target  *xliffTarget `xml:"target"`
+	}
+
+	xliffTarget struct {
+		State string `xml:"state,attr,omitempty"`
+		Text  string `xml:",chardata"`
+	}
+)
+
+// xliffPluralRestype is the restype of groups containing plural forms.
+const xliffPluralRestype = "x-gettext-plurals"
+
+// writeXLIFF writes the passed exchangeFile as XLIFF 1.2 document.
+func writeXLIFF(w io.Writer, f *exchangeFile) error {
+	doc := xliffDocument{
+		Version: "1.2",
+		File: xliffFile{
+			Original:       "levin",
+			SourceLanguage: sourceLanguage.String(),
+			TargetLanguage: f.Lang.String(),
+			Datatype:       "plaintext",
+		},
+	}
+
+	for _, e := range f.Entries {
+		if !e.Plural {
+			doc.File.Body.Units = append(doc.File.Body.Units, xliffUnit{
+				ID:      e.Term,
+				Resname: e.Term,
+				Source:  e.Source.Other,
+				Target:  newXLIFFTarget(e.Target.Other),
+			})
+
+			continue
+		}
+
+		g := xliffGroup{
+			ID:      e.Term,
+			Resname: e.Term,
+			Restype: xliffPluralRestype,
+			Units:   make([]xliffUnit, len(f.Categories)),
+		}
+
+		for i, c := range f.Categories {
+			source := e.Source.get(c)
+			if len(source) == 0 {
+				source = e.Source.Other
+			}
+
+			g.Units[i] = xliffUnit{
+				ID:      e.Term + "[" + c + "]",
+				Resname: c,
+				Source:  source,
+				Target:  newXLIFFTarget(e.Target.get(c)),
+			}
+		}
+
+		doc.File.Body.Groups = append(doc.File.Body.Groups, g)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newXLIFFTarget(s string) *xliffTarget {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return &xliffTarget{Text: s}
+}
+
+// readXLIFF reads a XLIFF 1.2 document written by writeXLIFF, or by a
+// translation platform from a document written by writeXLIFF.
+func readXLIFF(r io.Reader) (*exchangeFile, error) {
+	var doc xliffDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.File.TargetLanguage) == 0 {
+		return nil, errors.New("i18nwrapper: XLIFF file has no target-language")
+	}
+
+	tag, err := language.Parse(doc.File.TargetLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &exchangeFile{Lang: tag}
+
+	for _, u := range doc.File.Body.Units {
+		e := exchangeEntry{Term: u.Resname}
+		if len(e.Term) == 0 {
+			e.Term = u.ID
+		}
+
+		e.Target.Other = u.target()
+		e.Fuzzy = u.fuzzy()
+		f.Entries = append(f.Entries, e)
+	}
+
+	for _, g := range doc.File.Body.Groups {
+		e := exchangeEntry{Term: g.Resname, Plural: true}
+		if len(e.Term) == 0 {
+			e.Term = g.ID
+		}
+
+		for _, u := range g.Units {
+			e.Target.set(u.Resname, u.target())
+			e.Fuzzy = e.Fuzzy || u.fuzzy()
+			f.Categories = mergeCategories(f.Categories, []string{u.Resname})
+		}
+
+		f.Entries = append(f.Entries, e)
+	}
+
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].Term < f.Entries[j].Term })
+
+	return f, nil
+}
+
+// target returns the text of the unit's target, or an empty string, if the
+// unit has no target.
+func (u *xliffUnit) target() string {
+	if u.Target == nil {
+		return ""
+	}
+
+	return u.Target.Text
+}
+
+// fuzzy checks if the target of the unit was marked as needing review, or
+// translation, which makes it the equivalent of a fuzzy PO translation.
+func (u *xliffUnit) fuzzy() bool {
+	return u.Target != nil && strings.HasPrefix(u.Target.State, "needs-")
+}
@@ -0,0 +1,37 @@
+// Package metrics provides the metrics levin exposes through expvar.
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// TranslationFallbacks counts the terms that weren't available in the
+// requested language, keyed by '$requested_lang>$used_lang'.
+var TranslationFallbacks = expvar.NewMap("translation_fallbacks")
+
+// CommandErrors counts the errors returned by commands, keyed by their error
+// class.
+var CommandErrors = expvar.NewMap("command_errors")
+
+// Serve serves the metrics under /debug/vars on the passed address in a
+// separate goroutine.
+// If addr is empty, Serve is a no-op.
+func Serve(addr string) {
+	if len(addr) == 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil { //nolint:gosec
+			zap.S().Named("metrics").
+				With("err", err, "addr", addr).
+				Error("unable to serve metrics")
+		}
+	}()
+}
@@ -0,0 +1,77 @@
+// Package languages provides the languages command.
+package languages
+
+import (
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/embedutil"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	i18nimpl "github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language/display"
+
+	"github.com/mavolin/levin/internal/i18nwrapper"
+)
+
+// Languages is the languages command.
+type Languages struct {
+	command.LocalizedMeta
+
+	bundle   *i18nimpl.Bundle
+	coverage []i18nwrapper.LanguageCoverage
+}
+
+var _ plugin.Command = new(Languages) // compile-time check
+
+// New creates a new Languages command, listing the languages of the passed
+// bundle.
+// coverage is the coverage of the bundle's languages, as returned by
+// i18nwrapper.Coverage.
+func New(bundle *i18nimpl.Bundle, coverage []i18nwrapper.LanguageCoverage) *Languages {
+	return &Languages{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "languages",
+			Aliases:          []string{"langs"},
+			ShortDescription: shortDescription,
+			LongDescription:  longDescription,
+			ChannelTypes:     plugin.AllChannels,
+			BotPermissions:   discord.PermissionSendMessages | discord.PermissionEmbedLinks,
+		},
+		bundle:   bundle,
+		coverage: coverage,
+	}
+}
+
+func (l *Languages) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	// the first language of the chain is the language that is actually used
+	current := i18nwrapper.FallbackChain(l.bundle, ctx.Localizer.Lang)[0]
+
+	var b strings.Builder
+
+	for _, c := range l.coverage {
+		entryConfig := entry
+		if c.Lang == current {
+			entryConfig = currentEntry
+		}
+
+		s, err := ctx.Localizer.Localize(entryConfig.
+			WithPlaceholders(entryPlaceholders{
+				Name:     display.Self.Name(c.Lang),
+				Code:     c.Lang.String(),
+				Coverage: c.Percent(),
+			}))
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+
+	return embedutil.NewBuilder().
+		WithSimpleTitlel(title).
+		WithDescription(b.String()).
+		WithSimpleFooterl(footer), nil
+}
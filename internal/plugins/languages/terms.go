@@ -0,0 +1,35 @@
+package languages
+
+import "github.com/mavolin/adam/pkg/i18n"
+
+// =============================================================================
+// Meta
+// =====================================================================================
+
+var (
+	shortDescription = i18n.NewFallbackConfig("plugin.languages.short_description",
+		"Lists the languages I speak.")
+	longDescription = i18n.NewFallbackConfig("plugin.languages.long_description",
+		"Lists the languages I speak, how much of me is translated to them, and which language is used here.")
+)
+
+// =============================================================================
+// Response
+// =====================================================================================
+
+var (
+	title = i18n.NewFallbackConfig("plugin.languages.response.title", "Languages")
+
+	entry        = i18n.NewFallbackConfig("plugin.languages.response.entry", "{{.name}} (`{{.code}}`): {{.coverage}}%")
+	currentEntry = i18n.NewFallbackConfig("plugin.languages.response.current_entry",
+		"**{{.name}} (`{{.code}}`): {{.coverage}}%** ← used here")
+
+	footer = i18n.NewFallbackConfig("plugin.languages.response.footer",
+		"Percentages are relative to the English translation.")
+)
+
+type entryPlaceholders struct {
+	Name     string
+	Code     string
+	Coverage int
+}
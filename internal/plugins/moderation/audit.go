@@ -0,0 +1,116 @@
+package moderation
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/arikawa/v2/utils/json/option"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+// maxAuditLogReasonLength is the maximum length of an audit log reason.
+const maxAuditLogReasonLength = 512
+
+// auditLogReason returns the reason displayed in the audit log for an action
+// taken by the passed moderator.
+// Since levin is displayed as the executor of the action, the moderator is
+// included in the reason.
+func auditLogReason(moderator discord.User, reason string) string {
+	r := discordutil.UserTag(moderator)
+	if len(reason) > 0 {
+		r += ": " + reason
+	}
+
+	return discordutil.Truncate(r, maxAuditLogReasonLength)
+}
+
+// withReason returns a httputil.RequestOption that sets the audit log reason
+// of the request.
+func withReason(reason string) httputil.RequestOption {
+	return httputil.WithHeaders(http.Header{
+		"X-Audit-Log-Reason": {url.PathEscape(reason)},
+	})
+}
+
+func memberEndpoint(guildID discord.GuildID, userID discord.UserID) string {
+	return api.EndpointGuilds + guildID.String() + "/members/" + userID.String()
+}
+
+func banEndpoint(guildID discord.GuildID, userID discord.UserID) string {
+	return api.EndpointGuilds + guildID.String() + "/bans/" + userID.String()
+}
+
+// kick kicks the user with the passed id, using the passed audit log reason.
+func kick(s *state.State, guildID discord.GuildID, userID discord.UserID, reason string) error {
+	return s.FastRequest(http.MethodDelete, memberEndpoint(guildID, userID), withReason(reason))
+}
+
+// ban bans the user with the passed id, using the passed audit log reason.
+// deleteDays is the number of days of messages to delete.
+func ban(s *state.State, guildID discord.GuildID, userID discord.UserID, deleteDays int, reason string) error {
+	endpoint := banEndpoint(guildID, userID)
+	if deleteDays > 0 {
+		endpoint += "?delete_message_days=" + strconv.Itoa(deleteDays)
+	}
+
+	return s.FastRequest(http.MethodPut, endpoint, withReason(reason))
+}
+
+// unban unbans the user with the passed id, using the passed audit log
+// reason.
+func unban(s *state.State, guildID discord.GuildID, userID discord.UserID, reason string) error {
+	return s.FastRequest(http.MethodDelete, banEndpoint(guildID, userID), withReason(reason))
+}
+
+// addRole adds the role with the passed id to the user with the passed id,
+// using the passed audit log reason.
+func addRole(
+	s *state.State, guildID discord.GuildID, userID discord.UserID, roleID discord.RoleID, reason string,
+) error {
+	return s.FastRequest(http.MethodPut, memberEndpoint(guildID, userID)+"/roles/"+roleID.String(),
+		withReason(reason))
+}
+
+// removeRole removes the role with the passed id from the user with the
+// passed id, using the passed audit log reason.
+func removeRole(
+	s *state.State, guildID discord.GuildID, userID discord.UserID, roleID discord.RoleID, reason string,
+) error {
+	return s.FastRequest(http.MethodDelete, memberEndpoint(guildID, userID)+"/roles/"+roleID.String(),
+		withReason(reason))
+}
+
+func overwriteEndpoint(channelID discord.ChannelID, overwriteID discord.Snowflake) string {
+	return api.EndpointChannels + channelID.String() + "/permissions/" + overwriteID.String()
+}
+
+// editOverwrite creates or replaces the passed permission overwrite, using
+// the passed audit log reason.
+func editOverwrite(s *state.State, channelID discord.ChannelID, o discord.Overwrite, reason string) error {
+	data := api.EditChannelPermissionData{Type: o.Type, Allow: o.Allow, Deny: o.Deny}
+
+	return s.FastRequest(http.MethodPut, overwriteEndpoint(channelID, o.ID),
+		httputil.WithJSONBody(data), withReason(reason))
+}
+
+// deleteOverwrite deletes the permission overwrite with the passed id, using
+// the passed audit log reason.
+func deleteOverwrite(s *state.State, channelID discord.ChannelID, overwriteID discord.Snowflake, reason string) error {
+	return s.FastRequest(http.MethodDelete, overwriteEndpoint(channelID, overwriteID), withReason(reason))
+}
+
+// setSlowmode sets the slowmode of the channel with the passed id, using the
+// passed audit log reason.
+func setSlowmode(s *state.State, channelID discord.ChannelID, d time.Duration, reason string) error {
+	data := api.ModifyChannelData{UserRateLimit: option.NewNullableUint(uint(d / time.Second))}
+
+	return s.FastRequest(http.MethodPatch, api.EndpointChannels+channelID.String(),
+		httputil.WithJSONBody(data), withReason(reason))
+}
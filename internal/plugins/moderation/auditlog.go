@@ -0,0 +1,119 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/repository"
+)
+
+const (
+	// auditLogDelay is the time waited before looking up the audit log entry
+	// of an event, as entries are sometimes created after the event is sent.
+	auditLogDelay = 2 * time.Second
+	// maxAuditLogAge is the maximum age of an audit log entry, to be
+	// considered the cause of an event.
+	maxAuditLogAge = 30 * time.Second
+)
+
+// IngestAuditLog returns a handler for all events, that records bans, unbans
+// and kicks made through Discord directly, i.e. not using levin, as cases.
+// The moderator and reason of those cases are taken from the audit log.
+func (l *CaseLog) IngestAuditLog() func(*state.State, interface{}) {
+	return func(_ *state.State, e interface{}) {
+		var (
+			guildID discord.GuildID
+			userID  discord.UserID
+			action  discord.AuditLogEvent
+			t       repository.CaseType
+		)
+
+		switch e := e.(type) {
+		case *state.GuildBanAddEvent:
+			guildID, userID, action, t = e.GuildID, e.User.ID, discord.MemberBanAdd, repository.CaseBan
+		case *state.GuildBanRemoveEvent:
+			guildID, userID, action, t = e.GuildID, e.User.ID, discord.MemberBanRemove, repository.CaseUnban
+		case *state.GuildMemberRemoveEvent:
+			// members also get removed, if they leave, which is why we only
+			// record a case, if there is an audit log entry for a kick
+			guildID, userID, action, t = e.GuildID, e.User.ID, discord.MemberKick, repository.CaseKick
+		default:
+			return
+		}
+
+		time.AfterFunc(auditLogDelay, func() { l.ingest(guildID, userID, action, t) })
+	}
+}
+
+// ingest records a case for the audit log entry of the passed type,
+// targeting the user with the passed id.
+// If there is no such entry, or levin is the entry's executor, and
+// therefore already recorded the case, ingest is a no-op.
+func (l *CaseLog) ingest(
+	guildID discord.GuildID, userID discord.UserID, action discord.AuditLogEvent, t repository.CaseType,
+) {
+	log := l.log(repository.Case{GuildID: guildID}).With("user_id", userID, "action_type", action)
+
+	entry, err := l.findAuditLogEntry(guildID, userID, action)
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to get audit log entry")
+		return
+	} else if entry == nil {
+		return
+	}
+
+	me, err := l.s.Me()
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to get self")
+		return
+	}
+
+	if entry.UserID == me.ID {
+		return
+	}
+
+	c := &repository.Case{
+		GuildID:     guildID,
+		Type:        t,
+		UserID:      userID,
+		ModeratorID: entry.UserID,
+		Reason:      entry.Reason,
+		Time:        discord.Snowflake(entry.ID).Time(),
+	}
+
+	if err := l.Record(c); err != nil {
+		log.With("err", err).
+			Error("unable to record case from audit log")
+	}
+}
+
+// findAuditLogEntry returns the most recent audit log entry of the passed
+// type targeting the user with the passed id, if it is not older than
+// maxAuditLogAge.
+func (l *CaseLog) findAuditLogEntry(
+	guildID discord.GuildID, userID discord.UserID, action discord.AuditLogEvent,
+) (*discord.AuditLogEntry, error) {
+	auditLog, err := l.s.AuditLog(guildID, api.AuditLogData{ActionType: action, Limit: 10})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range auditLog.Entries {
+		if discord.UserID(e.TargetID) != userID {
+			continue
+		}
+
+		if time.Since(discord.Snowflake(e.ID).Time()) > maxAuditLogAge {
+			return nil, nil
+		}
+
+		return &auditLog.Entries[i], nil
+	}
+
+	return nil, nil
+}
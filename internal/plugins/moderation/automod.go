@@ -0,0 +1,420 @@
+package moderation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// The actions automod can take.
+const (
+	automodDelete  = "delete"
+	automodWarn    = "warn"
+	automodTimeout = "timeout"
+	automodLog     = "log"
+)
+
+// maxAutomodLogContentLength is the maximum length of the message content
+// shown in automod logs.
+const maxAutomodLogContentLength = 1024
+
+// Automod automatically moderates messages using the filters configured in
+// config.C.Moderation.Automod.
+type Automod struct {
+	s       *state.State
+	expirer *Expirer
+	cases   *CaseLog
+
+	spam *spamTracker
+	// words are the compiled word filters of the guilds.
+	words map[discord.GuildID][]*wordFilter
+}
+
+// NewAutomod creates a new *Automod, that uses the passed *Expirer to lift
+// timeouts and the passed *CaseLog to record the actions it takes.
+// It returns an error, if the word filters of a guild are invalid.
+func NewAutomod(s *state.State, e *Expirer, cl *CaseLog) (*Automod, error) {
+	a := &Automod{
+		s:       s,
+		expirer: e,
+		cases:   cl,
+		spam:    newSpamTracker(),
+		words:   make(map[discord.GuildID][]*wordFilter, len(config.C.Moderation.Automod)),
+	}
+
+	for guildID, cfg := range config.C.Moderation.Automod {
+		for i, f := range cfg.Words {
+			wf, err := compileWordFilter(f, i)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid word filter %d of guild %d", i, guildID)
+			}
+
+			a.words[guildID] = append(a.words[guildID], wf)
+		}
+	}
+
+	return a, nil
+}
+
+// Middleware returns the message create middleware, that checks messages
+// against the automod filters of their guild.
+// If a message is deleted, the middleware prevents it from being routed.
+//
+// It must be added before all other message create middlewares.
+func (a *Automod) Middleware() func(*state.State, *state.MessageCreateEvent) error {
+	return func(_ *state.State, e *state.MessageCreateEvent) error {
+		if a.moderate(e.Message, e.Member, true) {
+			return state.Filtered
+		}
+
+		return nil
+	}
+}
+
+// UpdateMiddleware returns the message update middleware, that checks edited
+// messages against the content filters of their guild.
+// Edits aren't checked against the spam filter, since they aren't new
+// messages.
+// If a message is deleted, the middleware prevents it from being routed.
+//
+// It must be added before all other message update middlewares.
+func (a *Automod) UpdateMiddleware() func(*state.State, *state.MessageUpdateEvent) error {
+	return func(_ *state.State, e *state.MessageUpdateEvent) error {
+		// updates without an author, such as embeds being added, don't
+		// change the content
+		if !e.Author.ID.IsValid() {
+			return nil
+		}
+
+		if a.moderate(e.Message, e.Member, false) {
+			return state.Filtered
+		}
+
+		return nil
+	}
+}
+
+// moderate checks the passed message against the automod filters of its
+// guild, and takes the actions of the triggered filter, if any.
+// If spam is false, the spam filter is skipped.
+// It returns whether the message was deleted.
+func (a *Automod) moderate(m discord.Message, member *discord.Member, spam bool) bool {
+	if !m.GuildID.IsValid() || m.Author.Bot || m.WebhookID.IsValid() {
+		return false
+	}
+
+	cfg, ok := config.C.Moderation.Automod[m.GuildID]
+	if !ok || exempt(cfg, m.ChannelID, member) {
+		return false
+	}
+
+	t, actions, messageIDs := a.check(cfg, m, spam)
+	if t == nil {
+		return false
+	}
+
+	return a.trigger(m, *t, actions, messageIDs)
+}
+
+// exempt checks if messages sent by the passed member in the channel with
+// the passed id are exempt from automod.
+func exempt(cfg config.AutomodConfig, channelID discord.ChannelID, member *discord.Member) bool {
+	for _, id := range cfg.ExemptChannels {
+		if id == channelID {
+			return true
+		}
+	}
+
+	if member == nil {
+		return false
+	}
+
+	for _, exemptID := range cfg.ExemptRoles {
+		for _, id := range member.RoleIDs {
+			if id == exemptID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// check checks the passed message against the passed filters.
+// If spam is false, the spam filter is skipped.
+// If a filter is triggered, check returns the trigger, the actions to take
+// and the ids of the messages that triggered the filter.
+func (a *Automod) check(
+	cfg config.AutomodConfig, m discord.Message, spam bool,
+) (*repository.AutomodTrigger, config.AutomodActions, []discord.MessageID) {
+	t := &repository.AutomodTrigger{
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		UserID:    m.Author.ID,
+		MessageID: m.ID,
+	}
+
+	messageIDs := []discord.MessageID{m.ID}
+
+	// the spam filter must see every message, which is why it is checked
+	// first
+	if spam && cfg.Spam != nil {
+		if ids := a.spam.track(*cfg.Spam, m); ids != nil {
+			t.Filter = "spam"
+			return t, cfg.Spam.AutomodActions, ids
+		}
+	}
+
+	if cfg.Mentions != nil && countMentions(m) > cfg.Mentions.Max {
+		t.Filter = "mentions"
+		return t, cfg.Mentions.AutomodActions, messageIDs
+	}
+
+	if cfg.Invites != nil {
+		if code, ok := findInvite(*cfg.Invites, m.Content); ok {
+			t.Filter, t.Match = "invites", code
+			return t, cfg.Invites.AutomodActions, messageIDs
+		}
+	}
+
+	if cfg.Links != nil {
+		if host, ok := findLink(*cfg.Links, m.Content); ok {
+			t.Filter, t.Match = "links", host
+			return t, cfg.Links.AutomodActions, messageIDs
+		}
+	}
+
+	for _, f := range a.words[m.GuildID] {
+		if match, ok := f.match(m.Content); ok {
+			t.Filter, t.Match = f.name, match
+			return t, f.AutomodActions, messageIDs
+		}
+	}
+
+	return nil, config.AutomodActions{}, nil
+}
+
+// trigger takes the passed actions for the passed trigger.
+// It returns whether the messages that triggered the filter were deleted.
+//
+// Only the messages are deleted synchronously, since that decides whether
+// the message is routed.
+// All other actions are taken in a separate goroutine, so that they don't
+// block the gateway.
+func (a *Automod) trigger(
+	m discord.Message, t repository.AutomodTrigger, f config.AutomodActions, messageIDs []discord.MessageID,
+) (deleted bool) {
+	log := triggerLog(t)
+
+	t.Actions = f.Actions
+	t.Time = time.Now()
+
+	for _, action := range f.Actions {
+		if strings.ToLower(action) != automodDelete {
+			continue
+		}
+
+		if err := a.delete(t.ChannelID, messageIDs); err != nil {
+			log.With("err", err, "action", action).
+				Warn("unable to take action")
+		} else {
+			deleted = true
+		}
+
+		break
+	}
+
+	go a.takeActions(m, t, f)
+
+	return deleted
+}
+
+func triggerLog(t repository.AutomodTrigger) *zap.SugaredLogger {
+	return zap.S().Named("automod").With(
+		"guild_id", t.GuildID,
+		"channel_id", t.ChannelID,
+		"user_id", t.UserID,
+		"filter", t.Filter,
+	)
+}
+
+// takeActions records the passed trigger and takes the passed actions,
+// except delete, which is taken by trigger.
+func (a *Automod) takeActions(m discord.Message, t repository.AutomodTrigger, f config.AutomodActions) {
+	log := triggerLog(t)
+
+	if err := a.cases.repo.AddAutomodTrigger(&t); err != nil {
+		log.With("err", err).
+			Error("unable to record trigger")
+	}
+
+	log.With("match", t.Match, "actions", t.Actions).
+		Info("filter triggered")
+
+	reason := autoReason{key: reasonAutomod, placeholders: map[string]string{"filter": t.Filter}}
+
+	for _, action := range f.Actions {
+		var err error
+
+		switch strings.ToLower(action) {
+		case automodDelete: // already taken
+		case automodWarn:
+			err = a.warn(t.GuildID, t.UserID, reason)
+		case automodTimeout:
+			err = a.timeout(t.GuildID, t.UserID, f.TimeoutDuration, reason)
+		case automodLog:
+			err = a.log(m, t)
+		default:
+			log.With("action", action).
+				Warn("unknown action")
+		}
+
+		if err != nil {
+			log.With("err", err, "action", action).
+				Warn("unable to take action")
+		}
+	}
+}
+
+// self returns the member of levin in the guild with the passed id.
+func (a *Automod) self(guildID discord.GuildID) (*discord.Member, error) {
+	me, err := a.s.Me()
+	if err != nil {
+		return nil, err
+	}
+
+	return a.s.Member(guildID, me.ID)
+}
+
+func (a *Automod) delete(channelID discord.ChannelID, messageIDs []discord.MessageID) error {
+	if len(messageIDs) == 1 {
+		return a.s.DeleteMessage(channelID, messageIDs[0])
+	}
+
+	return a.s.DeleteMessages(channelID, messageIDs)
+}
+
+// warn warns the user with the passed id, and escalates, if the user
+// reached the number of warnings of an escalation.
+func (a *Automod) warn(guildID discord.GuildID, userID discord.UserID, reason autoReason) error {
+	self, err := a.self(guildID)
+	if err != nil {
+		return err
+	}
+
+	c := &repository.Case{
+		GuildID:     guildID,
+		Type:        repository.CaseWarn,
+		UserID:      userID,
+		ModeratorID: self.User.ID,
+	}
+	reason.apply(c)
+
+	if err := a.cases.Record(c); err != nil {
+		return err
+	}
+
+	active, err := addWarning(a.cases.repo, *c)
+	if err != nil {
+		return err
+	}
+
+	l := a.cases.localizer(guildID)
+
+	localized, err := localizeReason(l, c.Reason, c.ReasonKey, c.ReasonPlaceholders)
+	if err != nil {
+		localized = c.Reason
+	}
+
+	notifyWarning(a.s, l, guildID, userID, localized)
+
+	esc, n := matchEscalation(active, config.C.Moderation.Warnings[guildID])
+	if esc == nil {
+		return nil
+	}
+
+	_, err = escalate(a.s, a.expirer, a.cases, *self, userID, guildID, *esc, n)
+	return err
+}
+
+// timeout mutes the user with the passed id for the passed duration.
+func (a *Automod) timeout(guildID discord.GuildID, userID discord.UserID, d time.Duration, reason autoReason) error {
+	self, err := a.self(guildID)
+	if err != nil {
+		return err
+	}
+
+	_, err = punish(a.s, a.expirer, a.cases, *self, userID, guildID, actionMute, d, reason)
+	return err
+}
+
+// log posts the passed trigger to the mod-log channel of its guild, if it
+// has one.
+func (a *Automod) log(m discord.Message, t repository.AutomodTrigger) error {
+	channelID, ok := config.C.Moderation.LogChannels[t.GuildID]
+	if !ok {
+		return nil
+	}
+
+	embed, err := automodEmbed(a.cases.localizer(t.GuildID), m, t)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.s.SendEmbed(channelID, embed)
+	return err
+}
+
+// automodEmbed creates the embed logging the passed trigger caused by the
+// passed message.
+func automodEmbed(l *i18n.Localizer, m discord.Message, t repository.AutomodTrigger) (discord.Embed, error) {
+	var err error
+
+	localize := func(cfg *i18n.Config) string {
+		if err != nil {
+			return ""
+		}
+
+		var s string
+		s, err = l.Localize(cfg)
+
+		return s
+	}
+
+	e := discord.Embed{
+		Title:       localize(automodLogTitle.WithPlaceholders(automodLogTitlePlaceholders{Filter: t.Filter})),
+		Description: discordutil.Truncate(m.Content, maxAutomodLogContentLength),
+		Color:       0xe67e22,
+		Timestamp:   discord.NewTimestamp(t.Time),
+		Fields: []discord.EmbedField{
+			{Name: localize(caseUserField), Value: userField(t.UserID), Inline: true},
+			{Name: localize(automodChannelField), Value: t.ChannelID.Mention(), Inline: true},
+		},
+	}
+
+	if len(t.Match) > 0 {
+		e.Fields = append(e.Fields, discord.EmbedField{
+			Name:   localize(automodMatchField),
+			Value:  "`" + discordutil.Truncate(t.Match, maxHistoryReasonLength) + "`",
+			Inline: true,
+		})
+	}
+
+	if len(t.Actions) > 0 {
+		e.Fields = append(e.Fields, discord.EmbedField{
+			Name:  localize(automodActionsField),
+			Value: strings.Join(t.Actions, ", "),
+		})
+	}
+
+	return e, err
+}
@@ -0,0 +1,295 @@
+package moderation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// =============================================================================
+// Spam
+// =====================================================================================
+
+const (
+	// spamSweepInterval is the interval in which the spamTracker removes
+	// users that stopped sending messages.
+	spamSweepInterval = time.Minute
+	// spamRetention is the time the spamTracker remembers messages.
+	// Spam filters with longer intervals are effectively capped at it.
+	spamRetention = time.Hour
+)
+
+type (
+	// spamTracker tracks the messages users send in each channel.
+	spamTracker struct {
+		mutex     sync.Mutex
+		messages  map[spamKey][]spamMessage
+		lastSweep time.Time
+	}
+
+	spamKey struct {
+		channelID discord.ChannelID
+		userID    discord.UserID
+	}
+
+	spamMessage struct {
+		id   discord.MessageID
+		time time.Time
+	}
+)
+
+func newSpamTracker() *spamTracker {
+	return &spamTracker{
+		messages:  make(map[spamKey][]spamMessage),
+		lastSweep: time.Now(),
+	}
+}
+
+// track tracks the passed message.
+// If the author of the message exceeded the passed filter's limit, track
+// returns the ids of the messages they sent within the filter's interval,
+// and forgets about them, so that the filter isn't triggered again, until
+// the limit is exceeded anew.
+func (t *spamTracker) track(f config.SpamFilter, m discord.Message) []discord.MessageID {
+	key := spamKey{channelID: m.ChannelID, userID: m.Author.ID}
+	now := time.Now()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.sweep(now)
+
+	ms := append(t.messages[key], spamMessage{id: m.ID, time: now})
+
+	// remove the messages sent before the interval
+	for len(ms) > 0 && now.Sub(ms[0].time) > f.Interval {
+		ms = ms[1:]
+	}
+
+	if len(ms) <= f.Messages {
+		t.messages[key] = ms
+		return nil
+	}
+
+	delete(t.messages, key)
+
+	ids := make([]discord.MessageID, len(ms))
+	for i, sm := range ms {
+		ids[i] = sm.id
+	}
+
+	return ids
+}
+
+// sweep removes the users that haven't sent any messages within
+// spamRetention, if the last sweep was more than spamSweepInterval ago.
+func (t *spamTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < spamSweepInterval {
+		return
+	}
+
+	t.lastSweep = now
+
+	for key, ms := range t.messages {
+		if len(ms) == 0 || now.Sub(ms[len(ms)-1].time) > spamRetention {
+			delete(t.messages, key)
+		}
+	}
+}
+
+// =============================================================================
+// Mentions
+// =====================================================================================
+
+// countMentions returns the number of users and roles mentioned in the
+// passed message.
+func countMentions(m discord.Message) int {
+	users := make(map[discord.UserID]struct{}, len(m.Mentions))
+	for _, u := range m.Mentions {
+		users[u.ID] = struct{}{}
+	}
+
+	n := len(users) + len(m.MentionRoleIDs)
+	if m.MentionEveryone {
+		n++
+	}
+
+	return n
+}
+
+// =============================================================================
+// Invites and Links
+// =====================================================================================
+
+var (
+	inviteRegexp = regexp.MustCompile(`(?i)(?:discord(?:app)?\.com/invite|discord\.gg)/([a-z0-9-]+)`)
+	linkRegexp   = regexp.MustCompile(`(?i)https?://([^\s/?#<>]+)`)
+)
+
+// listFiltered checks if the passed item is filtered by the passed
+// config.ListFilter, using match to check if the item matches an entry of
+// one of the lists.
+func listFiltered(f config.ListFilter, item string, match func(item, entry string) bool) bool {
+	for _, entry := range f.Allow {
+		if match(item, entry) {
+			return false
+		}
+	}
+
+	if len(f.Deny) == 0 {
+		return true
+	}
+
+	for _, entry := range f.Deny {
+		if match(item, entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findInvite returns the code of the first invite in content that is
+// filtered by the passed config.ListFilter.
+func findInvite(f config.ListFilter, content string) (string, bool) {
+	for _, m := range inviteRegexp.FindAllStringSubmatch(content, -1) {
+		// invite codes are case-sensitive
+		if listFiltered(f, m[1], func(code, entry string) bool { return code == entry }) {
+			return m[1], true
+		}
+	}
+
+	return "", false
+}
+
+// findLink returns the host of the first link in content that is filtered
+// by the passed config.ListFilter.
+func findLink(f config.ListFilter, content string) (string, bool) {
+	for _, m := range linkRegexp.FindAllStringSubmatch(content, -1) {
+		host := linkHost(m[1])
+		if listFiltered(f, host, matchDomain) {
+			return host, true
+		}
+	}
+
+	return "", false
+}
+
+// linkHost returns the host of the passed authority of a URL, i.e. without
+// user info and port.
+func linkHost(authority string) string {
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		authority = authority[i+1:]
+	}
+
+	if i := strings.LastIndex(authority, ":"); i >= 0 {
+		authority = authority[:i]
+	}
+
+	return strings.TrimSuffix(strings.ToLower(authority), ".")
+}
+
+// matchDomain checks if the passed host is the passed domain or one of its
+// subdomains.
+func matchDomain(host, domain string) bool {
+	domain = strings.ToLower(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// =============================================================================
+// Words
+// =====================================================================================
+
+// leetReplacer translates leetspeak to letters.
+// Characters commonly used as punctuation, such as '!', are not translated,
+// so that they still separate words.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g",
+	"@", "a", "$", "s", "€", "e",
+)
+
+// normalize lowercases s and translates leetspeak.
+func normalize(s string) string {
+	return leetReplacer.Replace(strings.ToLower(s))
+}
+
+// wordFilter is a compiled config.WordFilter.
+type wordFilter struct {
+	config.WordFilter
+	// name is the name of the filter used in trigger records.
+	name      string
+	wildcards []*regexp.Regexp
+	regexps   []*regexp.Regexp
+}
+
+// compileWordFilter compiles the passed config.WordFilter, which is the i-th
+// word filter of its guild.
+func compileWordFilter(f config.WordFilter, i int) (*wordFilter, error) {
+	wf := &wordFilter{
+		WordFilter: f,
+		name:       "words/" + f.Name,
+		wildcards:  make([]*regexp.Regexp, len(f.Wildcards)),
+		regexps:    make([]*regexp.Regexp, len(f.Regexps)),
+	}
+
+	if len(f.Name) == 0 {
+		wf.name = "words/" + strconv.Itoa(i)
+	}
+
+	var err error
+
+	for i, w := range f.Wildcards {
+		if wf.wildcards[i], err = wildcardRegexp(w); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, r := range f.Regexps {
+		if wf.regexps[i], err = regexp.Compile(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return wf, nil
+}
+
+// wildcardRegexp compiles the passed wildcard pattern to a regular
+// expression matching whole words in normalized content.
+// The matched word is the first submatch.
+func wildcardRegexp(pattern string) (*regexp.Regexp, error) {
+	parts := strings.Split(normalize(pattern), "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+
+	return regexp.Compile(`(?:^|[^\p{L}\p{N}])(` + strings.Join(parts, `\S*?`) + `)(?:$|[^\p{L}\p{N}])`)
+}
+
+// match returns the first match of the filter in the passed content.
+func (f *wordFilter) match(content string) (string, bool) {
+	normalized := normalize(content)
+
+	for _, re := range f.wildcards {
+		if m := re.FindStringSubmatch(normalized); m != nil {
+			return m[1], true
+		}
+	}
+
+	for _, re := range f.regexps {
+		if loc := re.FindStringIndex(content); loc != nil {
+			return content[loc[0]:loc[1]], true
+		}
+
+		if loc := re.FindStringIndex(normalized); loc != nil {
+			return normalized[loc[0]:loc[1]], true
+		}
+	}
+
+	return "", false
+}
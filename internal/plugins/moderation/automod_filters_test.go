@@ -0,0 +1,236 @@
+package moderation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		s      string
+		expect string
+	}{
+		{name: "lowercase", s: "HeLLo", expect: "hello"},
+		{name: "digits", s: "h3ll0 w0r1d", expect: "hello worid"},
+		{name: "symbols", s: "$p@m", expect: "spam"},
+		{name: "euro", s: "€vil", expect: "evil"},
+		{name: "punctuation", s: "b4d!", expect: "bad!"},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			if actual := normalize(c.s); actual != c.expect {
+				t.Errorf("expected %q, but got %q", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestWildcardRegexp(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+		content string
+		expect  string // empty if no match is expected
+	}{
+		{name: "whole word", pattern: "bad", content: "this is bad.", expect: "bad"},
+		{name: "no partial word", pattern: "bad", content: "badly done", expect: ""},
+		{name: "trailing wildcard", pattern: "b4d*", content: "what a badass", expect: "badass"},
+		{name: "trailing wildcard exact", pattern: "b4d*", content: "b4d", expect: "bad"},
+		{name: "trailing wildcard inside word", pattern: "b4d*", content: "embadded", expect: ""},
+		{name: "leading wildcard", pattern: "*bad", content: "superbad!", expect: "superbad"},
+		{name: "inner wildcard", pattern: "f*k", content: "oh, f00k", expect: "fook"},
+		{name: "leetspeak content", pattern: "spam", content: "$P4M", expect: "spam"},
+		{name: "meta characters quoted", pattern: "a.b", content: "axb", expect: ""},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			re, err := wildcardRegexp(c.pattern)
+			if err != nil {
+				t.Fatalf("unable to compile pattern: %v", err)
+			}
+
+			var actual string
+			if m := re.FindStringSubmatch(normalize(c.content)); m != nil {
+				actual = m[1]
+			}
+
+			if actual != c.expect {
+				t.Errorf("expected match %q, but got %q", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestListFiltered(t *testing.T) {
+	equal := func(item, entry string) bool { return item == entry }
+
+	testCases := []struct {
+		name   string
+		filter config.ListFilter
+		item   string
+		expect bool
+	}{
+		{name: "no lists", filter: config.ListFilter{}, item: "a", expect: true},
+		{name: "allowed", filter: config.ListFilter{Allow: []string{"a"}}, item: "a", expect: false},
+		{name: "not allowed", filter: config.ListFilter{Allow: []string{"a"}}, item: "b", expect: true},
+		{name: "denied", filter: config.ListFilter{Deny: []string{"a"}}, item: "a", expect: true},
+		{name: "not denied", filter: config.ListFilter{Deny: []string{"a"}}, item: "b", expect: false},
+		{
+			name:   "allow precedes deny",
+			filter: config.ListFilter{Allow: []string{"a"}, Deny: []string{"a"}},
+			item:   "a",
+			expect: false,
+		},
+		{
+			name:   "neither allowed nor denied",
+			filter: config.ListFilter{Allow: []string{"a"}, Deny: []string{"b"}},
+			item:   "c",
+			expect: false,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			if actual := listFiltered(c.filter, c.item, equal); actual != c.expect {
+				t.Errorf("expected %t, but got %t", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	testCases := []struct {
+		name   string
+		host   string
+		domain string
+		expect bool
+	}{
+		{name: "equal", host: "example.com", domain: "example.com", expect: true},
+		{name: "subdomain", host: "www.example.com", domain: "example.com", expect: true},
+		{name: "nested subdomain", host: "a.b.example.com", domain: "example.com", expect: true},
+		{name: "case insensitive domain", host: "example.com", domain: "Example.COM", expect: true},
+		{name: "suffix without dot", host: "badexample.com", domain: "example.com", expect: false},
+		{name: "parent domain", host: "example.com", domain: "www.example.com", expect: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			if actual := matchDomain(c.host, c.domain); actual != c.expect {
+				t.Errorf("expected %t, but got %t", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestLinkHost(t *testing.T) {
+	testCases := []struct {
+		name      string
+		authority string
+		expect    string
+	}{
+		{name: "host", authority: "example.com", expect: "example.com"},
+		{name: "uppercase", authority: "Example.COM", expect: "example.com"},
+		{name: "port", authority: "example.com:8080", expect: "example.com"},
+		{name: "user info", authority: "user:pass@example.com", expect: "example.com"},
+		{name: "user info and port", authority: "user:pass@example.com:443", expect: "example.com"},
+		{name: "host as user info", authority: "trusted.com@example.com", expect: "example.com"},
+		{name: "trailing dot", authority: "example.com.", expect: "example.com"},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			if actual := linkHost(c.authority); actual != c.expect {
+				t.Errorf("expected %q, but got %q", c.expect, actual)
+			}
+		})
+	}
+}
+
+func TestSpamTracker_Track(t *testing.T) {
+	f := config.SpamFilter{Messages: 2, Interval: time.Minute}
+
+	message := func(id discord.MessageID, channelID discord.ChannelID) discord.Message {
+		return discord.Message{ID: id, ChannelID: channelID, Author: discord.User{ID: 1}}
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		st := newSpamTracker()
+
+		for id := discord.MessageID(1); id <= 2; id++ {
+			if ids := st.track(f, message(id, 1)); ids != nil {
+				t.Fatalf("expected message %d to be within the limit, but got %v", id, ids)
+			}
+		}
+
+		// messages in other channels are tracked separately
+		if ids := st.track(f, message(3, 2)); ids != nil {
+			t.Fatalf("expected message in other channel to be within the limit, but got %v", ids)
+		}
+
+		ids := st.track(f, message(4, 1))
+
+		expect := []discord.MessageID{1, 2, 4}
+		if len(ids) != len(expect) {
+			t.Fatalf("expected messages %v, but got %v", expect, ids)
+		}
+
+		for i, id := range ids {
+			if id != expect[i] {
+				t.Fatalf("expected messages %v, but got %v", expect, ids)
+			}
+		}
+	})
+
+	t.Run("reset after trigger", func(t *testing.T) {
+		st := newSpamTracker()
+
+		for id := discord.MessageID(1); id <= 3; id++ {
+			st.track(f, message(id, 1))
+		}
+
+		for id := discord.MessageID(4); id <= 5; id++ {
+			if ids := st.track(f, message(id, 1)); ids != nil {
+				t.Fatalf("expected message %d to be within the limit after the trigger, but got %v", id, ids)
+			}
+		}
+	})
+
+	t.Run("window expiry", func(t *testing.T) {
+		st := newSpamTracker()
+
+		for id := discord.MessageID(1); id <= 2; id++ {
+			st.track(f, message(id, 1))
+		}
+
+		// pretend the messages were sent before the interval
+		key := spamKey{channelID: 1, userID: 1}
+		for i := range st.messages[key] {
+			st.messages[key][i].time = st.messages[key][i].time.Add(-2 * f.Interval)
+		}
+
+		if ids := st.track(f, message(3, 1)); ids != nil {
+			t.Fatalf("expected expired messages to be forgotten, but got %v", ids)
+		}
+
+		if n := len(st.messages[key]); n != 1 {
+			t.Errorf("expected 1 tracked message, but got %d", n)
+		}
+	})
+}
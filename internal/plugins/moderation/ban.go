@@ -0,0 +1,231 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/discorderr"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// =============================================================================
+// Ban
+// =====================================================================================
+
+// Ban is the ban command.
+type Ban struct {
+	command.LocalizedMeta
+	expirer *Expirer
+	cases   *CaseLog
+}
+
+var _ plugin.Command = new(Ban) // compile-time check
+
+func newBan(e *Expirer, cl *CaseLog) *Ban {
+	return &Ban{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "ban",
+			ShortDescription: banShortDescription,
+			LongDescription:  banLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+				Flags:    []arg.LocalizedFlag{durationFlag, deleteDaysFlag},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionBanMembers,
+			Restrictions:   restriction.UserPermissions(discord.PermissionBanMembers),
+		},
+		expirer: e,
+		cases:   cl,
+	}
+}
+
+func (b *Ban) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+	reason := ctx.Args.String(1)
+	d := ctx.Flags.Duration("duration")
+
+	// users that aren't members can be banned as well, but if they are a
+	// member, the hierarchy must be respected
+	if err := checkUserHierarchy(s, ctx, target.ID); err != nil {
+		return nil, err
+	}
+
+	// the ban must not be lifted, while it is being replaced
+	defer b.expirer.claim(repository.PunishmentBan, ctx.GuildID, target.ID)()
+
+	err := ban(s, ctx.GuildID, target.ID, ctx.Flags.Int("delete"), auditLogReason(ctx.Author, reason))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := b.cases.recordInvoke(ctx, repository.CaseBan, target.ID, reason, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == 0 {
+		// a permanent ban replaces a temporary one
+		if err := b.expirer.Cancel(repository.PunishmentBan, ctx.GuildID, target.ID); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return banSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(*target), Case: c.Number}), nil
+	}
+
+	err = b.expirer.Schedule(repository.TempPunishment{
+		Type:    repository.PunishmentBan,
+		GuildID: ctx.GuildID,
+		UserID:  target.ID,
+		Expires: time.Now().Add(d),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return tempBanSuccess.WithPlaceholders(tempActionPlaceholders{
+		Target:   discordutil.UserTag(*target),
+		Duration: duration.Format(d),
+		Case:     c.Number,
+	}), nil
+}
+
+// checkUserHierarchy checks the hierarchy for the user with the passed id,
+// if they are a member of the guild.
+func checkUserHierarchy(s *state.State, ctx *plugin.Context, userID discord.UserID) error {
+	target, err := s.Member(ctx.GuildID, userID)
+	if discorderr.Is(discorderr.As(err), discorderr.UnknownMember) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return checkHierarchy(s, ctx, target)
+}
+
+// =============================================================================
+// Softban
+// =====================================================================================
+
+// Softban is the softban command.
+type Softban struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(Softban) // compile-time check
+
+func newSoftban(cl *CaseLog) *Softban {
+	deleteDays := deleteDaysFlag
+	deleteDays.Default = 1
+
+	return &Softban{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "softban",
+			ShortDescription: softbanShortDescription,
+			LongDescription:  softbanLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: memberArgName, Type: arg.Member}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+				Flags:    []arg.LocalizedFlag{deleteDays},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionBanMembers,
+			Restrictions:   restriction.UserPermissions(discord.PermissionBanMembers),
+		},
+		cases: cl,
+	}
+}
+
+func (sb *Softban) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.Member(0)
+	reason := ctx.Args.String(1)
+	auditReason := auditLogReason(ctx.Author, reason)
+
+	if err := checkHierarchy(s, ctx, target); err != nil {
+		return nil, err
+	}
+
+	if err := ban(s, ctx.GuildID, target.User.ID, ctx.Flags.Int("delete"), auditReason); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := unban(s, ctx.GuildID, target.User.ID, auditReason); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := sb.cases.recordInvoke(ctx, repository.CaseSoftban, target.User.ID, reason, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return softbanSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(target.User), Case: c.Number}), nil
+}
+
+// =============================================================================
+// Unban
+// =====================================================================================
+
+// Unban is the unban command.
+type Unban struct {
+	command.LocalizedMeta
+	expirer *Expirer
+	cases   *CaseLog
+}
+
+var _ plugin.Command = new(Unban) // compile-time check
+
+func newUnban(e *Expirer, cl *CaseLog) *Unban {
+	return &Unban{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "unban",
+			ShortDescription: unbanShortDescription,
+			LongDescription:  unbanLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionBanMembers,
+			Restrictions:   restriction.UserPermissions(discord.PermissionBanMembers),
+		},
+		expirer: e,
+		cases:   cl,
+	}
+}
+
+func (u *Unban) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+	reason := ctx.Args.String(1)
+
+	defer u.expirer.claim(repository.PunishmentBan, ctx.GuildID, target.ID)()
+
+	err := unban(s, ctx.GuildID, target.ID, auditLogReason(ctx.Author, reason))
+	if discorderr.Is(discorderr.As(err), discorderr.UnknownBan) {
+		return nil, errors.NewUserErrorl(notBannedError.
+			WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := u.expirer.Cancel(repository.PunishmentBan, ctx.GuildID, target.ID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := u.cases.recordInvoke(ctx, repository.CaseUnban, target.ID, reason, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return unbanSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(*target), Case: c.Number}), nil
+}
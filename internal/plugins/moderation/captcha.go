@@ -0,0 +1,195 @@
+package moderation
+
+import (
+	"bytes"
+	"crypto/rand"
+	"image"
+	"image/color"
+	"image/png"
+	"math/big"
+	mathrand "math/rand"
+	"time"
+)
+
+const (
+	// captchaLength is the number of characters of a captcha code.
+	captchaLength = 6
+	// captchaCharset are the characters used in captcha codes.
+	// Characters that are easily confused, such as 0 and O, are left out.
+	captchaCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// captchaScale is the size of a pixel of a glyph in the captcha image.
+	captchaScale = 6
+	// captchaCellWidth is the width of the space of a character in the
+	// captcha image.
+	captchaCellWidth = 7 * captchaScale
+	captchaPadding   = 4 * captchaScale
+	captchaWidth     = captchaLength*captchaCellWidth + 2*captchaPadding
+	captchaHeight    = 7*captchaScale + 2*captchaPadding
+)
+
+// captchaGlyphs are the 5x7 bitmaps of the characters in captchaCharset.
+var captchaGlyphs = map[byte][7]string{
+	'A': {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G': {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'J': {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M': {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N': {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'P': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V': {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X': {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+}
+
+// newCaptchaCode generates a random captcha code.
+func newCaptchaCode() (string, error) {
+	code := make([]byte, captchaLength)
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(captchaCharset))))
+		if err != nil {
+			return "", err
+		}
+
+		code[i] = captchaCharset[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// renderCaptcha renders the passed code as a PNG image.
+// Each character is sheared and offset randomly, and the image is covered in
+// noise and lines, so that the code is hard to read for machines.
+func renderCaptcha(code string) ([]byte, error) {
+	r := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+
+	img := image.NewRGBA(image.Rect(0, 0, captchaWidth, captchaHeight))
+
+	for x := 0; x < captchaWidth; x++ {
+		for y := 0; y < captchaHeight; y++ {
+			img.Set(x, y, randomColor(r, 200, 255))
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		drawGlyph(img, r, captchaGlyphs[code[i]], captchaPadding+i*captchaCellWidth)
+	}
+
+	for i := 0; i < 6; i++ {
+		drawLine(img, r.Intn(captchaWidth/3), r.Intn(captchaHeight),
+			captchaWidth-r.Intn(captchaWidth/3), r.Intn(captchaHeight), randomColor(r, 30, 150))
+	}
+
+	for i := 0; i < captchaWidth*captchaHeight/12; i++ {
+		img.Set(r.Intn(captchaWidth), r.Intn(captchaHeight), randomColor(r, 0, 255))
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// drawGlyph draws the passed glyph, starting at the passed x coordinate.
+func drawGlyph(img *image.RGBA, r *mathrand.Rand, glyph [7]string, x int) {
+	c := randomColor(r, 0, 120)
+
+	// shear is the horizontal offset per row, in pixels
+	shear := r.Intn(captchaScale+1) - captchaScale/2
+	y := captchaPadding + r.Intn(captchaPadding) - captchaPadding/2
+	x += r.Intn(captchaScale)
+
+	for row, line := range glyph {
+		offset := (row - len(glyph)/2) * shear
+
+		for col := 0; col < len(line); col++ {
+			if line[col] != '#' {
+				continue
+			}
+
+			px := x + col*captchaScale + offset
+			py := y + row*captchaScale
+
+			for dx := 0; dx < captchaScale; dx++ {
+				for dy := 0; dy < captchaScale; dy++ {
+					img.Set(px+dx, py+dy, c)
+				}
+			}
+		}
+	}
+}
+
+// drawLine draws a line two pixels wide from (x0, y0) to (x1, y1).
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+
+	if y0 > y1 {
+		sy = -1
+	}
+
+	for err := dx + dy; ; {
+		img.Set(x0, y0, c)
+		img.Set(x0, y0+1, c)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// randomColor returns a random opaque color, whose components are between
+// min and max.
+func randomColor(r *mathrand.Rand, min, max int) color.RGBA {
+	component := func() uint8 { return uint8(min + r.Intn(max-min+1)) }
+	return color.RGBA{R: component(), G: component(), B: component(), A: 0xff}
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
@@ -0,0 +1,247 @@
+package moderation
+
+import (
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// moderatorRestriction allows access to everyone who may use any of the
+// moderation actions.
+var moderatorRestriction = restriction.Any(
+	restriction.UserPermissions(discord.PermissionKickMembers),
+	restriction.UserPermissions(discord.PermissionBanMembers),
+	restriction.UserPermissions(discord.PermissionManageRoles),
+)
+
+var caseArg = arg.LocalizedRequiredArg{
+	Name:        caseArgName,
+	Type:        arg.IntegerWithMin(1),
+	Description: caseArgDescription,
+}
+
+// getCase returns the case with the passed number, or a user error, if
+// there is no such case.
+func getCase(repo *repository.Repository, guildID discord.GuildID, number int) (*repository.Case, error) {
+	c, err := repo.Case(guildID, number)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errors.NewUserErrorl(caseNotFoundError.
+			WithPlaceholders(caseNumberPlaceholders{Number: number}))
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
+
+// =============================================================================
+// Case
+// =====================================================================================
+
+// Case is the case command.
+type Case struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(Case) // compile-time check
+
+func newCase(cl *CaseLog) *Case {
+	return &Case{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "case",
+			ShortDescription: caseShortDescription,
+			LongDescription:  caseLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{caseArg},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionEmbedLinks,
+			Restrictions:   moderatorRestriction,
+		},
+		cases: cl,
+	}
+}
+
+func (c *Case) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	mc, err := getCase(c.cases.repo, ctx.GuildID, ctx.Args.Int(0))
+	if err != nil {
+		return nil, err
+	}
+
+	embed, err := caseEmbed(ctx.Localizer, *mc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return embed, nil
+}
+
+// =============================================================================
+// Reason
+// =====================================================================================
+
+// Reason is the reason command.
+type Reason struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(Reason) // compile-time check
+
+func newReason(cl *CaseLog) *Reason {
+	return &Reason{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "reason",
+			ShortDescription: reasonShortDescription,
+			LongDescription:  reasonLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{
+					caseArg,
+					{Name: newReasonArgName, Type: arg.SimpleText, Description: newReasonArgDescription},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages,
+			Restrictions:   moderatorRestriction,
+		},
+		cases: cl,
+	}
+}
+
+func (r *Reason) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	c, err := getCase(r.cases.repo, ctx.GuildID, ctx.Args.Int(0))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Reason = ctx.Args.String(1)
+	c.ReasonKey, c.ReasonPlaceholders = "", nil
+
+	if err := r.cases.Update(*c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return reasonSuccess.WithPlaceholders(caseNumberPlaceholders{Number: c.Number}), nil
+}
+
+// =============================================================================
+// History
+// =====================================================================================
+
+const (
+	// maxHistoryEntries is the maximum number of cases shown by the history
+	// command.
+	maxHistoryEntries = 15
+	// maxHistoryReasonLength is the maximum length of the reasons shown by
+	// the history command.
+	maxHistoryReasonLength = 100
+)
+
+// History is the history command.
+type History struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(History) // compile-time check
+
+func newHistory(cl *CaseLog) *History {
+	return &History{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "history",
+			Aliases:          []string{"cases"},
+			ShortDescription: historyShortDescription,
+			LongDescription:  historyLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionEmbedLinks,
+			Restrictions:   moderatorRestriction,
+		},
+		cases: cl,
+	}
+}
+
+func (h *History) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+
+	cs, err := h.cases.repo.UserCases(ctx.GuildID, target.ID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(cs) == 0 {
+		return historyEmpty.WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}), nil
+	}
+
+	title, err := ctx.Localize(historyTitle.WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	shown := cs
+	if len(shown) > maxHistoryEntries {
+		shown = shown[len(shown)-maxHistoryEntries:]
+	}
+
+	entries := make([]string, 0, len(shown))
+
+	// most recent first
+	for i := len(shown) - 1; i >= 0; i-- {
+		c := shown[i]
+
+		typeName, err := ctx.Localize(caseTypeNames[c.Type])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		reason, err := localizeReason(ctx.Localizer, c.Reason, c.ReasonKey, c.ReasonPlaceholders)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		reason = discordutil.Truncate(reason, maxHistoryReasonLength)
+		if len(reason) == 0 {
+			if reason, err = ctx.Localize(historyNoReason); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		entry, err := ctx.Localize(historyEntry.WithPlaceholders(historyEntryPlaceholders{
+			Number: c.Number,
+			Type:   typeName,
+			Date:   c.Time.UTC().Format("2006-01-02"),
+			Reason: reason,
+		}))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	footer, err := ctx.Localize(historyFooter.WithPlaceholders(historyFooterPlaceholders{
+		Shown: len(shown),
+		Total: len(cs),
+	}))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return discord.Embed{
+		Title:       title,
+		Description: strings.Join(entries, "\n"),
+		Footer:      &discord.EmbedFooter{Text: footer},
+	}, nil
+}
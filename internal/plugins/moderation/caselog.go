@@ -0,0 +1,249 @@
+package moderation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// LocalizerFunc returns the *i18n.Localizer used for the guild with the
+// passed id.
+type LocalizerFunc func(guildID discord.GuildID) *i18n.Localizer
+
+// CaseLog records moderation cases, and posts them to the mod-log channel of
+// their guild, as configured in config.C.Moderation.LogChannels.
+type CaseLog struct {
+	s         *state.State
+	repo      *repository.Repository
+	localizer LocalizerFunc
+}
+
+// NewCaseLog creates a new *CaseLog, that stores cases in the passed
+// repository.
+// The passed LocalizerFunc is used to localize the cases posted to the
+// mod-log channel.
+func NewCaseLog(s *state.State, repo *repository.Repository, localizer LocalizerFunc) *CaseLog {
+	return &CaseLog{s: s, repo: repo, localizer: localizer}
+}
+
+// The keys of the reasons of actions taken automatically.
+const (
+	reasonTempBanExpired      = "temp_ban_expired"
+	reasonTempMuteExpired     = "temp_mute_expired"
+	reasonEscalation          = "escalation"
+	reasonAutomod             = "automod"
+	reasonRaidMode            = "raid_mode"
+	reasonVerificationTimeout = "verification_timeout"
+	reasonVerificationFailed  = "verification_failed"
+)
+
+// autoReason is the reason of an action taken automatically.
+// Instead of an English reason, cases store its key and placeholders, so
+// that it is localized when displayed.
+type autoReason struct {
+	key          string
+	placeholders map[string]string
+}
+
+// String returns the English reason, which is used as audit log reason, and
+// stored as fallback.
+func (r autoReason) String() string {
+	s, err := i18n.NewFallbackLocalizer().Localize(reasonConfig(r.key, r.placeholders))
+	if err != nil {
+		return r.key
+	}
+
+	return s
+}
+
+// apply sets the reason of the passed case to r.
+func (r autoReason) apply(c *repository.Case) {
+	c.Reason = r.String()
+	c.ReasonKey = r.key
+	c.ReasonPlaceholders = r.placeholders
+}
+
+// reasonConfig returns the *i18n.Config of the reason with the passed key.
+func reasonConfig(key string, placeholders map[string]string) *i18n.Config {
+	p := make(map[string]interface{}, len(placeholders))
+	for k, v := range placeholders {
+		p[k] = v
+	}
+
+	return caseReasons[key].WithPlaceholders(p)
+}
+
+// localizeReason localizes the reason with the passed key, if it is one of
+// the reasons of actions taken automatically.
+// Otherwise, it returns the passed reason.
+func localizeReason(l *i18n.Localizer, reason, key string, placeholders map[string]string) (string, error) {
+	if _, ok := caseReasons[key]; !ok {
+		return reason, nil
+	}
+
+	return l.Localize(reasonConfig(key, placeholders))
+}
+
+func (l *CaseLog) log(c repository.Case) *zap.SugaredLogger {
+	return zap.S().Named("moderation").With("guild_id", c.GuildID, "case", c.Number)
+}
+
+// Record stores the passed case, assigning it the next case number, and
+// posts it to the mod-log channel.
+// If c.Time is zero, it is set to the current time.
+//
+// Failing to post the case is only logged, since the case is stored
+// nonetheless.
+func (l *CaseLog) Record(c *repository.Case) error {
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+
+	if err := l.repo.CreateCase(c); err != nil {
+		return err
+	}
+
+	channelID, ok := config.C.Moderation.LogChannels[c.GuildID]
+	if !ok {
+		return nil
+	}
+
+	embed, err := caseEmbed(l.localizer(c.GuildID), *c)
+	if err != nil {
+		return err
+	}
+
+	msg, err := l.s.SendEmbed(channelID, embed)
+	if err != nil {
+		l.log(*c).With("err", err, "channel_id", channelID).
+			Warn("unable to post case to mod-log channel")
+		return nil
+	}
+
+	c.LogChannelID = channelID
+	c.LogMessageID = msg.ID
+
+	return l.repo.UpdateCase(*c)
+}
+
+// recordInvoke records a case of the passed type for an action taken by the
+// invoking user against the user with the passed id.
+func (l *CaseLog) recordInvoke(
+	ctx *plugin.Context, t repository.CaseType, userID discord.UserID, reason string, d time.Duration,
+) (*repository.Case, error) {
+	c := &repository.Case{
+		GuildID:     ctx.GuildID,
+		Type:        t,
+		UserID:      userID,
+		ModeratorID: ctx.Author.ID,
+		Reason:      reason,
+		Duration:    d,
+	}
+
+	if err := l.Record(c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
+
+// Update stores the passed modified case, and edits its message in the
+// mod-log channel, if there is one.
+//
+// Failing to edit the message is only logged, since the case is updated
+// nonetheless.
+func (l *CaseLog) Update(c repository.Case) error {
+	if err := l.repo.UpdateCase(c); err != nil {
+		return err
+	}
+
+	if !c.LogMessageID.IsValid() {
+		return nil
+	}
+
+	embed, err := caseEmbed(l.localizer(c.GuildID), c)
+	if err != nil {
+		return err
+	}
+
+	if _, err = l.s.EditEmbed(c.LogChannelID, c.LogMessageID, embed); err != nil {
+		l.log(c).With("err", err, "channel_id", c.LogChannelID).
+			Warn("unable to edit case in mod-log channel")
+	}
+
+	return nil
+}
+
+// caseColors are the colors of the case embeds, by case type.
+var caseColors = map[repository.CaseType]discord.Color{
+	repository.CaseKick:       0xe67e22,
+	repository.CaseBan:        0xe74c3c,
+	repository.CaseSoftban:    0xe67e22,
+	repository.CaseUnban:      0x2ecc71,
+	repository.CaseMute:       0xf1c40f,
+	repository.CaseUnmute:     0x2ecc71,
+	repository.CaseWarn:       0xf1c40f,
+	repository.CaseQuarantine: 0xf1c40f,
+}
+
+// caseEmbed creates the embed for the passed case.
+func caseEmbed(l *i18n.Localizer, c repository.Case) (discord.Embed, error) {
+	var err error
+
+	localize := func(cfg *i18n.Config) string {
+		if err != nil {
+			return ""
+		}
+
+		var s string
+		s, err = l.Localize(cfg)
+
+		return s
+	}
+
+	e := discord.Embed{
+		Title: localize(caseTitle.WithPlaceholders(caseTitlePlaceholders{
+			Type:   localize(caseTypeNames[c.Type]),
+			Number: c.Number,
+		})),
+		Description: c.Reason,
+		Color:       caseColors[c.Type],
+		Timestamp:   discord.NewTimestamp(c.Time),
+		Fields: []discord.EmbedField{
+			{Name: localize(caseUserField), Value: userField(c.UserID), Inline: true},
+			{Name: localize(caseModeratorField), Value: userField(c.ModeratorID), Inline: true},
+		},
+	}
+
+	if _, ok := caseReasons[c.ReasonKey]; ok {
+		e.Description = localize(reasonConfig(c.ReasonKey, c.ReasonPlaceholders))
+	}
+
+	if len(e.Description) == 0 {
+		e.Description = localize(caseNoReason.WithPlaceholders(caseNumberPlaceholders{Number: c.Number}))
+	}
+
+	if c.Duration > 0 {
+		e.Fields = append(e.Fields, discord.EmbedField{
+			Name:   localize(caseDurationField),
+			Value:  duration.Format(c.Duration),
+			Inline: true,
+		})
+	}
+
+	return e, err
+}
+
+func userField(userID discord.UserID) string {
+	return fmt.Sprintf("%s (`%d`)", userID.Mention(), userID)
+}
@@ -0,0 +1,360 @@
+package moderation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/utils/discorderr"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// retryDelay is the time waited before retrying to lift a punishment, if
+// lifting failed.
+const retryDelay = time.Minute
+
+type (
+	// Expirer lifts temporary punishments once they expire.
+	//
+	// Since punishments are stored in the repository, they are also lifted
+	// if they expire while levin is offline, as soon as the Expirer is
+	// started.
+	//
+	// Additionally, the Expirer stores permanent mutes, so that the mute
+	// role of a muted member is known, even if they leave while they aren't
+	// cached.
+	Expirer struct {
+		s     *state.State
+		repo  *repository.Repository
+		cases *CaseLog
+
+		// mutex guards timers and claims, and ensures that punishments are
+		// stored and deleted in the same order as they are scheduled and
+		// lifted.
+		mutex  sync.Mutex
+		timers map[expiryKey]scheduledExpiry
+		// claims are the punishments currently being applied or lifted.
+		// The channel is closed once the claim is released.
+		claims map[expiryKey]chan struct{}
+	}
+
+	expiryKey struct {
+		t       repository.PunishmentType
+		guildID discord.GuildID
+		userID  discord.UserID
+	}
+
+	scheduledExpiry struct {
+		timer *time.Timer
+		// expires is the expiry of the scheduled punishment, used to detect
+		// if a punishment was rescheduled while it was being lifted.
+		expires time.Time
+	}
+)
+
+// NewExpirer creates a new *Expirer, that uses the passed *state.State to
+// lift the punishments stored in the passed repository.
+// Lifted punishments are recorded using the passed *CaseLog.
+func NewExpirer(s *state.State, repo *repository.Repository, cl *CaseLog) *Expirer {
+	return &Expirer{
+		s:      s,
+		repo:   repo,
+		cases:  cl,
+		timers: make(map[expiryKey]scheduledExpiry),
+		claims: make(map[expiryKey]chan struct{}),
+	}
+}
+
+// Start schedules all stored punishments.
+// Punishments that expired while levin was offline are lifted immediately.
+func (e *Expirer) Start() error {
+	ps, err := e.repo.TempPunishments()
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, p := range ps {
+		e.schedule(p)
+	}
+
+	return nil
+}
+
+// Schedule stores the passed punishment and lifts it once it expires.
+// If the user already has a punishment of the same type, it is replaced.
+func (e *Expirer) Schedule(p repository.TempPunishment) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.repo.PutTempPunishment(p); err != nil {
+		return err
+	}
+
+	// a temporary mute replaces a permanent one
+	if p.Type == repository.PunishmentMute {
+		if err := e.repo.DeletePermanentMute(p.GuildID, p.UserID); err != nil {
+			return err
+		}
+	}
+
+	e.schedule(p)
+	return nil
+}
+
+// Cancel stops lifting the punishment of the passed type of the user with
+// the passed id, and deletes it.
+// If t is repository.PunishmentMute, a permanent mute is deleted as well.
+// It is a no-op, if there is no such punishment.
+func (e *Expirer) Cancel(t repository.PunishmentType, guildID discord.GuildID, userID discord.UserID) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stop(expiryKey{t: t, guildID: guildID, userID: userID})
+
+	if err := e.repo.DeleteTempPunishment(t, guildID, userID); err != nil {
+		return err
+	}
+
+	if t == repository.PunishmentMute {
+		return e.repo.DeletePermanentMute(guildID, userID)
+	}
+
+	return nil
+}
+
+// expireAfter schedules the passed punishment to be lifted after d, or
+// cancels lifting it, if d is 0, and the punishment is therefore permanent.
+// Permanent mutes are stored as such.
+func (e *Expirer) expireAfter(p repository.TempPunishment, d time.Duration) error {
+	if d > 0 {
+		p.Expires = time.Now().Add(d)
+		return e.Schedule(p)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stop(expiryKey{t: p.Type, guildID: p.GuildID, userID: p.UserID})
+
+	if err := e.repo.DeleteTempPunishment(p.Type, p.GuildID, p.UserID); err != nil {
+		return err
+	}
+
+	if p.Type != repository.PunishmentMute {
+		return nil
+	}
+
+	return e.repo.PutPermanentMute(repository.PermanentMute{
+		GuildID: p.GuildID,
+		UserID:  p.UserID,
+		RoleID:  p.RoleID,
+	})
+}
+
+// claim claims the punishment of the passed type of the user with the passed
+// id, waiting until a previous claim is released, and returns the function
+// releasing it.
+//
+// Punishments must be claimed while they are applied or lifted, so that a
+// punishment can't be lifted, while a new one of the same type is being
+// applied, and vice versa.
+func (e *Expirer) claim(t repository.PunishmentType, guildID discord.GuildID, userID discord.UserID) func() {
+	key := expiryKey{t: t, guildID: guildID, userID: userID}
+
+	e.mutex.Lock()
+
+	for {
+		claimed, ok := e.claims[key]
+		if !ok {
+			break
+		}
+
+		e.mutex.Unlock()
+		<-claimed
+		e.mutex.Lock()
+	}
+
+	claimed := make(chan struct{})
+	e.claims[key] = claimed
+
+	e.mutex.Unlock()
+
+	return func() {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+
+		delete(e.claims, key)
+		close(claimed)
+	}
+}
+
+// schedule schedules the passed punishment to be lifted once it expires,
+// replacing the scheduled punishment of the same type of the user, if any.
+// The caller must hold the mutex.
+func (e *Expirer) schedule(p repository.TempPunishment) {
+	key := expiryKey{t: p.Type, guildID: p.GuildID, userID: p.UserID}
+
+	if se, ok := e.timers[key]; ok {
+		se.timer.Stop()
+	}
+
+	e.timers[key] = scheduledExpiry{
+		timer:   time.AfterFunc(time.Until(p.Expires), func() { e.lift(p) }),
+		expires: p.Expires,
+	}
+}
+
+// stop stops lifting the punishment with the passed key.
+// The caller must hold the mutex.
+func (e *Expirer) stop(key expiryKey) {
+	if se, ok := e.timers[key]; ok {
+		se.timer.Stop()
+		delete(e.timers, key)
+	}
+}
+
+// isScheduled checks if the passed punishment is the one currently
+// scheduled, i.e. if it wasn't cancelled or replaced.
+// The caller must hold the mutex.
+func (e *Expirer) isScheduled(p repository.TempPunishment) bool {
+	se, ok := e.timers[expiryKey{t: p.Type, guildID: p.GuildID, userID: p.UserID}]
+	return ok && se.expires.Equal(p.Expires)
+}
+
+// lift lifts the passed punishment.
+// If that fails, lifting is retried after retryDelay.
+func (e *Expirer) lift(p repository.TempPunishment) {
+	log := zap.S().Named("moderation").With(
+		"punishment_type", p.Type,
+		"guild_id", p.GuildID,
+		"user_id", p.UserID,
+	)
+
+	// hold a claim while lifting, so that the punishment can't be replaced
+	// while its role is being removed or the user is being unbanned
+	defer e.claim(p.Type, p.GuildID, p.UserID)()
+
+	e.mutex.Lock()
+	scheduled := e.isScheduled(p)
+	e.mutex.Unlock()
+
+	// the timer fired, but the punishment was replaced or cancelled in the
+	// meantime
+	if !scheduled {
+		return
+	}
+
+	var (
+		err      error
+		caseType repository.CaseType
+		reason   autoReason
+	)
+
+	switch p.Type {
+	case repository.PunishmentBan:
+		caseType, reason = repository.CaseUnban, autoReason{key: reasonTempBanExpired}
+		err = unban(e.s, p.GuildID, p.UserID, reason.String())
+	case repository.PunishmentMute:
+		caseType, reason = repository.CaseUnmute, autoReason{key: reasonTempMuteExpired}
+		err = removeRole(e.s, p.GuildID, p.UserID, p.RoleID, reason.String())
+	}
+
+	lifted := err == nil
+
+	// the punishment was already lifted manually or the user left
+	if discorderr.Is(discorderr.As(err), discorderr.UnknownBan, discorderr.UnknownMember) {
+		err = nil
+
+		// don't restore the mute role, if the user rejoins after the mute
+		// expired
+		if p.Type == repository.PunishmentMute {
+			err = e.repo.RemoveStoredRole(p.GuildID, p.UserID, p.RoleID)
+		}
+	}
+
+	if err != nil {
+		log.With("err", err).
+			Error("unable to lift punishment, retrying")
+		sentry.CaptureException(err)
+
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+
+		if e.isScheduled(p) {
+			p.Expires = time.Now().Add(retryDelay)
+			e.schedule(p)
+		}
+
+		return
+	}
+
+	if !e.finishLift(log, p) {
+		return
+	}
+
+	log.Info("lifted expired punishment")
+
+	if lifted {
+		e.recordLift(p, caseType, reason)
+	}
+}
+
+// finishLift stops lifting the passed punishment and deletes it, unless it
+// was replaced while it was being lifted, in which case the replacing
+// punishment stays scheduled.
+// It returns whether the punishment was deleted.
+func (e *Expirer) finishLift(log *zap.SugaredLogger, p repository.TempPunishment) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.isScheduled(p) {
+		log.Warn("punishment was replaced while it was being lifted")
+		return false
+	}
+
+	e.stop(expiryKey{t: p.Type, guildID: p.GuildID, userID: p.UserID})
+
+	if err := e.repo.DeleteTempPunishment(p.Type, p.GuildID, p.UserID); err != nil {
+		log.With("err", err).
+			Error("unable to delete lifted punishment")
+		sentry.CaptureException(err)
+
+		return false
+	}
+
+	return true
+}
+
+// recordLift records a case for the lifted punishment, with levin as the
+// moderator.
+func (e *Expirer) recordLift(p repository.TempPunishment, t repository.CaseType, reason autoReason) {
+	log := zap.S().Named("moderation").With("guild_id", p.GuildID, "user_id", p.UserID)
+
+	me, err := e.s.Me()
+	if err != nil {
+		log.With("err", err).
+			Error("unable to get self to record lifted punishment")
+		return
+	}
+
+	c := &repository.Case{
+		GuildID:     p.GuildID,
+		Type:        t,
+		UserID:      p.UserID,
+		ModeratorID: me.ID,
+	}
+	reason.apply(c)
+
+	if err := e.cases.Record(c); err != nil {
+		log.With("err", err).
+			Error("unable to record lifted punishment")
+		sentry.CaptureException(err)
+	}
+}
@@ -0,0 +1,151 @@
+package moderation
+
+import (
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// hierarchy holds the information needed to compare the positions of members
+// in a guild.
+type hierarchy struct {
+	ownerID discord.UserID
+	// positions are the positions of the roles of the guild.
+	positions map[discord.RoleID]int
+}
+
+func newHierarchy(s *state.State, guildID discord.GuildID) (*hierarchy, error) {
+	g, err := s.Guild(guildID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	roles, err := s.Roles(guildID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	h := &hierarchy{ownerID: g.OwnerID, positions: make(map[discord.RoleID]int, len(roles))}
+	for _, r := range roles {
+		h.positions[r.ID] = r.Position
+	}
+
+	return h, nil
+}
+
+// position returns the position of the highest role of the passed member.
+func (h *hierarchy) position(m discord.Member) (pos int) {
+	for _, id := range m.RoleIDs {
+		if p := h.positions[id]; p > pos {
+			pos = p
+		}
+	}
+
+	return pos
+}
+
+// outranks checks if a is allowed to take action on b.
+func (h *hierarchy) outranks(a, b discord.Member) bool {
+	switch {
+	case a.User.ID == h.ownerID:
+		return true
+	case b.User.ID == h.ownerID:
+		return false
+	default:
+		return h.position(a) > h.position(b)
+	}
+}
+
+// outranksRole checks if the passed member is allowed to manage the role with
+// the passed id.
+func (h *hierarchy) outranksRole(m discord.Member, roleID discord.RoleID) bool {
+	return m.User.ID == h.ownerID || h.position(m) > h.positions[roleID]
+}
+
+// checkHierarchy checks if both the invoking member and levin are allowed to
+// take action on the passed target.
+func checkHierarchy(s *state.State, ctx *plugin.Context, target *discord.Member) error {
+	self, err := ctx.Self()
+	if err != nil {
+		return err
+	}
+
+	switch target.User.ID {
+	case ctx.Author.ID:
+		return errors.NewUserErrorl(selfTargetError)
+	case self.User.ID:
+		return errors.NewUserErrorl(botTargetError)
+	}
+
+	h, err := newHierarchy(s, ctx.GuildID)
+	if err != nil {
+		return err
+	}
+
+	if !h.outranks(*ctx.Member, *target) {
+		return errors.NewUserErrorl(moderatorHierarchyError.
+			WithPlaceholders(targetPlaceholders{Target: target.Mention()}))
+	}
+
+	if !h.outranks(*self, *target) {
+		return errors.NewUserErrorl(botHierarchyError.
+			WithPlaceholders(targetPlaceholders{Target: target.Mention()}))
+	}
+
+	return nil
+}
+
+// muteRole returns the id of the mute role of the guild the command was
+// invoked in.
+// It also checks that levin is allowed to assign the role.
+func muteRole(s *state.State, ctx *plugin.Context) (discord.RoleID, error) {
+	self, err := ctx.Self()
+	if err != nil {
+		return 0, err
+	}
+
+	return guildMuteRole(s, ctx.GuildID, *self)
+}
+
+// guildMuteRole returns the id of the mute role of the guild with the passed
+// id.
+// It also checks that the passed member of levin is allowed to assign the
+// role.
+func guildMuteRole(s *state.State, guildID discord.GuildID, self discord.Member) (discord.RoleID, error) {
+	roleID, ok := config.C.Moderation.MuteRoles[guildID]
+	if !ok {
+		roles, err := s.Roles(guildID)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+
+		for _, r := range roles {
+			if strings.EqualFold(r.Name, config.C.Moderation.MuteRoleName) {
+				roleID = r.ID
+				break
+			}
+		}
+
+		if !roleID.IsValid() {
+			return 0, errors.NewUserErrorl(noMuteRoleError.
+				WithPlaceholders(muteRolePlaceholders{Name: config.C.Moderation.MuteRoleName}))
+		}
+	}
+
+	h, err := newHierarchy(s, guildID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !h.outranksRole(self, roleID) {
+		return 0, errors.NewUserErrorl(muteRoleHierarchyError.
+			WithPlaceholders(muteRolePlaceholders{Name: roleID.Mention()}))
+	}
+
+	return roleID, nil
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// Kick is the kick command.
+type Kick struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(Kick) // compile-time check
+
+func newKick(cl *CaseLog) *Kick {
+	return &Kick{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "kick",
+			ShortDescription: kickShortDescription,
+			LongDescription:  kickLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: memberArgName, Type: arg.Member}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionKickMembers,
+			Restrictions:   restriction.UserPermissions(discord.PermissionKickMembers),
+		},
+		cases: cl,
+	}
+}
+
+func (k *Kick) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.Member(0)
+	reason := ctx.Args.String(1)
+
+	if err := checkHierarchy(s, ctx, target); err != nil {
+		return nil, err
+	}
+
+	if err := kick(s, ctx.GuildID, target.User.ID, auditLogReason(ctx.Author, reason)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := k.cases.recordInvoke(ctx, repository.CaseKick, target.User.ID, reason, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return kickSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(target.User), Case: c.Number}), nil
+}
@@ -0,0 +1,567 @@
+package moderation
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// maxSlowmode is the maximum slowmode Discord allows.
+const maxSlowmode = 6 * time.Hour
+
+// =============================================================================
+// Locking
+// =====================================================================================
+
+// lockable checks if the passed channel can be locked.
+func lockable(c discord.Channel) bool {
+	return c.Type == discord.GuildText || c.Type == discord.GuildNews
+}
+
+// findOverwrite returns the permission overwrite with the passed id.
+func findOverwrite(c discord.Channel, id discord.Snowflake) (discord.Overwrite, bool) {
+	for _, o := range c.Permissions {
+		if o.ID == id {
+			return o, true
+		}
+	}
+
+	return discord.Overwrite{}, false
+}
+
+// lockChannel locks the passed channel, by denying @everyone to send
+// messages.
+// To still be able to respond in the channel, levin allows itself to send
+// messages.
+// The previous overwrites are stored, so that unlockChannel can restore them.
+//
+// If the channel is already locked, lockChannel returns false.
+func lockChannel(
+	s *state.State, repo *repository.Repository, c discord.Channel, selfID, moderatorID discord.UserID,
+	lockdown bool, reason string,
+) (bool, error) {
+	_, err := repo.Lock(c.GuildID, c.ID)
+	if err == nil {
+		return false, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return false, err
+	}
+
+	l := repository.Lock{
+		GuildID:     c.GuildID,
+		ChannelID:   c.ID,
+		Lockdown:    lockdown,
+		ModeratorID: moderatorID,
+		Time:        time.Now(),
+	}
+
+	var changes []discord.Overwrite
+
+	change := func(o discord.Overwrite, existed bool) {
+		if existed {
+			l.Previous = append(l.Previous, o)
+		} else {
+			l.Added = append(l.Added, o.ID)
+		}
+	}
+
+	// the id of the @everyone role is the guild's id
+	everyone, ok := findOverwrite(c, discord.Snowflake(c.GuildID))
+	if !ok {
+		everyone = discord.Overwrite{ID: discord.Snowflake(c.GuildID), Type: discord.OverwriteRole}
+	}
+
+	change(everyone, ok)
+	changes = append(changes, discord.Overwrite{
+		ID:    everyone.ID,
+		Type:  discord.OverwriteRole,
+		Allow: everyone.Allow &^ discord.PermissionSendMessages,
+		Deny:  everyone.Deny | discord.PermissionSendMessages,
+	})
+
+	self, ok := findOverwrite(c, discord.Snowflake(selfID))
+	if !ok {
+		self = discord.Overwrite{ID: discord.Snowflake(selfID), Type: discord.OverwriteMember}
+	}
+
+	if !self.Allow.Has(discord.PermissionSendMessages) {
+		change(self, ok)
+		changes = append(changes, discord.Overwrite{
+			ID:    self.ID,
+			Type:  discord.OverwriteMember,
+			Allow: self.Allow | discord.PermissionSendMessages,
+			Deny:  self.Deny &^ discord.PermissionSendMessages,
+		})
+	}
+
+	// store the lock first, so that a partially applied lock can still be
+	// lifted
+	if err := repo.PutLock(l); err != nil {
+		return false, err
+	}
+
+	for _, o := range changes {
+		if err := editOverwrite(s, c.ID, o, reason); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// unlockChannel lifts the passed lock, by restoring the overwrites that were
+// changed to lock the channel.
+func unlockChannel(s *state.State, repo *repository.Repository, l repository.Lock, reason string) error {
+	for _, o := range l.Previous {
+		if err := editOverwrite(s, l.ChannelID, o, reason); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range l.Added {
+		if err := deleteOverwrite(s, l.ChannelID, id, reason); err != nil {
+			return err
+		}
+	}
+
+	return repo.DeleteLock(l.GuildID, l.ChannelID)
+}
+
+// lockTargets returns the channels affected by locking the passed channel.
+// If the channel is a category, lockTargets returns the lockable channels in
+// it.
+func lockTargets(s *state.State, c discord.Channel) ([]discord.Channel, error) {
+	if c.Type != discord.GuildCategory {
+		return []discord.Channel{c}, nil
+	}
+
+	channels, err := s.Channels(c.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []discord.Channel
+
+	for _, child := range channels {
+		if child.CategoryID == c.ID && lockable(child) {
+			targets = append(targets, child)
+		}
+	}
+
+	return targets, nil
+}
+
+// lockTarget returns the channel passed to the lock or unlock command, or,
+// if none was passed, the channel the command was invoked in.
+func lockTarget(ctx *plugin.Context) (*discord.Channel, error) {
+	if c := ctx.Args.Channel(0); c != nil {
+		return c, nil
+	}
+
+	return ctx.Channel()
+}
+
+// lockChannelType is the arg.Type of a text channel, news channel, or
+// category.
+//
+// Go type: *discord.Channel
+type lockChannelType struct{}
+
+var _ arg.Type = lockChannelType{}
+
+func (lockChannelType) Name(l *i18n.Localizer) string {
+	name, _ := l.Localize(lockChannelTypeName) // we have a fallback
+	return name
+}
+
+func (lockChannelType) Description(l *i18n.Localizer) string {
+	desc, _ := l.Localize(lockChannelTypeDescription) // we have a fallback
+	return desc
+}
+
+var channelMentionRegexp = regexp.MustCompile(`^<#(\d+)>$`)
+
+func (lockChannelType) Parse(s *state.State, ctx *arg.Context) (interface{}, error) {
+	raw := ctx.Raw
+	if matches := channelMentionRegexp.FindStringSubmatch(raw); len(matches) == 2 {
+		raw = matches[1]
+	}
+
+	invalid := plugin.NewArgumentErrorl(invalidLockChannelError.
+		WithPlaceholders(invalidLockChannelPlaceholders{Raw: ctx.Raw}))
+
+	id, err := discord.ParseSnowflake(raw)
+	if err != nil {
+		return nil, invalid
+	}
+
+	c, err := s.Channel(discord.ChannelID(id))
+	if err != nil || c.GuildID != ctx.GuildID || (!lockable(*c) && c.Type != discord.GuildCategory) {
+		return nil, invalid
+	}
+
+	return c, nil
+}
+
+func (lockChannelType) Default() interface{} {
+	return (*discord.Channel)(nil)
+}
+
+// =============================================================================
+// Lock
+// =====================================================================================
+
+// Lock is the lock command.
+type Lock struct {
+	command.LocalizedMeta
+	repo *repository.Repository
+}
+
+var _ plugin.Command = new(Lock) // compile-time check
+
+func newLock(repo *repository.Repository) *Lock {
+	return &Lock{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "lock",
+			ShortDescription: lockShortDescription,
+			LongDescription:  lockLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Optional: []arg.LocalizedOptionalArg{
+					{Name: lockChannelArgName, Type: lockChannelType{}, Description: lockChannelArgDescription},
+					reasonArg,
+				},
+			},
+			ChannelTypes: plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageChannels |
+				discord.PermissionManageRoles,
+			Restrictions: restriction.UserPermissions(discord.PermissionManageChannels),
+		},
+		repo: repo,
+	}
+}
+
+func (l *Lock) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target, err := lockTarget(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	channels, err := lockTargets(s, *target)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	self, err := ctx.Self()
+	if err != nil {
+		return nil, err
+	}
+
+	reason := auditLogReason(ctx.Author, ctx.Args.String(1))
+
+	var locked int
+
+	for _, c := range channels {
+		ok, err := lockChannel(s, l.repo, c, self.User.ID, ctx.Author.ID, false, reason)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		} else if ok {
+			locked++
+		}
+	}
+
+	if locked == 0 {
+		return nil, errors.NewUserErrorl(alreadyLockedError.
+			WithPlaceholders(channelPlaceholders{Channel: target.Mention()}))
+	}
+
+	if target.Type == discord.GuildCategory {
+		return lockCategorySuccess.WithPlaceholders(lockCategoryPlaceholders{
+			Category: target.Name,
+			Count:    locked,
+		}), nil
+	}
+
+	return lockSuccess.WithPlaceholders(channelPlaceholders{Channel: target.Mention()}), nil
+}
+
+// =============================================================================
+// Unlock
+// =====================================================================================
+
+// Unlock is the unlock command.
+type Unlock struct {
+	command.LocalizedMeta
+	repo *repository.Repository
+}
+
+var _ plugin.Command = new(Unlock) // compile-time check
+
+func newUnlock(repo *repository.Repository) *Unlock {
+	return &Unlock{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "unlock",
+			ShortDescription: unlockShortDescription,
+			LongDescription:  unlockLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Optional: []arg.LocalizedOptionalArg{
+					{Name: lockChannelArgName, Type: lockChannelType{}, Description: lockChannelArgDescription},
+					reasonArg,
+				},
+			},
+			ChannelTypes: plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageChannels |
+				discord.PermissionManageRoles,
+			Restrictions: restriction.UserPermissions(discord.PermissionManageChannels),
+		},
+		repo: repo,
+	}
+}
+
+func (u *Unlock) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target, err := lockTarget(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	channels, err := lockTargets(s, *target)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	reason := auditLogReason(ctx.Author, ctx.Args.String(1))
+
+	var unlocked int
+
+	for _, c := range channels {
+		l, err := u.repo.Lock(c.GuildID, c.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := unlockChannel(s, u.repo, l, reason); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		unlocked++
+	}
+
+	if unlocked == 0 {
+		return nil, errors.NewUserErrorl(notLockedError.
+			WithPlaceholders(channelPlaceholders{Channel: target.Mention()}))
+	}
+
+	if target.Type == discord.GuildCategory {
+		return unlockCategorySuccess.WithPlaceholders(lockCategoryPlaceholders{
+			Category: target.Name,
+			Count:    unlocked,
+		}), nil
+	}
+
+	return unlockSuccess.WithPlaceholders(channelPlaceholders{Channel: target.Mention()}), nil
+}
+
+// =============================================================================
+// Lockdown
+// =====================================================================================
+
+// Lockdown is the lockdown command.
+type Lockdown struct {
+	command.LocalizedMeta
+	repo *repository.Repository
+}
+
+var _ plugin.Command = new(Lockdown) // compile-time check
+
+func newLockdown(repo *repository.Repository) *Lockdown {
+	return &Lockdown{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "lockdown",
+			ShortDescription: lockdownShortDescription,
+			LongDescription:  lockdownLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Optional: []arg.LocalizedOptionalArg{
+					{
+						Name:        lockdownStateArgName,
+						Type:        arg.Choice{{Name: "on"}, {Name: "off"}},
+						Description: lockdownStateArgDescription,
+					},
+				},
+			},
+			ChannelTypes: plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageChannels |
+				discord.PermissionManageRoles,
+			Restrictions: restriction.UserPermissions(discord.PermissionManageGuild),
+		},
+		repo: repo,
+	}
+}
+
+func (l *Lockdown) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	locks, err := l.repo.Locks(ctx.GuildID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var lockdown []repository.Lock
+
+	for _, lock := range locks {
+		if lock.Lockdown {
+			lockdown = append(lockdown, lock)
+		}
+	}
+
+	switch ctx.Args.String(0) {
+	case "on":
+		if len(lockdown) > 0 {
+			return nil, errors.NewUserErrorl(lockdownAlreadyEnabledError)
+		}
+
+		n, err := l.lockdown(s, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return lockdownEnabled.WithPlaceholders(lockdownPlaceholders{Count: n}), nil
+	case "off":
+		if len(lockdown) == 0 {
+			return nil, errors.NewUserErrorl(lockdownNotEnabledError)
+		}
+
+		reason := auditLogReason(ctx.Author, "Lockdown lifted")
+
+		for _, lock := range lockdown {
+			if err := unlockChannel(s, l.repo, lock, reason); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		return lockdownDisabled.WithPlaceholders(lockdownPlaceholders{Count: len(lockdown)}), nil
+	default:
+		if len(lockdown) == 0 {
+			return lockdownStatusDisabled, nil
+		}
+
+		return lockdownStatusEnabled.WithPlaceholders(lockdownPlaceholders{Count: len(lockdown)}), nil
+	}
+}
+
+// lockdown locks all channels, in which @everyone can send messages, and
+// returns the number of channels locked.
+// Channels that are already locked are left untouched, so that lifting the
+// lockdown doesn't unlock them.
+func (l *Lockdown) lockdown(s *state.State, ctx *plugin.Context) (int, error) {
+	channels, err := s.Channels(ctx.GuildID)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	everyone, err := s.Role(ctx.GuildID, discord.RoleID(ctx.GuildID))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	self, err := ctx.Self()
+	if err != nil {
+		return 0, err
+	}
+
+	reason := auditLogReason(ctx.Author, "Lockdown")
+
+	var n int
+
+	for _, c := range channels {
+		if !lockable(c) || !everyoneCanSend(c, everyone.Permissions) {
+			continue
+		}
+
+		ok, err := lockChannel(s, l.repo, c, self.User.ID, ctx.Author.ID, true, reason)
+		if err != nil {
+			return n, errors.WithStack(err)
+		} else if ok {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// everyoneCanSend checks if @everyone can send messages in the passed
+// channel, if it has the passed guild-wide permissions.
+func everyoneCanSend(c discord.Channel, perms discord.Permissions) bool {
+	if o, ok := findOverwrite(c, discord.Snowflake(c.GuildID)); ok {
+		perms = perms&^o.Deny | o.Allow
+	}
+
+	return perms.Has(discord.PermissionSendMessages)
+}
+
+// =============================================================================
+// Slowmode
+// =====================================================================================
+
+// Slowmode is the slowmode command.
+type Slowmode struct {
+	command.LocalizedMeta
+}
+
+var _ plugin.Command = new(Slowmode) // compile-time check
+
+func newSlowmode() *Slowmode {
+	return &Slowmode{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "slowmode",
+			ShortDescription: slowmodeShortDescription,
+			LongDescription:  slowmodeLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{
+					{
+						Name:        slowmodeDurationArgName,
+						Type:        arg.Duration{Max: maxSlowmode},
+						Description: slowmodeDurationArgDescription,
+					},
+				},
+				Optional: []arg.LocalizedOptionalArg{
+					{Name: slowmodeChannelArgName, Type: arg.TextChannel},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageChannels,
+			Restrictions:   restriction.UserPermissions(discord.PermissionManageChannels),
+		},
+	}
+}
+
+func (sm *Slowmode) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	d := ctx.Args.Duration(0).Truncate(time.Second)
+
+	channelID := ctx.ChannelID
+	if c := ctx.Args.Channel(1); c != nil {
+		channelID = c.ID
+	}
+
+	if err := setSlowmode(s, channelID, d, auditLogReason(ctx.Author, "")); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if d == 0 {
+		return slowmodeDisabled.WithPlaceholders(channelPlaceholders{Channel: channelID.Mention()}), nil
+	}
+
+	return slowmodeSuccess.WithPlaceholders(slowmodePlaceholders{
+		Channel:  channelID.Mention(),
+		Duration: duration.Format(d),
+	}), nil
+}
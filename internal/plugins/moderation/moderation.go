@@ -0,0 +1,67 @@
+// Package moderation provides the moderation module.
+package moderation
+
+import (
+	"time"
+
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/module"
+	"github.com/mavolin/adam/pkg/plugin"
+)
+
+// New creates the moderation module.
+// The passed *Expirer is used to lift temporary punishments, the passed
+// *CaseLog to record the actions taken, and the passed *RaidGuard to manage
+// raid mode.
+func New(e *Expirer, cl *CaseLog, rg *RaidGuard) plugin.Module {
+	m := module.New(module.LocalizedMeta{
+		Name:             "mod",
+		ShortDescription: shortDescription,
+		LongDescription:  longDescription,
+	})
+
+	m.AddCommand(newKick(cl))
+	m.AddCommand(newBan(e, cl))
+	m.AddCommand(newSoftban(cl))
+	m.AddCommand(newUnban(e, cl))
+	m.AddCommand(newMute(e, cl))
+	m.AddCommand(newUnmute(e, cl))
+	m.AddCommand(newWarn(e, cl))
+	m.AddCommand(newWarnings(cl))
+	m.AddCommand(newClearWarn(cl.repo))
+
+	m.AddCommand(newRaidMode(rg))
+	m.AddCommand(newPurge())
+	m.AddCommand(newLock(cl.repo))
+	m.AddCommand(newUnlock(cl.repo))
+	m.AddCommand(newLockdown(cl.repo))
+	m.AddCommand(newSlowmode())
+	m.AddCommand(newStoredRoles(cl.repo))
+
+	m.AddCommand(newCase(cl))
+	m.AddCommand(newReason(cl))
+	m.AddCommand(newHistory(cl))
+
+	return m
+}
+
+var (
+	reasonArg = arg.LocalizedOptionalArg{
+		Name:        reasonArgName,
+		Type:        arg.SimpleText,
+		Description: reasonArgDescription,
+	}
+
+	durationFlag = arg.LocalizedFlag{
+		Name:        "duration",
+		Aliases:     []string{"d"},
+		Type:        arg.Duration{Min: time.Minute},
+		Description: durationFlagDescription,
+	}
+
+	deleteDaysFlag = arg.LocalizedFlag{
+		Name:        "delete",
+		Type:        arg.IntegerWithBounds(0, 7),
+		Description: deleteDaysFlagDescription,
+	}
+)
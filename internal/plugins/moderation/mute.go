@@ -0,0 +1,173 @@
+package moderation
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// =============================================================================
+// Mute
+// =====================================================================================
+
+// Mute is the mute command.
+type Mute struct {
+	command.LocalizedMeta
+	expirer *Expirer
+	cases   *CaseLog
+}
+
+var _ plugin.Command = new(Mute) // compile-time check
+
+func newMute(e *Expirer, cl *CaseLog) *Mute {
+	return &Mute{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "mute",
+			Aliases:          []string{"timeout"},
+			ShortDescription: muteShortDescription,
+			LongDescription:  muteLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: memberArgName, Type: arg.Member}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+				Flags:    []arg.LocalizedFlag{durationFlag},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageRoles,
+			Restrictions:   restriction.UserPermissions(discord.PermissionManageRoles),
+		},
+		expirer: e,
+		cases:   cl,
+	}
+}
+
+func (m *Mute) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.Member(0)
+	reason := ctx.Args.String(1)
+	d := ctx.Flags.Duration("duration")
+
+	if err := checkHierarchy(s, ctx, target); err != nil {
+		return nil, err
+	}
+
+	roleID, err := muteRole(s, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the mute must not be lifted, while it is being replaced
+	defer m.expirer.claim(repository.PunishmentMute, ctx.GuildID, target.User.ID)()
+
+	err = addRole(s, ctx.GuildID, target.User.ID, roleID, auditLogReason(ctx.Author, reason))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := m.cases.recordInvoke(ctx, repository.CaseMute, target.User.ID, reason, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// a permanent mute replaces a temporary one, and vice versa
+	err = m.expirer.expireAfter(repository.TempPunishment{
+		Type:    repository.PunishmentMute,
+		GuildID: ctx.GuildID,
+		UserID:  target.User.ID,
+		RoleID:  roleID,
+	}, d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if d == 0 {
+		return muteSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(target.User), Case: c.Number}), nil
+	}
+
+	return tempMuteSuccess.WithPlaceholders(tempActionPlaceholders{
+		Target:   discordutil.UserTag(target.User),
+		Duration: duration.Format(d),
+		Case:     c.Number,
+	}), nil
+}
+
+// =============================================================================
+// Unmute
+// =====================================================================================
+
+// Unmute is the unmute command.
+type Unmute struct {
+	command.LocalizedMeta
+	expirer *Expirer
+	cases   *CaseLog
+}
+
+var _ plugin.Command = new(Unmute) // compile-time check
+
+func newUnmute(e *Expirer, cl *CaseLog) *Unmute {
+	return &Unmute{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "unmute",
+			ShortDescription: unmuteShortDescription,
+			LongDescription:  unmuteLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: memberArgName, Type: arg.Member}},
+				Optional: []arg.LocalizedOptionalArg{reasonArg},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageRoles,
+			Restrictions:   restriction.UserPermissions(discord.PermissionManageRoles),
+		},
+		expirer: e,
+		cases:   cl,
+	}
+}
+
+func (u *Unmute) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.Member(0)
+	reason := ctx.Args.String(1)
+
+	roleID, err := muteRole(s, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasRole(*target, roleID) {
+		return nil, errors.NewUserErrorl(notMutedError.
+			WithPlaceholders(targetPlaceholders{Target: target.Mention()}))
+	}
+
+	defer u.expirer.claim(repository.PunishmentMute, ctx.GuildID, target.User.ID)()
+
+	err = removeRole(s, ctx.GuildID, target.User.ID, roleID, auditLogReason(ctx.Author, reason))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := u.expirer.Cancel(repository.PunishmentMute, ctx.GuildID, target.User.ID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c, err := u.cases.recordInvoke(ctx, repository.CaseUnmute, target.User.ID, reason, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmuteSuccess.WithPlaceholders(actionPlaceholders{Target: discordutil.UserTag(target.User), Case: c.Number}), nil
+}
+
+func hasRole(m discord.Member, roleID discord.RoleID) bool {
+	for _, id := range m.RoleIDs {
+		if id == roleID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// The actions that can be taken automatically, e.g. by escalations.
+const (
+	actionMute = "mute"
+	actionKick = "kick"
+	actionBan  = "ban"
+)
+
+// punish takes the passed action, either mute, kick, or ban, against the
+// user with the passed id, and records it as a case with levin as the
+// moderator.
+// If d is not 0, mutes and bans are lifted after d.
+//
+// If the action is unknown, punish is a no-op and returns a nil case.
+func punish(
+	s *state.State, e *Expirer, cl *CaseLog, self discord.Member, userID discord.UserID, guildID discord.GuildID,
+	action string, d time.Duration, r autoReason,
+) (*repository.Case, error) {
+	c := &repository.Case{
+		GuildID:     guildID,
+		UserID:      userID,
+		ModeratorID: self.User.ID,
+		Duration:    d,
+	}
+	r.apply(c)
+
+	reason := c.Reason
+
+	switch strings.ToLower(action) {
+	case actionMute:
+		c.Type = repository.CaseMute
+
+		roleID, err := guildMuteRole(s, guildID, self)
+		if err != nil {
+			return nil, err
+		}
+
+		defer e.claim(repository.PunishmentMute, guildID, userID)()
+
+		if err := addRole(s, guildID, userID, roleID, reason); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		err = e.expireAfter(repository.TempPunishment{
+			Type:    repository.PunishmentMute,
+			GuildID: guildID,
+			UserID:  userID,
+			RoleID:  roleID,
+		}, d)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	case actionKick:
+		c.Type, c.Duration = repository.CaseKick, 0
+
+		if err := kick(s, guildID, userID, reason); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	case actionBan:
+		c.Type = repository.CaseBan
+
+		defer e.claim(repository.PunishmentBan, guildID, userID)()
+
+		if err := ban(s, guildID, userID, 0, reason); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		err := e.expireAfter(repository.TempPunishment{
+			Type:    repository.PunishmentBan,
+			GuildID: guildID,
+			UserID:  userID,
+		}, d)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	default:
+		zap.S().Named("moderation").
+			With("guild_id", guildID, "action", action).
+			Warn("unknown action")
+		return nil, nil
+	}
+
+	if err := cl.Record(c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,382 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/sendpart"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+const (
+	// maxPurge is the maximum number of messages deleted by a single purge.
+	maxPurge = 1000
+	// maxPurgeScan is the maximum number of messages scanned for messages
+	// matching the filters of a purge.
+	maxPurgeScan = 5000
+	// purgeFetchLimit is the number of messages fetched per request.
+	purgeFetchLimit = 100
+	// purgeProgressThreshold is the number of messages above which the
+	// progress of a purge is shown.
+	purgeProgressThreshold = 100
+	// bulkDeleteAge is the maximum age of messages that can be bulk deleted.
+	// It is a minute shorter than Discord's limit of 14 days, so that
+	// messages don't become too old while purging.
+	bulkDeleteAge = 14*24*time.Hour - time.Minute
+)
+
+// purgeFilter is a filter a message must pass to be purged.
+type purgeFilter func(m discord.Message) bool
+
+// Purge is the purge command.
+type Purge struct {
+	command.LocalizedMeta
+}
+
+var _ plugin.Command = new(Purge) // compile-time check
+
+func newPurge() *Purge {
+	return &Purge{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "purge",
+			Aliases:          []string{"clear"},
+			ShortDescription: purgeShortDescription,
+			LongDescription:  purgeLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{
+					{
+						Name:        purgeCountArgName,
+						Type:        arg.IntegerWithBounds(1, maxPurge),
+						Description: purgeCountArgDescription,
+					},
+				},
+				Flags: []arg.LocalizedFlag{
+					{Name: "user", Aliases: []string{"u"}, Type: arg.User, Description: purgeUserFlagDescription},
+					{Name: "bots", Type: arg.Switch, Description: purgeBotsFlagDescription},
+					{
+						Name:        "contains",
+						Aliases:     []string{"c"},
+						Type:        arg.SimpleText,
+						Description: purgeContainsFlagDescription,
+					},
+					{
+						Name:        "regexp",
+						Aliases:     []string{"r"},
+						Type:        arg.RegularExpression,
+						Description: purgeRegexpFlagDescription,
+					},
+					{Name: "attachments", Type: arg.Switch, Description: purgeAttachmentsFlagDescription},
+					{Name: "links", Type: arg.Switch, Description: purgeLinksFlagDescription},
+					{Name: "before", Type: arg.SimpleNumericID, Description: purgeBeforeFlagDescription},
+					{Name: "after", Type: arg.SimpleNumericID, Description: purgeAfterFlagDescription},
+				},
+			},
+			ChannelTypes: plugin.GuildTextChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionManageMessages |
+				discord.PermissionReadMessageHistory | discord.PermissionAttachFiles,
+			Restrictions: restriction.UserPermissions(discord.PermissionManageMessages),
+		},
+	}
+}
+
+func (p *Purge) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	count := ctx.Args.Int(0)
+	filters := purgeFilters(ctx.Flags)
+
+	before := discord.MessageID(ctx.Flags.Uint64("before"))
+	if !before.IsValid() {
+		before = ctx.Message.ID
+	}
+
+	after := discord.MessageID(ctx.Flags.Uint64("after"))
+
+	msgs, err := findPurgeable(s, ctx.ChannelID, before, after, count, filters)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(msgs) == 0 {
+		return nil, errors.NewUserErrorl(noPurgeableMessagesError)
+	}
+
+	pr := &purgeProgress{ctx: ctx, total: len(msgs)}
+
+	deleted, err := deletePurgeable(s, ctx.ChannelID, msgs, pr)
+	// log what was deleted, even if the purge didn't finish
+	logPurge(s, ctx, deleted)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the invoke isn't purged itself, so that it is visible who purged, but
+	// it may be deleted now that the purge is done
+	_ = s.DeleteMessage(ctx.ChannelID, ctx.Message.ID)
+
+	success := purgeSuccess.WithPlaceholders(purgePlaceholders{Count: len(deleted)})
+
+	if pr.messageID.IsValid() {
+		_, err = ctx.Editl(pr.messageID, success)
+		return nil, err
+	}
+
+	return success, nil
+}
+
+// purgeFilters returns the filters set through the passed flags.
+func purgeFilters(f plugin.Flags) []purgeFilter {
+	var filters []purgeFilter
+
+	if u := f.User("user"); u != nil {
+		filters = append(filters, func(m discord.Message) bool { return m.Author.ID == u.ID })
+	}
+
+	if f.Bool("bots") {
+		filters = append(filters, func(m discord.Message) bool { return m.Author.Bot })
+	}
+
+	if text := strings.ToLower(f.String("contains")); len(text) > 0 {
+		filters = append(filters, func(m discord.Message) bool {
+			return strings.Contains(strings.ToLower(m.Content), text)
+		})
+	}
+
+	if re := f.Regexp("regexp"); re != nil {
+		filters = append(filters, func(m discord.Message) bool { return re.MatchString(m.Content) })
+	}
+
+	if f.Bool("attachments") {
+		filters = append(filters, func(m discord.Message) bool { return len(m.Attachments) > 0 })
+	}
+
+	if f.Bool("links") {
+		filters = append(filters, func(m discord.Message) bool { return linkRegexp.MatchString(m.Content) })
+	}
+
+	return filters
+}
+
+// findPurgeable returns up to count messages sent between after and before,
+// that pass all passed filters, starting with the most recent.
+// Pinned messages are never purged.
+func findPurgeable(
+	s *state.State, channelID discord.ChannelID, before, after discord.MessageID, count int,
+	filters []purgeFilter,
+) ([]discord.Message, error) {
+	var purgeable []discord.Message
+
+	for scanned := 0; scanned < maxPurgeScan && len(purgeable) < count; {
+		msgs, err := s.MessagesBefore(channelID, before, purgeFetchLimit)
+		if err != nil {
+			return nil, err
+		}
+
+	Messages:
+		for _, m := range msgs {
+			if m.ID <= after {
+				return purgeable, nil
+			}
+
+			if m.Pinned {
+				continue
+			}
+
+			for _, f := range filters {
+				if !f(m) {
+					continue Messages
+				}
+			}
+
+			purgeable = append(purgeable, m)
+			if len(purgeable) == count {
+				return purgeable, nil
+			}
+		}
+
+		if len(msgs) < purgeFetchLimit {
+			break
+		}
+
+		scanned += len(msgs)
+		before = msgs[len(msgs)-1].ID
+	}
+
+	return purgeable, nil
+}
+
+// deletePurgeable deletes the passed messages, using bulk deletes for those
+// younger than 14 days, and single deletes for all others.
+// It returns the messages that were deleted.
+func deletePurgeable(
+	s *state.State, channelID discord.ChannelID, msgs []discord.Message, pr *purgeProgress,
+) ([]discord.Message, error) {
+	deleted := make([]discord.Message, 0, len(msgs))
+
+	var young, old []discord.Message
+
+	for _, m := range msgs {
+		if time.Since(m.ID.Time()) < bulkDeleteAge {
+			young = append(young, m)
+		} else {
+			old = append(old, m)
+		}
+	}
+
+	for len(young) > 0 {
+		n := len(young)
+		if n > purgeFetchLimit {
+			n = purgeFetchLimit
+		}
+
+		chunk := young[:n]
+		young = young[n:]
+
+		ids := make([]discord.MessageID, len(chunk))
+		for i, m := range chunk {
+			ids[i] = m.ID
+		}
+
+		// DeleteMessages uses a single delete, if there is only one message
+		if err := s.DeleteMessages(channelID, ids); err != nil {
+			return deleted, err
+		}
+
+		deleted = append(deleted, chunk...)
+		pr.update(len(deleted))
+	}
+
+	for i, m := range old {
+		if err := s.DeleteMessage(channelID, m.ID); err != nil {
+			return deleted, err
+		}
+
+		deleted = append(deleted, m)
+
+		// single deletes are slow, so update more often
+		if (i+1)%10 == 0 {
+			pr.update(len(deleted))
+		}
+	}
+
+	return deleted, nil
+}
+
+// purgeProgress shows the progress of large purges.
+type purgeProgress struct {
+	ctx   *plugin.Context
+	total int
+	// messageID is the id of the progress message, if one was sent.
+	messageID discord.MessageID
+}
+
+func (p *purgeProgress) update(deleted int) {
+	if p.total <= purgeProgressThreshold || deleted >= p.total {
+		return
+	}
+
+	progress := purgeProgressMessage.WithPlaceholders(purgeProgressPlaceholders{
+		Deleted: deleted,
+		Total:   p.total,
+	})
+
+	// showing the progress is not essential, so errors are ignored
+	if !p.messageID.IsValid() {
+		if msg, err := p.ctx.Replyl(progress); err == nil {
+			p.messageID = msg.ID
+		}
+
+		return
+	}
+
+	_, _ = p.ctx.Editl(p.messageID, progress)
+}
+
+// logPurge posts a transcript of the passed purged messages to the mod-log
+// channel, if the guild has one.
+func logPurge(s *state.State, ctx *plugin.Context, msgs []discord.Message) {
+	channelID, ok := config.C.Moderation.LogChannels[ctx.GuildID]
+	if !ok || len(msgs) == 0 {
+		return
+	}
+
+	log := zap.S().Named("moderation").With("guild_id", ctx.GuildID, "channel_id", ctx.ChannelID)
+
+	title, err := ctx.Localize(purgeLogTitle.WithPlaceholders(purgeLogPlaceholders{
+		Count:   len(msgs),
+		Channel: channelName(s, ctx.ChannelID),
+	}))
+	if err != nil {
+		log.With("err", err).
+			Error("unable to localize purge log")
+		return
+	}
+
+	moderatorField, err := ctx.Localize(caseModeratorField)
+	if err != nil {
+		log.With("err", err).
+			Error("unable to localize purge log")
+		return
+	}
+
+	embed := discord.Embed{
+		Title:     title,
+		Color:     0x95a5a6,
+		Timestamp: discord.NowTimestamp(),
+		Fields: []discord.EmbedField{
+			{Name: moderatorField, Value: userField(ctx.Author.ID)},
+		},
+	}
+
+	file := sendpart.File{
+		Name:   fmt.Sprintf("purge-%d.txt", ctx.ChannelID),
+		Reader: strings.NewReader(purgeTranscript(msgs)),
+	}
+
+	_, err = s.SendMessageComplex(channelID, api.SendMessageData{Embed: &embed, Files: []sendpart.File{file}})
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to post purge transcript")
+	}
+}
+
+// purgeTranscript creates a transcript of the passed messages, sorted from
+// oldest to newest.
+func purgeTranscript(msgs []discord.Message) string {
+	var b strings.Builder
+
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+
+		b.WriteString("[" + m.ID.Time().UTC().Format("2006-01-02 15:04:05") + "] ")
+		b.WriteString(discordutil.UserTag(m.Author) + " (" + m.Author.ID.String() + "): " + m.Content)
+
+		for _, a := range m.Attachments {
+			b.WriteString("\n    " + a.URL)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// channelName returns the name of the channel with the passed id, or its
+// mention, if the channel can't be retrieved.
+func channelName(s *state.State, channelID discord.ChannelID) string {
+	c, err := s.Channel(channelID)
+	if err != nil {
+		return channelID.Mention()
+	}
+
+	return "#" + c.Name
+}
@@ -0,0 +1,544 @@
+package moderation
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+const (
+	// defaultRaidInterval is the interval used, if a guild's
+	// config.RaidConfig has none.
+	defaultRaidInterval = 10 * time.Second
+	// defaultRaidCooldown is the cool-down used, if a guild's
+	// config.RaidConfig has none.
+	defaultRaidCooldown = 10 * time.Minute
+	// minSkeletonLength is the minimum length of a username skeleton, to be
+	// compared with those of other members.
+	minSkeletonLength = 3
+)
+
+// The actions taken against members joining during raid mode.
+const (
+	raidActionKick       = "kick"
+	raidActionQuarantine = "quarantine"
+)
+
+type (
+	// RaidGuard detects raids by tracking the members joining guilds, and
+	// manages the raid mode of guilds.
+	RaidGuard struct {
+		s         *state.State
+		repo      *repository.Repository
+		cases     *CaseLog
+		localizer LocalizerFunc
+
+		mutex sync.Mutex
+		// joins are the recent joins of each guild.
+		joins map[discord.GuildID][]raidJoin
+		raids map[discord.GuildID]*raidMode
+	}
+
+	raidJoin struct {
+		userID   discord.UserID
+		time     time.Time
+		username string
+		avatar   discord.Hash
+	}
+
+	raidMode struct {
+		repository.Raid
+		// cooldown is the timer disabling an automatically enabled raid mode.
+		cooldown *time.Timer
+	}
+)
+
+// NewRaidGuard creates a new *RaidGuard, that stores the raid modes of
+// guilds in the passed repository, and uses the passed *CaseLog to record the
+// actions taken against members joining during raid mode.
+// The passed LocalizerFunc is used to localize alerts.
+func NewRaidGuard(
+	s *state.State, repo *repository.Repository, cl *CaseLog, localizer LocalizerFunc,
+) *RaidGuard {
+	return &RaidGuard{
+		s:         s,
+		repo:      repo,
+		cases:     cl,
+		localizer: localizer,
+		joins:     make(map[discord.GuildID][]raidJoin),
+		raids:     make(map[discord.GuildID]*raidMode),
+	}
+}
+
+func raidLog(guildID discord.GuildID) *zap.SugaredLogger {
+	return zap.S().Named("raid").With("guild_id", guildID)
+}
+
+// Start restores the raid modes that were active when levin was stopped.
+// The cool-downs of automatically enabled raid modes are restarted.
+func (g *RaidGuard) Start() error {
+	raids, err := g.repo.Raids()
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, r := range raids {
+		rm := &raidMode{Raid: r}
+		g.raids[r.GuildID] = rm
+
+		if r.Automatic {
+			g.resetCooldown(rm)
+		}
+	}
+
+	return nil
+}
+
+// Active checks if the guild with the passed id is in raid mode.
+func (g *RaidGuard) Active(guildID discord.GuildID) bool {
+	_, ok := g.Raid(guildID)
+	return ok
+}
+
+// Raid returns the raid mode of the guild with the passed id, if it is in
+// raid mode.
+func (g *RaidGuard) Raid(guildID discord.GuildID) (repository.Raid, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	rm, ok := g.raids[guildID]
+	if !ok {
+		return repository.Raid{}, false
+	}
+
+	return rm.Raid, true
+}
+
+// Enable enables raid mode in the guild with the passed id, raises its
+// verification level and alerts its moderators.
+// Automatically enabled raid modes are disabled after the cool-down of the
+// guild.
+//
+// The passed reason is localized in the language of the guild.
+//
+// If the guild is already in raid mode, Enable returns false.
+func (g *RaidGuard) Enable(guildID discord.GuildID, automatic bool, reason *i18n.Config) (bool, error) {
+	cfg := config.C.Moderation.Raids[guildID]
+	log := raidLog(guildID)
+
+	localizedReason := g.localizeReason(guildID, reason)
+
+	g.mutex.Lock()
+
+	if _, ok := g.raids[guildID]; ok {
+		g.mutex.Unlock()
+		return false, nil
+	}
+
+	rm := &raidMode{Raid: repository.Raid{
+		GuildID:              guildID,
+		Since:                time.Now(),
+		Automatic:            automatic,
+		Reason:               localizedReason,
+		PreviousVerification: discord.NullVerification,
+	}}
+	g.raids[guildID] = rm
+
+	g.mutex.Unlock()
+
+	prev, err := g.raiseVerification(guildID, cfg.Verification)
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to raise verification level")
+	}
+
+	g.mutex.Lock()
+
+	rm.PreviousVerification = prev
+	if automatic {
+		g.resetCooldown(rm)
+	}
+
+	raid := rm.Raid
+
+	g.mutex.Unlock()
+
+	log.With("automatic", automatic, "reason", localizedReason).
+		Warn("raid mode enabled")
+
+	g.alert(cfg, guildID, raidEnabledTitle, localizedReason, 0xe74c3c)
+
+	return true, g.repo.PutRaid(raid)
+}
+
+// Disable disables raid mode in the guild with the passed id, restores its
+// verification level and alerts its moderators.
+//
+// The passed reason is localized in the language of the guild.
+//
+// If the guild is not in raid mode, Disable returns false.
+func (g *RaidGuard) Disable(guildID discord.GuildID, reason *i18n.Config) (bool, error) {
+	g.mutex.Lock()
+
+	rm, ok := g.raids[guildID]
+	if !ok {
+		g.mutex.Unlock()
+		return false, nil
+	}
+
+	if rm.cooldown != nil {
+		rm.cooldown.Stop()
+	}
+
+	delete(g.raids, guildID)
+
+	g.mutex.Unlock()
+
+	log := raidLog(guildID)
+
+	if rm.PreviousVerification != discord.NullVerification {
+		prev := rm.PreviousVerification
+
+		_, err := g.s.ModifyGuild(guildID, api.ModifyGuildData{Verification: &prev})
+		if err != nil {
+			log.With("err", err).
+				Warn("unable to restore verification level")
+		}
+	}
+
+	localizedReason := g.localizeReason(guildID, reason)
+
+	log.With("reason", localizedReason).
+		Info("raid mode disabled")
+
+	g.alert(config.C.Moderation.Raids[guildID], guildID, raidDisabledTitle, localizedReason, 0x2ecc71)
+
+	return true, g.repo.DeleteRaid(guildID)
+}
+
+// raiseVerification raises the verification level of the guild with the
+// passed id to the passed level, and returns the previous level.
+// If the verification level is already as high, or level is 0, the
+// verification level is not changed, and discord.NullVerification is
+// returned.
+func (g *RaidGuard) raiseVerification(
+	guildID discord.GuildID, level discord.Verification,
+) (discord.Verification, error) {
+	if level <= discord.NoVerification {
+		return discord.NullVerification, nil
+	}
+
+	guild, err := g.s.Guild(guildID)
+	if err != nil {
+		return discord.NullVerification, err
+	}
+
+	if guild.Verification >= level {
+		return discord.NullVerification, nil
+	}
+
+	if _, err = g.s.ModifyGuild(guildID, api.ModifyGuildData{Verification: &level}); err != nil {
+		return discord.NullVerification, err
+	}
+
+	return guild.Verification, nil
+}
+
+// resetCooldown (re)starts the cool-down of the passed raid mode.
+// The caller must hold the mutex.
+func (g *RaidGuard) resetCooldown(rm *raidMode) {
+	cooldown := config.C.Moderation.Raids[rm.GuildID].Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultRaidCooldown
+	}
+
+	if rm.cooldown != nil {
+		rm.cooldown.Stop()
+	}
+
+	rm.cooldown = time.AfterFunc(cooldown, func() {
+		if _, err := g.Disable(rm.GuildID, raidReasonCooldown); err != nil {
+			raidLog(rm.GuildID).With("err", err).
+				Error("unable to disable raid mode after cool-down")
+		}
+	})
+}
+
+// localizeReason localizes the passed reason in the language of the guild
+// with the passed id.
+// If that fails, the fallback is used.
+func (g *RaidGuard) localizeReason(guildID discord.GuildID, reason *i18n.Config) string {
+	s, err := g.localizer(guildID).Localize(reason)
+	if err != nil {
+		raidLog(guildID).With("err", err).
+			Error("unable to localize raid reason")
+
+		s, _ = i18n.NewFallbackLocalizer().Localize(reason)
+	}
+
+	return s
+}
+
+// alert alerts the moderators of the guild with the passed id about a
+// change of the raid mode.
+func (g *RaidGuard) alert(
+	cfg config.RaidConfig, guildID discord.GuildID, title *i18n.Config, reason string, color discord.Color,
+) {
+	channelID := cfg.AlertChannel
+	if !channelID.IsValid() {
+		channelID = config.C.Moderation.LogChannels[guildID]
+	}
+
+	if !channelID.IsValid() {
+		return
+	}
+
+	log := raidLog(guildID).With("channel_id", channelID)
+
+	localizedTitle, err := g.localizer(guildID).Localize(title)
+	if err != nil {
+		log.With("err", err).
+			Error("unable to localize raid alert")
+		return
+	}
+
+	mentions := make([]string, len(cfg.AlertRoles))
+	for i, id := range cfg.AlertRoles {
+		mentions[i] = id.Mention()
+	}
+
+	_, err = g.s.SendMessageComplex(channelID, api.SendMessageData{
+		Content: strings.Join(mentions, " "),
+		Embed: &discord.Embed{
+			Title:       localizedTitle,
+			Description: reason,
+			Color:       color,
+			Timestamp:   discord.NowTimestamp(),
+		},
+		AllowedMentions: &api.AllowedMentions{Parse: []api.AllowedMentionType{}, Roles: cfg.AlertRoles},
+	})
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to send raid alert")
+	}
+}
+
+// Handler returns the handler tracking the members joining guilds.
+// It enables raid mode, if it detects a raid, and takes action against
+// the members joining during raid mode.
+func (g *RaidGuard) Handler() func(*state.State, *state.GuildMemberAddEvent) {
+	return func(_ *state.State, e *state.GuildMemberAddEvent) {
+		cfg, ok := config.C.Moderation.Raids[e.GuildID]
+		if !ok {
+			return
+		}
+
+		interval := cfg.Interval
+		if interval <= 0 {
+			interval = defaultRaidInterval
+		}
+
+		now := time.Now()
+
+		g.mutex.Lock()
+
+		joins := append(g.joins[e.GuildID], raidJoin{
+			userID:   e.User.ID,
+			time:     now,
+			username: e.User.Username,
+			avatar:   e.User.Avatar,
+		})
+
+		// forget the joins before the interval
+		for len(joins) > 0 && now.Sub(joins[0].time) > interval {
+			joins = joins[1:]
+		}
+
+		g.joins[e.GuildID] = joins
+
+		rm, active := g.raids[e.GuildID]
+		if active && rm.Automatic {
+			g.resetCooldown(rm)
+		}
+
+		g.mutex.Unlock()
+
+		if active {
+			g.act(cfg, e.GuildID, e.User.ID)
+			return
+		}
+
+		reason := detectRaid(cfg, interval, joins)
+		if reason == nil {
+			return
+		}
+
+		enabled, err := g.Enable(e.GuildID, true, reason)
+		if err != nil {
+			raidLog(e.GuildID).With("err", err).
+				Error("unable to store raid mode")
+		}
+
+		// raid mode was enabled concurrently, which means the other joins
+		// are already being handled
+		if !enabled {
+			g.act(cfg, e.GuildID, e.User.ID)
+			return
+		}
+
+		userIDs := make([]discord.UserID, len(joins))
+		for i, j := range joins {
+			userIDs[i] = j.userID
+		}
+
+		g.act(cfg, e.GuildID, userIDs...)
+	}
+}
+
+// detectRaid checks if the passed joins, which all occurred within the
+// passed interval, are a raid.
+// If so, it returns the reason why the joins are considered a raid.
+// Otherwise, it returns nil.
+func detectRaid(cfg config.RaidConfig, interval time.Duration, joins []raidJoin) *i18n.Config {
+	reason := func(term *i18n.Config, n int) *i18n.Config {
+		return term.WithPlaceholders(raidReasonPlaceholders{
+			Members:  n,
+			Interval: duration.Format(interval),
+		})
+	}
+
+	if cfg.Joins > 0 && len(joins) > cfg.Joins {
+		return reason(raidReasonJoins, len(joins))
+	}
+
+	if cfg.NewAccountJoins > 0 && cfg.MinAccountAge > 0 {
+		var n int
+
+		for _, j := range joins {
+			if j.time.Sub(discord.Snowflake(j.userID).Time()) < cfg.MinAccountAge {
+				n++
+			}
+		}
+
+		if n > cfg.NewAccountJoins {
+			return reason(raidReasonNewAccounts, n)
+		}
+	}
+
+	if cfg.SimilarJoins > 0 {
+		skeletons := make(map[string]int, len(joins))
+		avatars := make(map[discord.Hash]int, len(joins))
+
+		for _, j := range joins {
+			if s := skeleton(j.username); len(s) >= minSkeletonLength {
+				skeletons[s]++
+			}
+
+			if len(j.avatar) > 0 {
+				avatars[j.avatar]++
+			}
+		}
+
+		for _, n := range skeletons {
+			if n > cfg.SimilarJoins {
+				return reason(raidReasonSimilarUsernames, n)
+			}
+		}
+
+		for _, n := range avatars {
+			if n > cfg.SimilarJoins {
+				return reason(raidReasonSameAvatar, n)
+			}
+		}
+	}
+
+	return nil
+}
+
+// skeleton returns the skeleton of the passed username, i.e. its lowercased
+// letters, so that usernames like raider1 and Raider_2 are considered
+// similar.
+func skeleton(username string) string {
+	var b strings.Builder
+	b.Grow(len(username))
+
+	for _, r := range strings.ToLower(username) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// act takes the raid action of the guild with the passed id against the
+// users with the passed ids.
+func (g *RaidGuard) act(cfg config.RaidConfig, guildID discord.GuildID, userIDs ...discord.UserID) {
+	log := raidLog(guildID).With("action", cfg.Action)
+
+	var caseType repository.CaseType
+
+	switch strings.ToLower(cfg.Action) {
+	case "":
+		return
+	case raidActionKick:
+		caseType = repository.CaseKick
+	case raidActionQuarantine:
+		caseType = repository.CaseQuarantine
+	default:
+		log.Warn("unknown raid action")
+		return
+	}
+
+	me, err := g.s.Me()
+	if err != nil {
+		log.With("err", err).
+			Error("unable to get self to take raid action")
+		return
+	}
+
+	reason := autoReason{key: reasonRaidMode}
+
+	for _, id := range userIDs {
+		if caseType == repository.CaseKick {
+			err = kick(g.s, guildID, id, reason.String())
+		} else {
+			err = addRole(g.s, guildID, id, cfg.QuarantineRole, reason.String())
+		}
+
+		if err != nil {
+			log.With("err", err, "user_id", id).
+				Warn("unable to take raid action")
+			continue
+		}
+
+		c := &repository.Case{
+			GuildID:     guildID,
+			Type:        caseType,
+			UserID:      id,
+			ModeratorID: me.ID,
+		}
+		reason.apply(c)
+
+		if err := g.cases.Record(c); err != nil {
+			log.With("err", err, "user_id", id).
+				Error("unable to record raid action")
+			sentry.CaptureException(err)
+		}
+	}
+}
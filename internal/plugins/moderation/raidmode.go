@@ -0,0 +1,81 @@
+package moderation
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/impl/restriction"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/discordutil"
+)
+
+// RaidMode is the raidmode command.
+type RaidMode struct {
+	command.LocalizedMeta
+	guard *RaidGuard
+}
+
+var _ plugin.Command = new(RaidMode) // compile-time check
+
+func newRaidMode(rg *RaidGuard) *RaidMode {
+	return &RaidMode{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "raidmode",
+			ShortDescription: raidModeShortDescription,
+			LongDescription:  raidModeLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Optional: []arg.LocalizedOptionalArg{
+					{
+						Name:        raidModeStateArgName,
+						Type:        arg.Choice{{Name: "on"}, {Name: "off"}},
+						Description: raidModeStateArgDescription,
+					},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages,
+			Restrictions:   restriction.UserPermissions(discord.PermissionManageGuild),
+		},
+		guard: rg,
+	}
+}
+
+func (r *RaidMode) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	switch ctx.Args.String(0) {
+	case "on":
+		reason := raidReasonEnabledBy.WithPlaceholders(raidReasonUserPlaceholders{User: discordutil.UserTag(ctx.Author)})
+
+		enabled, err := r.guard.Enable(ctx.GuildID, false, reason)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		} else if !enabled {
+			return nil, errors.NewUserErrorl(raidModeAlreadyEnabledError)
+		}
+
+		return raidModeEnabled, nil
+	case "off":
+		reason := raidReasonDisabledBy.WithPlaceholders(raidReasonUserPlaceholders{User: discordutil.UserTag(ctx.Author)})
+
+		disabled, err := r.guard.Disable(ctx.GuildID, reason)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		} else if !disabled {
+			return nil, errors.NewUserErrorl(raidModeNotEnabledError)
+		}
+
+		return raidModeDisabled, nil
+	default:
+		raid, ok := r.guard.Raid(ctx.GuildID)
+		if !ok {
+			return raidModeStatusDisabled, nil
+		}
+
+		return raidModeStatusEnabled.WithPlaceholders(raidModeStatusPlaceholders{
+			Since:  raid.Since.UTC().Format("2006-01-02 15:04 MST"),
+			Reason: raid.Reason,
+		}), nil
+	}
+}
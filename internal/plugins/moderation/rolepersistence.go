@@ -0,0 +1,350 @@
+package moderation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// defaultRetention is the retention used, if a guild's
+// config.RolePersistenceConfig has none.
+const defaultRetention = 30 * 24 * time.Hour
+
+// RolePersistence stores the roles of members leaving a guild, and restores
+// the sticky ones, if they rejoin within the retention period of the guild.
+//
+// Mute and quarantine roles are always sticky, so that members can't escape
+// mutes by rejoining.
+// Which other roles are sticky is configured through
+// config.C.Moderation.RolePersistence.
+type RolePersistence struct {
+	s    *state.State
+	repo *repository.Repository
+}
+
+// NewRolePersistence creates a new *RolePersistence, that stores the roles
+// of members in the passed repository.
+func NewRolePersistence(s *state.State, repo *repository.Repository) *RolePersistence {
+	return &RolePersistence{s: s, repo: repo}
+}
+
+func rolePersistenceLog(guildID discord.GuildID) *zap.SugaredLogger {
+	return zap.S().Named("role_persistence").With("guild_id", guildID)
+}
+
+// retention returns the retention period of the guild with the passed id.
+func retention(guildID discord.GuildID) time.Duration {
+	if r := config.C.Moderation.RolePersistence[guildID].Retention; r > 0 {
+		return r
+	}
+
+	return defaultRetention
+}
+
+// Handlers returns the handlers storing and restoring roles.
+func (p *RolePersistence) Handlers() []interface{} {
+	return []interface{}{p.guildCreate, p.memberRemove, p.memberAdd}
+}
+
+// guildCreate prunes the roles stored for members that didn't rejoin within
+// the retention period.
+func (p *RolePersistence) guildCreate(_ *state.State, e *state.GuildCreateEvent) {
+	_, err := p.repo.PruneStoredRoles(e.ID, time.Now().Add(-retention(e.ID)))
+	if err != nil {
+		rolePersistenceLog(e.ID).With("err", err).
+			Error("unable to prune stored roles")
+	}
+}
+
+func (p *RolePersistence) memberRemove(_ *state.State, e *state.GuildMemberRemoveEvent) {
+	log := rolePersistenceLog(e.GuildID).With("user_id", e.User.ID)
+
+	var roleIDs []discord.RoleID
+
+	if e.Old != nil {
+		roleIDs = e.Old.RoleIDs
+	} else {
+		// without the old member, the roles are unknown, but the mute role
+		// must stay sticky nonetheless
+		muteRoleID, err := p.muteRole(e.GuildID, e.User.ID)
+		if err != nil {
+			log.With("err", err).
+				Error("unable to get mute of leaving member")
+			return
+		}
+
+		if muteRoleID.IsValid() {
+			roleIDs = []discord.RoleID{muteRoleID}
+		}
+	}
+
+	if len(roleIDs) == 0 {
+		return
+	}
+
+	err := p.repo.PutStoredRoles(repository.StoredRoles{
+		GuildID: e.GuildID,
+		UserID:  e.User.ID,
+		RoleIDs: roleIDs,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		log.With("err", err).
+			Error("unable to store roles of leaving member")
+	}
+}
+
+// muteRole returns the id of the mute role of the user with the passed id,
+// if they are muted, either temporarily or permanently.
+// If they aren't muted, muteRole returns discord.NullRoleID.
+func (p *RolePersistence) muteRole(guildID discord.GuildID, userID discord.UserID) (discord.RoleID, error) {
+	tp, err := p.repo.TempPunishment(repository.PunishmentMute, guildID, userID)
+	if err != nil {
+		return discord.NullRoleID, err
+	} else if tp != nil {
+		return tp.RoleID, nil
+	}
+
+	pm, err := p.repo.PermanentMute(guildID, userID)
+	if err != nil || pm == nil {
+		return discord.NullRoleID, err
+	}
+
+	return pm.RoleID, nil
+}
+
+func (p *RolePersistence) memberAdd(_ *state.State, e *state.GuildMemberAddEvent) {
+	log := rolePersistenceLog(e.GuildID).With("user_id", e.User.ID)
+
+	sr, err := p.repo.StoredRoles(e.GuildID, e.User.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return
+	} else if err != nil {
+		log.With("err", err).
+			Error("unable to get stored roles")
+		return
+	}
+
+	// the roles are restored only once, the next time the member leaves
+	// they are stored again
+	if _, err = p.repo.DeleteStoredRoles(e.GuildID, e.User.ID); err != nil {
+		log.With("err", err).
+			Error("unable to delete restored roles")
+	}
+
+	if time.Since(sr.Time) > retention(e.GuildID) {
+		return
+	}
+
+	restore, err := stickyRoles(p.s, e.GuildID, sr.RoleIDs)
+	if err != nil {
+		log.With("err", err).
+			Error("unable to determine sticky roles")
+		return
+	}
+
+	for _, id := range restore {
+		if err := addRole(p.s, e.GuildID, e.User.ID, id, "Role persistence"); err != nil {
+			log.With("err", err, "role_id", id).
+				Warn("unable to restore role")
+		}
+	}
+
+	if len(restore) > 0 {
+		log.With("roles", restore).
+			Info("restored roles of rejoining member")
+	}
+}
+
+// stickyRoles returns the roles of the passed roles, that are sticky in the
+// guild with the passed id, and that levin is allowed to assign.
+func stickyRoles(s *state.State, guildID discord.GuildID, roleIDs []discord.RoleID) ([]discord.RoleID, error) {
+	me, err := s.Me()
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := s.Member(guildID, me.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.Roles(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := newHierarchy(s, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	sticky := make([]discord.RoleID, 0, len(roleIDs))
+
+	for _, r := range roles {
+		if !containsRole(roleIDs, r.ID) || !isSticky(guildID, r) || r.Managed || !h.outranksRole(*self, r.ID) {
+			continue
+		}
+
+		sticky = append(sticky, r.ID)
+	}
+
+	return sticky, nil
+}
+
+// isSticky checks if the passed role of the guild with the passed id is
+// sticky.
+func isSticky(guildID discord.GuildID, r discord.Role) bool {
+	// @everyone has the id of the guild
+	if discord.GuildID(r.ID) == guildID {
+		return false
+	}
+
+	if muteRoleID, ok := config.C.Moderation.MuteRoles[guildID]; ok {
+		if r.ID == muteRoleID {
+			return true
+		}
+	} else if strings.EqualFold(r.Name, config.C.Moderation.MuteRoleName) {
+		return true
+	}
+
+	if qr := config.C.Moderation.Raids[guildID].QuarantineRole; qr.IsValid() && r.ID == qr {
+		return true
+	}
+
+	cfg := config.C.Moderation.RolePersistence[guildID]
+	return cfg.AllRoles || containsRole(cfg.Roles, r.ID)
+}
+
+func containsRole(roleIDs []discord.RoleID, roleID discord.RoleID) bool {
+	for _, id := range roleIDs {
+		if id == roleID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// =============================================================================
+// StoredRoles
+// =====================================================================================
+
+// StoredRoles is the storedroles command.
+type StoredRoles struct {
+	command.LocalizedMeta
+	repo *repository.Repository
+}
+
+var _ plugin.Command = new(StoredRoles) // compile-time check
+
+func newStoredRoles(repo *repository.Repository) *StoredRoles {
+	return &StoredRoles{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "storedroles",
+			ShortDescription: storedRolesShortDescription,
+			LongDescription:  storedRolesLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+				Optional: []arg.LocalizedOptionalArg{
+					{
+						Name:        storedRolesActionArgName,
+						Type:        arg.Choice{{Name: "clear"}},
+						Description: storedRolesActionArgDescription,
+					},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionEmbedLinks,
+			Restrictions:   moderatorRestriction,
+		},
+		repo: repo,
+	}
+}
+
+func (c *StoredRoles) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+
+	if ctx.Args.String(1) == "clear" {
+		deleted, err := c.repo.DeleteStoredRoles(ctx.GuildID, target.ID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		} else if !deleted {
+			return nil, errors.NewUserErrorl(noStoredRolesError.
+				WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+		}
+
+		return storedRolesCleared.WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}), nil
+	}
+
+	sr, err := c.repo.StoredRoles(ctx.GuildID, target.ID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && time.Since(sr.Time) > retention(ctx.GuildID)) {
+		return nil, errors.NewUserErrorl(noStoredRolesError.
+			WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sticky, err := stickyRoles(s, ctx.GuildID, sr.RoleIDs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	title, err := ctx.Localize(storedRolesTitle.WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rolesField, err := ctx.Localize(storedRolesRolesField)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	restoredField, err := ctx.Localize(storedRolesRestoredField)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	footer, err := ctx.Localize(storedRolesFooter.WithPlaceholders(storedRolesFooterPlaceholders{
+		Left:    sr.Time.UTC().Format("2006-01-02 15:04"),
+		Expires: sr.Time.Add(retention(ctx.GuildID)).UTC().Format("2006-01-02 15:04"),
+	}))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return discord.Embed{
+		Title: title,
+		Fields: []discord.EmbedField{
+			{Name: rolesField, Value: roleMentions(sr.RoleIDs)},
+			{Name: restoredField, Value: roleMentions(sticky)},
+		},
+		Footer: &discord.EmbedFooter{Text: footer},
+	}, nil
+}
+
+// roleMentions returns the mentions of the passed roles, or a dash, if there
+// are none.
+func roleMentions(roleIDs []discord.RoleID) string {
+	if len(roleIDs) == 0 {
+		return "-"
+	}
+
+	mentions := make([]string, len(roleIDs))
+	for i, id := range roleIDs {
+		mentions[i] = id.Mention()
+	}
+
+	return discordutil.Truncate(strings.Join(mentions, " "), 1024)
+}
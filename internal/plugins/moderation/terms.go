@@ -0,0 +1,666 @@
+package moderation
+
+import (
+	"github.com/mavolin/adam/pkg/i18n"
+
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// =============================================================================
+// Meta
+// =====================================================================================
+
+var (
+	shortDescription = i18n.NewFallbackConfig("plugin.moderation.short_description",
+		"Commands to moderate the server.")
+	longDescription = i18n.NewFallbackConfig("plugin.moderation.long_description",
+		"Commands to warn, kick, ban, and mute members of the server, to view their cases, to delete messages, "+
+			"to restore the roles of rejoining members, and to protect the server from raids.")
+)
+
+var (
+	kickShortDescription = i18n.NewFallbackConfig("plugin.moderation.kick.short_description",
+		"Kicks a member.")
+	kickLongDescription = i18n.NewFallbackConfig("plugin.moderation.kick.long_description",
+		"Kicks a member from the server. They can rejoin using an invite.")
+
+	banShortDescription = i18n.NewFallbackConfig("plugin.moderation.ban.short_description",
+		"Bans a user.")
+	banLongDescription = i18n.NewFallbackConfig("plugin.moderation.ban.long_description",
+		"Bans a user from the server, even if they are not a member. "+
+			"If a duration is given, the ban is lifted automatically once it expires.")
+
+	softbanShortDescription = i18n.NewFallbackConfig("plugin.moderation.softban.short_description",
+		"Kicks a member and deletes their recent messages.")
+	softbanLongDescription = i18n.NewFallbackConfig("plugin.moderation.softban.long_description",
+		"Bans and immediately unbans a member, to kick them and delete their messages of the last days.")
+
+	unbanShortDescription = i18n.NewFallbackConfig("plugin.moderation.unban.short_description",
+		"Unbans a user.")
+	unbanLongDescription = i18n.NewFallbackConfig("plugin.moderation.unban.long_description",
+		"Unbans a user, and cancels their temporary ban, if they have one.")
+
+	muteShortDescription = i18n.NewFallbackConfig("plugin.moderation.mute.short_description",
+		"Mutes a member.")
+	muteLongDescription = i18n.NewFallbackConfig("plugin.moderation.mute.long_description",
+		"Mutes a member by giving them the mute role. "+
+			"If a duration is given, the mute is lifted automatically once it expires.")
+
+	unmuteShortDescription = i18n.NewFallbackConfig("plugin.moderation.unmute.short_description",
+		"Unmutes a member.")
+	unmuteLongDescription = i18n.NewFallbackConfig("plugin.moderation.unmute.long_description",
+		"Unmutes a member by removing the mute role from them.")
+
+	caseShortDescription = i18n.NewFallbackConfig("plugin.moderation.case.short_description",
+		"Shows a case.")
+	caseLongDescription = i18n.NewFallbackConfig("plugin.moderation.case.long_description",
+		"Shows the moderation case with the passed number.")
+
+	reasonShortDescription = i18n.NewFallbackConfig("plugin.moderation.reason.short_description",
+		"Changes the reason of a case.")
+	reasonLongDescription = i18n.NewFallbackConfig("plugin.moderation.reason.long_description",
+		"Changes the reason of the case with the passed number, and updates it in the mod-log channel.")
+
+	historyShortDescription = i18n.NewFallbackConfig("plugin.moderation.history.short_description",
+		"Shows the cases of a user.")
+	historyLongDescription = i18n.NewFallbackConfig("plugin.moderation.history.long_description",
+		"Shows the moderation cases of a user, starting with the most recent.")
+
+	warnShortDescription = i18n.NewFallbackConfig("plugin.moderation.warn.short_description",
+		"Warns a member.")
+	warnLongDescription = i18n.NewFallbackConfig("plugin.moderation.warn.long_description",
+		"Warns a member. "+
+			"If the member reaches the number of warnings of one of the server's escalation rules, "+
+			"they are punished automatically.")
+
+	warningsShortDescription = i18n.NewFallbackConfig("plugin.moderation.warnings.short_description",
+		"Shows the warnings of a user.")
+	warningsLongDescription = i18n.NewFallbackConfig("plugin.moderation.warnings.long_description",
+		"Shows the warnings of a user that haven't expired yet.")
+
+	clearWarnShortDescription = i18n.NewFallbackConfig("plugin.moderation.clearwarn.short_description",
+		"Clears the warnings of a user.")
+	clearWarnLongDescription = i18n.NewFallbackConfig("plugin.moderation.clearwarn.long_description",
+		"Clears all warnings of a user, or only the warning with the passed id.")
+
+	raidModeShortDescription = i18n.NewFallbackConfig("plugin.moderation.raidmode.short_description",
+		"Enables or disables raid mode.")
+	raidModeLongDescription = i18n.NewFallbackConfig("plugin.moderation.raidmode.long_description",
+		"Enables or disables raid mode, or shows whether it is enabled. "+
+			"During raid mode, the verification level of the server is raised, and action is taken against "+
+			"new members. Raid modes enabled manually stay enabled until they are disabled.")
+
+	purgeShortDescription = i18n.NewFallbackConfig("plugin.moderation.purge.short_description",
+		"Deletes messages in bulk.")
+	purgeLongDescription = i18n.NewFallbackConfig("plugin.moderation.purge.long_description",
+		"Deletes up to the passed number of messages matching all passed filters, starting with the most "+
+			"recent. Pinned messages are never deleted. A transcript is posted to the mod-log channel.")
+
+	lockShortDescription = i18n.NewFallbackConfig("plugin.moderation.lock.short_description",
+		"Locks a channel or category.")
+	lockLongDescription = i18n.NewFallbackConfig("plugin.moderation.lock.long_description",
+		"Prevents @everyone from sending messages in a channel, or in all channels of a category. "+
+			"If no channel is given, the current channel is locked.")
+
+	unlockShortDescription = i18n.NewFallbackConfig("plugin.moderation.unlock.short_description",
+		"Unlocks a channel or category.")
+	unlockLongDescription = i18n.NewFallbackConfig("plugin.moderation.unlock.long_description",
+		"Unlocks a locked channel, or all locked channels of a category, restoring the permissions they had "+
+			"before they were locked.")
+
+	lockdownShortDescription = i18n.NewFallbackConfig("plugin.moderation.lockdown.short_description",
+		"Locks or unlocks the whole server.")
+	lockdownLongDescription = i18n.NewFallbackConfig("plugin.moderation.lockdown.long_description",
+		"Locks all channels @everyone can send messages in, or lifts the lockdown, or shows whether the "+
+			"server is in lockdown. Channels that were locked before the lockdown stay locked when it is lifted.")
+
+	slowmodeShortDescription = i18n.NewFallbackConfig("plugin.moderation.slowmode.short_description",
+		"Sets the slowmode of a channel.")
+	slowmodeLongDescription = i18n.NewFallbackConfig("plugin.moderation.slowmode.long_description",
+		"Sets the time members have to wait between sending messages in a channel. "+
+			"If no channel is given, the slowmode of the current channel is set.")
+
+	storedRolesShortDescription = i18n.NewFallbackConfig("plugin.moderation.storedroles.short_description",
+		"Shows or clears the stored roles of a user.")
+	storedRolesLongDescription = i18n.NewFallbackConfig("plugin.moderation.storedroles.long_description",
+		"Shows the roles a user had when they left the server, and which of them are restored if they "+
+			"rejoin. Use `clear` to delete the stored roles, so that none are restored.")
+)
+
+// =============================================================================
+// Args
+// =====================================================================================
+
+var (
+	memberArgName = i18n.NewFallbackConfig("plugin.moderation.args.member.name", "Member")
+	userArgName   = i18n.NewFallbackConfig("plugin.moderation.args.user.name", "User")
+
+	reasonArgName        = i18n.NewFallbackConfig("plugin.moderation.args.reason.name", "Reason")
+	reasonArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.reason.description",
+		"The reason shown in the audit log.")
+
+	durationFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.duration.description",
+		"The duration of the punishment. If not set, the punishment is permanent.")
+	deleteDaysFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.delete.description",
+		"The number of days of messages to delete, between 0 and 7.")
+
+	caseArgName        = i18n.NewFallbackConfig("plugin.moderation.args.case.name", "Case")
+	caseArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.case.description",
+		"The number of the case.")
+
+	newReasonArgName        = i18n.NewFallbackConfig("plugin.moderation.args.new_reason.name", "Reason")
+	newReasonArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.new_reason.description",
+		"The new reason of the case.")
+
+	warnReasonArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.warn_reason.description",
+		"The reason of the warning, which is also sent to the member.")
+
+	raidModeStateArgName        = i18n.NewFallbackConfig("plugin.moderation.args.raid_mode_state.name", "State")
+	raidModeStateArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.raid_mode_state.description",
+		"Either `on` or `off`.")
+
+	warningArgName        = i18n.NewFallbackConfig("plugin.moderation.args.warning.name", "Warning")
+	warningArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.warning.description",
+		"The id of the warning to clear.")
+
+	lockChannelArgName        = i18n.NewFallbackConfig("plugin.moderation.args.lock_channel.name", "Channel")
+	lockChannelArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.lock_channel.description",
+		"The channel or category. Defaults to the current channel.")
+
+	lockdownStateArgName        = i18n.NewFallbackConfig("plugin.moderation.args.lockdown_state.name", "State")
+	lockdownStateArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.lockdown_state.description",
+		"Either `on` or `off`.")
+
+	slowmodeDurationArgName        = i18n.NewFallbackConfig("plugin.moderation.args.slowmode_duration.name", "Duration")
+	slowmodeDurationArgDescription = i18n.NewFallbackConfig(
+		"plugin.moderation.args.slowmode_duration.description",
+		"The time to wait between messages, up to 6 hours. Use `0s` to disable slowmode.")
+	slowmodeChannelArgName = i18n.NewFallbackConfig("plugin.moderation.args.slowmode_channel.name", "Channel")
+
+	storedRolesActionArgName        = i18n.NewFallbackConfig("plugin.moderation.args.stored_roles_action.name", "Action")
+	storedRolesActionArgDescription = i18n.NewFallbackConfig(
+		"plugin.moderation.args.stored_roles_action.description",
+		"Use `clear` to delete the stored roles.")
+
+	lockChannelTypeName = i18n.NewFallbackConfig("plugin.moderation.types.lock_channel.name",
+		"Channel or Category")
+	lockChannelTypeDescription = i18n.NewFallbackConfig("plugin.moderation.types.lock_channel.description",
+		"A mention or the id of a text channel, or the id of a category.")
+
+	purgeCountArgName        = i18n.NewFallbackConfig("plugin.moderation.args.purge_count.name", "Count")
+	purgeCountArgDescription = i18n.NewFallbackConfig("plugin.moderation.args.purge_count.description",
+		"The maximum number of messages to delete, between 1 and 1000.")
+
+	purgeUserFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_user.description",
+		"Only delete messages sent by this user.")
+	purgeBotsFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_bots.description",
+		"Only delete messages sent by bots.")
+	purgeContainsFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_contains.description",
+		"Only delete messages containing this text, ignoring case.")
+	purgeRegexpFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_regexp.description",
+		"Only delete messages matching this regular expression.")
+	purgeAttachmentsFlagDescription = i18n.NewFallbackConfig(
+		"plugin.moderation.flags.purge_attachments.description", "Only delete messages with attachments.")
+	purgeLinksFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_links.description",
+		"Only delete messages containing links.")
+	purgeBeforeFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_before.description",
+		"Only delete messages sent before the message with this id.")
+	purgeAfterFlagDescription = i18n.NewFallbackConfig("plugin.moderation.flags.purge_after.description",
+		"Only delete messages sent after the message with this id.")
+)
+
+// =============================================================================
+// Response
+// =====================================================================================
+
+var (
+	kickSuccess = i18n.NewFallbackConfig("plugin.moderation.kick.response.success",
+		"{{.target}} was kicked. (Case #{{.case}})")
+
+	banSuccess = i18n.NewFallbackConfig("plugin.moderation.ban.response.success",
+		"{{.target}} was banned. (Case #{{.case}})")
+	tempBanSuccess = i18n.NewFallbackConfig("plugin.moderation.ban.response.temp_success",
+		"{{.target}} was banned for {{.duration}}. (Case #{{.case}})")
+
+	softbanSuccess = i18n.NewFallbackConfig("plugin.moderation.softban.response.success",
+		"{{.target}} was softbanned. (Case #{{.case}})")
+
+	unbanSuccess = i18n.NewFallbackConfig("plugin.moderation.unban.response.success",
+		"{{.target}} was unbanned. (Case #{{.case}})")
+
+	muteSuccess = i18n.NewFallbackConfig("plugin.moderation.mute.response.success",
+		"{{.target}} was muted. (Case #{{.case}})")
+	tempMuteSuccess = i18n.NewFallbackConfig("plugin.moderation.mute.response.temp_success",
+		"{{.target}} was muted for {{.duration}}. (Case #{{.case}})")
+
+	unmuteSuccess = i18n.NewFallbackConfig("plugin.moderation.unmute.response.success",
+		"{{.target}} was unmuted. (Case #{{.case}})")
+)
+
+var (
+	reasonSuccess = i18n.NewFallbackConfig("plugin.moderation.reason.response.success",
+		"The reason of case #{{.number}} was updated.")
+
+	historyTitle = i18n.NewFallbackConfig("plugin.moderation.history.response.title",
+		"Cases of {{.target}}")
+	historyEmpty = i18n.NewFallbackConfig("plugin.moderation.history.response.empty",
+		"{{.target}} has no cases.")
+	historyEntry = i18n.NewFallbackConfig("plugin.moderation.history.response.entry",
+		"**#{{.number}}** {{.type}} on {{.date}}: {{.reason}}")
+	historyNoReason = i18n.NewFallbackConfig("plugin.moderation.history.response.no_reason",
+		"*no reason*")
+	historyFooter = i18n.NewFallbackConfig("plugin.moderation.history.response.footer",
+		"Showing {{.shown}} of {{.total}} cases.")
+
+	warnSuccess = i18n.NewFallbackConfig("plugin.moderation.warn.response.success",
+		"{{.target}} was warned and now has {{.warnings}} active warnings. (Case #{{.case}})")
+	warnNotification = i18n.NewFallbackConfig("plugin.moderation.warn.response.notification",
+		"You were warned in **{{.guild}}**: {{.reason}}")
+	escalationSuccess = i18n.NewFallbackConfig("plugin.moderation.warn.response.escalation",
+		"{{.target}} reached {{.warnings}} warnings. Action taken: {{.action}}. (Case #{{.case}})")
+	tempEscalationSuccess = i18n.NewFallbackConfig("plugin.moderation.warn.response.temp_escalation",
+		"{{.target}} reached {{.warnings}} warnings. Action taken: {{.action}} for {{.duration}}. "+
+			"(Case #{{.case}})")
+
+	warningsTitle = i18n.NewFallbackConfig("plugin.moderation.warnings.response.title",
+		"Warnings of {{.target}} ({{.warnings}})")
+	warningsEmpty = i18n.NewFallbackConfig("plugin.moderation.warnings.response.empty",
+		"{{.target}} has no active warnings.")
+	warningsEntry = i18n.NewFallbackConfig("plugin.moderation.warnings.response.entry",
+		"**#{{.id}}** on {{.date}} by {{.moderator}}: {{.reason}}")
+	warningsExpiryFooter = i18n.NewFallbackConfig("plugin.moderation.warnings.response.expiry_footer",
+		"Warnings expire after {{.expiry}}.")
+
+	clearWarnSuccess = i18n.NewFallbackConfig("plugin.moderation.clearwarn.response.success",
+		"Cleared {{.count}} warnings of {{.target}}.")
+	clearWarnSingleSuccess = i18n.NewFallbackConfig("plugin.moderation.clearwarn.response.single_success",
+		"Cleared warning #{{.id}} of {{.target}}.")
+
+	raidModeEnabled = i18n.NewFallbackConfig("plugin.moderation.raidmode.response.enabled",
+		"Raid mode was enabled.")
+	raidModeDisabled = i18n.NewFallbackConfig("plugin.moderation.raidmode.response.disabled",
+		"Raid mode was disabled.")
+	raidModeStatusEnabled = i18n.NewFallbackConfig("plugin.moderation.raidmode.response.status_enabled",
+		"Raid mode is enabled since {{.since}}: {{.reason}}")
+	raidModeStatusDisabled = i18n.NewFallbackConfig("plugin.moderation.raidmode.response.status_disabled",
+		"Raid mode is disabled.")
+
+	purgeSuccess = i18n.NewFallbackConfig("plugin.moderation.purge.response.success",
+		"Deleted {{.count}} messages.")
+	purgeProgressMessage = i18n.NewFallbackConfig("plugin.moderation.purge.response.progress",
+		"Deleting messages... ({{.deleted}}/{{.total}})")
+
+	lockSuccess = i18n.NewFallbackConfig("plugin.moderation.lock.response.success",
+		"{{.channel}} was locked.")
+	lockCategorySuccess = i18n.NewFallbackConfig("plugin.moderation.lock.response.category_success",
+		"Locked {{.count}} channels in {{.category}}.")
+	unlockSuccess = i18n.NewFallbackConfig("plugin.moderation.unlock.response.success",
+		"{{.channel}} was unlocked.")
+	unlockCategorySuccess = i18n.NewFallbackConfig("plugin.moderation.unlock.response.category_success",
+		"Unlocked {{.count}} channels in {{.category}}.")
+
+	lockdownEnabled = i18n.NewFallbackConfig("plugin.moderation.lockdown.response.enabled",
+		"The server is in lockdown. {{.count}} channels were locked.")
+	lockdownDisabled = i18n.NewFallbackConfig("plugin.moderation.lockdown.response.disabled",
+		"The lockdown was lifted. {{.count}} channels were unlocked.")
+	lockdownStatusEnabled = i18n.NewFallbackConfig("plugin.moderation.lockdown.response.status_enabled",
+		"The server is in lockdown, {{.count}} channels are locked.")
+	lockdownStatusDisabled = i18n.NewFallbackConfig("plugin.moderation.lockdown.response.status_disabled",
+		"The server is not in lockdown.")
+
+	storedRolesTitle = i18n.NewFallbackConfig("plugin.moderation.storedroles.response.title",
+		"Stored Roles of {{.target}}")
+	storedRolesRolesField = i18n.NewFallbackConfig("plugin.moderation.storedroles.response.roles_field",
+		"Roles")
+	storedRolesRestoredField = i18n.NewFallbackConfig("plugin.moderation.storedroles.response.restored_field",
+		"Restored on Rejoin")
+	storedRolesFooter = i18n.NewFallbackConfig("plugin.moderation.storedroles.response.footer",
+		"Left on {{.left}} UTC, stored until {{.expires}} UTC.")
+	storedRolesCleared = i18n.NewFallbackConfig("plugin.moderation.storedroles.response.cleared",
+		"Cleared the stored roles of {{.target}}.")
+
+	verificationTitle = i18n.NewFallbackConfig("plugin.moderation.verification.title",
+		"Verification for {{.guild}}")
+	verificationReactionDescription = i18n.NewFallbackConfig("plugin.moderation.verification.reaction_description",
+		"React with {{.emoji}} to this message to get access to the server. "+
+			"If you don't do so within {{.timeout}}, you will be kicked.")
+	verificationCaptchaDescription = &i18n.Config{
+		Term: "plugin.moderation.verification.captcha_description",
+		Fallback: i18n.Fallback{
+			One: "Send the code shown in the image below to get access to the server. " +
+				"You have {{.attempts}} attempt and {{.timeout}} to do so, otherwise you will be kicked.",
+			Other: "Send the code shown in the image below to get access to the server. " +
+				"You have {{.attempts}} attempts and {{.timeout}} to do so, otherwise you will be kicked.",
+		},
+	}
+	verificationWrongCode = &i18n.Config{
+		Term: "plugin.moderation.verification.wrong_code",
+		Fallback: i18n.Fallback{
+			One:   "That code is wrong, you have {{.attempts}} attempt left.",
+			Other: "That code is wrong, you have {{.attempts}} attempts left.",
+		},
+	}
+	verificationPassed = i18n.NewFallbackConfig("plugin.moderation.verification.passed",
+		"You were verified, and now have access to **{{.guild}}**.")
+
+	slowmodeSuccess = i18n.NewFallbackConfig("plugin.moderation.slowmode.response.success",
+		"The slowmode of {{.channel}} was set to {{.duration}}.")
+	slowmodeDisabled = i18n.NewFallbackConfig("plugin.moderation.slowmode.response.disabled",
+		"The slowmode of {{.channel}} was disabled.")
+)
+
+type (
+	targetPlaceholders struct {
+		Target string
+	}
+
+	actionPlaceholders struct {
+		Target string
+		Case   int
+	}
+
+	tempActionPlaceholders struct {
+		Target   string
+		Duration string
+		Case     int
+	}
+
+	warnPlaceholders struct {
+		Target   string
+		Warnings int
+		Case     int
+	}
+
+	warnNotificationPlaceholders struct {
+		Guild  string
+		Reason string
+	}
+
+	escalationPlaceholders struct {
+		Target   string
+		Warnings int
+		Action   string
+		Duration string
+		Case     int
+	}
+
+	warningsTitlePlaceholders struct {
+		Target   string
+		Warnings int
+	}
+
+	warningsEntryPlaceholders struct {
+		ID        int
+		Date      string
+		Moderator string
+		Reason    string
+	}
+
+	warningsExpiryPlaceholders struct {
+		Expiry string
+	}
+
+	warningPlaceholders struct {
+		Target string
+		ID     int
+	}
+
+	raidModeStatusPlaceholders struct {
+		Since  string
+		Reason string
+	}
+
+	purgePlaceholders struct {
+		Count int
+	}
+
+	channelPlaceholders struct {
+		Channel string
+	}
+
+	lockCategoryPlaceholders struct {
+		Category string
+		Count    int
+	}
+
+	lockdownPlaceholders struct {
+		Count int
+	}
+
+	slowmodePlaceholders struct {
+		Channel  string
+		Duration string
+	}
+
+	verificationGuildPlaceholders struct {
+		Guild string
+	}
+
+	verificationReactionPlaceholders struct {
+		Emoji   string
+		Timeout string
+	}
+
+	verificationCaptchaPlaceholders struct {
+		Attempts int
+		Timeout  string
+	}
+
+	verificationWrongCodePlaceholders struct {
+		Attempts int
+	}
+
+	storedRolesFooterPlaceholders struct {
+		Left    string
+		Expires string
+	}
+
+	purgeProgressPlaceholders struct {
+		Deleted int
+		Total   int
+	}
+
+	clearWarnPlaceholders struct {
+		Target string
+		Count  int
+	}
+
+	historyEntryPlaceholders struct {
+		Number int
+		Type   string
+		Date   string
+		Reason string
+	}
+
+	historyFooterPlaceholders struct {
+		Shown int
+		Total int
+	}
+)
+
+// =============================================================================
+// Cases
+// =====================================================================================
+
+var (
+	caseTitle = i18n.NewFallbackConfig("plugin.moderation.case.title", "{{.type}} | Case #{{.number}}")
+
+	caseTypeNames = map[repository.CaseType]*i18n.Config{
+		repository.CaseKick:    i18n.NewFallbackConfig("plugin.moderation.case.type.kick", "Kick"),
+		repository.CaseBan:     i18n.NewFallbackConfig("plugin.moderation.case.type.ban", "Ban"),
+		repository.CaseSoftban: i18n.NewFallbackConfig("plugin.moderation.case.type.softban", "Softban"),
+		repository.CaseUnban:   i18n.NewFallbackConfig("plugin.moderation.case.type.unban", "Unban"),
+		repository.CaseMute:    i18n.NewFallbackConfig("plugin.moderation.case.type.mute", "Mute"),
+		repository.CaseUnmute:  i18n.NewFallbackConfig("plugin.moderation.case.type.unmute", "Unmute"),
+		repository.CaseWarn:    i18n.NewFallbackConfig("plugin.moderation.case.type.warn", "Warning"),
+		repository.CaseQuarantine: i18n.NewFallbackConfig("plugin.moderation.case.type.quarantine",
+			"Quarantine"),
+	}
+
+	caseUserField      = i18n.NewFallbackConfig("plugin.moderation.case.field.user", "User")
+	caseModeratorField = i18n.NewFallbackConfig("plugin.moderation.case.field.moderator", "Moderator")
+	caseDurationField  = i18n.NewFallbackConfig("plugin.moderation.case.field.duration", "Duration")
+
+	caseNoReason = i18n.NewFallbackConfig("plugin.moderation.case.no_reason",
+		"No reason given. Use `reason {{.number}}, <reason>` to add one.")
+
+	// caseReasons are the reasons of actions taken automatically, keyed by
+	// their key.
+	caseReasons = map[string]*i18n.Config{
+		reasonTempBanExpired: i18n.NewFallbackConfig("plugin.moderation.case.reason.temp_ban_expired",
+			"Temporary ban expired"),
+		reasonTempMuteExpired: i18n.NewFallbackConfig("plugin.moderation.case.reason.temp_mute_expired",
+			"Temporary mute expired"),
+		reasonEscalation: i18n.NewFallbackConfig("plugin.moderation.case.reason.escalation",
+			"Automatic escalation after {{.warnings}} warnings"),
+		reasonAutomod: i18n.NewFallbackConfig("plugin.moderation.case.reason.automod", "Automod: {{.filter}}"),
+		reasonRaidMode: i18n.NewFallbackConfig("plugin.moderation.case.reason.raid_mode",
+			"Joined during raid mode"),
+		reasonVerificationTimeout: i18n.NewFallbackConfig("plugin.moderation.case.reason.verification_timeout",
+			"Verification timed out"),
+		reasonVerificationFailed: i18n.NewFallbackConfig("plugin.moderation.case.reason.verification_failed",
+			"Verification failed"),
+	}
+)
+
+type (
+	caseTitlePlaceholders struct {
+		Type   string
+		Number int
+	}
+
+	caseNumberPlaceholders struct {
+		Number int
+	}
+)
+
+// =============================================================================
+// Automod
+// =====================================================================================
+
+var (
+	automodLogTitle = i18n.NewFallbackConfig("plugin.moderation.automod.log.title", "Automod: {{.filter}}")
+
+	automodChannelField = i18n.NewFallbackConfig("plugin.moderation.automod.log.field.channel", "Channel")
+	automodMatchField   = i18n.NewFallbackConfig("plugin.moderation.automod.log.field.match", "Match")
+	automodActionsField = i18n.NewFallbackConfig("plugin.moderation.automod.log.field.actions", "Actions")
+)
+
+type automodLogTitlePlaceholders struct {
+	Filter string
+}
+
+// =============================================================================
+// Purge
+// =====================================================================================
+
+var purgeLogTitle = i18n.NewFallbackConfig("plugin.moderation.purge.log.title",
+	"Purged {{.count}} messages in {{.channel}}")
+
+type purgeLogPlaceholders struct {
+	Count   int
+	Channel string
+}
+
+// =============================================================================
+// Raids
+// =====================================================================================
+
+var (
+	raidEnabledTitle = i18n.NewFallbackConfig("plugin.moderation.raid.alert.enabled_title",
+		"Raid mode enabled")
+	raidDisabledTitle = i18n.NewFallbackConfig("plugin.moderation.raid.alert.disabled_title",
+		"Raid mode disabled")
+
+	raidReasonJoins = i18n.NewFallbackConfig("plugin.moderation.raid.reason.joins",
+		"{{.members}} members joined within {{.interval}}")
+	raidReasonNewAccounts = i18n.NewFallbackConfig("plugin.moderation.raid.reason.new_accounts",
+		"{{.members}} members with new accounts joined within {{.interval}}")
+	raidReasonSimilarUsernames = i18n.NewFallbackConfig("plugin.moderation.raid.reason.similar_usernames",
+		"{{.members}} members with similar usernames joined within {{.interval}}")
+	raidReasonSameAvatar = i18n.NewFallbackConfig("plugin.moderation.raid.reason.same_avatar",
+		"{{.members}} members with the same avatar joined within {{.interval}}")
+	raidReasonCooldown = i18n.NewFallbackConfig("plugin.moderation.raid.reason.cooldown",
+		"Cool-down expired")
+	raidReasonEnabledBy = i18n.NewFallbackConfig("plugin.moderation.raid.reason.enabled_by",
+		"Enabled by {{.user}}")
+	raidReasonDisabledBy = i18n.NewFallbackConfig("plugin.moderation.raid.reason.disabled_by",
+		"Disabled by {{.user}}")
+)
+
+type (
+	raidReasonPlaceholders struct {
+		Members  int
+		Interval string
+	}
+
+	raidReasonUserPlaceholders struct {
+		User string
+	}
+)
+
+// =============================================================================
+// Errors
+// =====================================================================================
+
+var (
+	selfTargetError = i18n.NewFallbackConfig("plugin.moderation.error.self_target",
+		"You can't take action against yourself.")
+	botTargetError = i18n.NewFallbackConfig("plugin.moderation.error.bot_target",
+		"I can't take action against myself.")
+
+	moderatorHierarchyError = i18n.NewFallbackConfig("plugin.moderation.error.moderator_hierarchy",
+		"You can't take action against {{.target}}, because their highest role is not below yours.")
+	botHierarchyError = i18n.NewFallbackConfig("plugin.moderation.error.bot_hierarchy",
+		"I can't take action against {{.target}}, because their highest role is not below mine.")
+
+	noMuteRoleError = i18n.NewFallbackConfig("plugin.moderation.error.no_mute_role",
+		"This server has no mute role. Create a role named `{{.name}}` to use mutes.")
+	muteRoleHierarchyError = i18n.NewFallbackConfig("plugin.moderation.error.mute_role_hierarchy",
+		"I can't assign the mute role {{.name}}, because it is not below my highest role.")
+
+	notBannedError = i18n.NewFallbackConfig("plugin.moderation.error.not_banned",
+		"{{.target}} is not banned.")
+	notMutedError = i18n.NewFallbackConfig("plugin.moderation.error.not_muted",
+		"{{.target}} is not muted.")
+
+	caseNotFoundError = i18n.NewFallbackConfig("plugin.moderation.error.case_not_found",
+		"There is no case #{{.number}}.")
+
+	noWarningsError = i18n.NewFallbackConfig("plugin.moderation.error.no_warnings",
+		"{{.target}} has no warnings.")
+	warningNotFoundError = i18n.NewFallbackConfig("plugin.moderation.error.warning_not_found",
+		"{{.target}} has no warning #{{.id}}.")
+
+	raidModeAlreadyEnabledError = i18n.NewFallbackConfig("plugin.moderation.error.raid_mode_already_enabled",
+		"Raid mode is already enabled.")
+	raidModeNotEnabledError = i18n.NewFallbackConfig("plugin.moderation.error.raid_mode_not_enabled",
+		"Raid mode is not enabled.")
+
+	noPurgeableMessagesError = i18n.NewFallbackConfig("plugin.moderation.error.no_purgeable_messages",
+		"There are no messages matching the filters.")
+
+	invalidLockChannelError = i18n.NewFallbackConfig("plugin.moderation.error.invalid_lock_channel",
+		"`{{.raw}}` is not a text channel or category of this server.")
+	alreadyLockedError = i18n.NewFallbackConfig("plugin.moderation.error.already_locked",
+		"{{.channel}} is already locked.")
+	notLockedError = i18n.NewFallbackConfig("plugin.moderation.error.not_locked",
+		"{{.channel}} is not locked.")
+	lockdownAlreadyEnabledError = i18n.NewFallbackConfig("plugin.moderation.error.lockdown_already_enabled",
+		"The server is already in lockdown.")
+	lockdownNotEnabledError = i18n.NewFallbackConfig("plugin.moderation.error.lockdown_not_enabled",
+		"The server is not in lockdown.")
+
+	noStoredRolesError = i18n.NewFallbackConfig("plugin.moderation.error.no_stored_roles",
+		"There are no stored roles of {{.target}}.")
+)
+
+type (
+	muteRolePlaceholders struct {
+		Name string
+	}
+
+	invalidLockChannelPlaceholders struct {
+		Raw string
+	}
+)
@@ -0,0 +1,597 @@
+package moderation
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/sendpart"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+	"go.uber.org/zap"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// The challenges new members can be given.
+const (
+	challengeReaction = "reaction"
+	// challengeButton is accepted, but replaced with challengeReaction, as
+	// the Discord libraries in use neither support sending message
+	// components nor receive the interactions created by them.
+	challengeButton  = "button"
+	challengeCaptcha = "captcha"
+)
+
+const (
+	// defaultVerificationTimeout is the timeout used, if a guild's
+	// config.VerificationConfig has none.
+	defaultVerificationTimeout = 10 * time.Minute
+	// defaultCaptchaAttempts is the number of attempts used, if a guild's
+	// config.VerificationConfig has none.
+	defaultCaptchaAttempts = 3
+	// verificationEmoji is the emoji members react with to pass a reaction
+	// challenge.
+	verificationEmoji = "✅"
+	// wrongCodeLifetime is the time after which the notice about a wrong
+	// captcha code is deleted from the verification channel.
+	wrongCodeLifetime = 10 * time.Second
+)
+
+type (
+	// VerificationGate gives new members an unverified role, and challenges
+	// them to verify, as configured in config.C.Moderation.Verification.
+	// Members that pass the challenge are verified, members that fail it or
+	// don't pass it in time are kicked.
+	//
+	// Since pending verifications are stored in the repository, members
+	// that didn't pass the challenge while levin was offline are kicked as
+	// soon as the VerificationGate is started.
+	VerificationGate struct {
+		s         *state.State
+		repo      *repository.Repository
+		raids     *RaidGuard
+		cases     *CaseLog
+		localizer LocalizerFunc
+
+		mutex   sync.Mutex
+		pending map[verificationKey]*pendingVerification
+	}
+
+	verificationKey struct {
+		guildID discord.GuildID
+		userID  discord.UserID
+	}
+
+	pendingVerification struct {
+		repository.Verification
+		timeout *time.Timer
+	}
+)
+
+// NewVerificationGate creates a new *VerificationGate, that stores pending
+// verifications in the passed repository.
+// During raid mode, as reported by the passed *RaidGuard, the raid
+// challenge of a guild is used.
+// Kicks of members that fail their verification are recorded using the
+// passed *CaseLog, and the passed LocalizerFunc is used to localize
+// challenges.
+//
+// It returns an error, if the verification config of a guild is invalid.
+func NewVerificationGate(
+	s *state.State, repo *repository.Repository, rg *RaidGuard, cl *CaseLog, localizer LocalizerFunc,
+) (*VerificationGate, error) {
+	for guildID, cfg := range config.C.Moderation.Verification {
+		if !cfg.UnverifiedRole.IsValid() {
+			return nil, errors.NewWithStackf("verification of guild %d has no unverified role", guildID)
+		}
+
+		for _, c := range []string{cfg.Challenge, cfg.RaidChallenge} {
+			if err := checkChallenge(c); err != nil {
+				return nil, errors.Wrapf(err, "invalid verification challenge of guild %d", guildID)
+			}
+
+			if strings.EqualFold(c, challengeButton) {
+				zap.S().Named("verification").With("guild_id", guildID).
+					Warn("button challenges are not supported yet, using reaction challenges instead")
+			}
+		}
+	}
+
+	return &VerificationGate{
+		s:         s,
+		repo:      repo,
+		raids:     rg,
+		cases:     cl,
+		localizer: localizer,
+		pending:   make(map[verificationKey]*pendingVerification),
+	}, nil
+}
+
+// checkChallenge checks if the passed challenge is supported.
+func checkChallenge(c string) error {
+	switch strings.ToLower(c) {
+	case "", challengeReaction, challengeButton, challengeCaptcha:
+		return nil
+	default:
+		return errors.NewWithStackf("unknown challenge %q", c)
+	}
+}
+
+func verificationLog(guildID discord.GuildID, userID discord.UserID) *zap.SugaredLogger {
+	return zap.S().Named("verification").With("guild_id", guildID, "user_id", userID)
+}
+
+// Start restores the verifications that were pending when levin was stopped.
+// Members whose verification timed out while levin was offline are kicked
+// immediately.
+func (g *VerificationGate) Start() error {
+	vs, err := g.repo.Verifications()
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, v := range vs {
+		g.schedule(v)
+	}
+
+	return nil
+}
+
+// schedule tracks the passed verification, and kicks the member once it
+// times out.
+// The caller must hold the mutex.
+func (g *VerificationGate) schedule(v repository.Verification) {
+	key := verificationKey{guildID: v.GuildID, userID: v.UserID}
+
+	if pv, ok := g.pending[key]; ok {
+		pv.timeout.Stop()
+	}
+
+	g.pending[key] = &pendingVerification{
+		Verification: v,
+		timeout: time.AfterFunc(time.Until(v.Expires), func() {
+			g.fail(v.GuildID, v.UserID, autoReason{key: reasonVerificationTimeout})
+		}),
+	}
+}
+
+// remove stops tracking the verification of the user with the passed id, and
+// deletes it.
+// It returns the verification, or false, if there was none.
+func (g *VerificationGate) remove(
+	guildID discord.GuildID, userID discord.UserID,
+) (repository.Verification, bool) {
+	key := verificationKey{guildID: guildID, userID: userID}
+
+	g.mutex.Lock()
+
+	pv, ok := g.pending[key]
+	if !ok {
+		g.mutex.Unlock()
+		return repository.Verification{}, false
+	}
+
+	pv.timeout.Stop()
+	delete(g.pending, key)
+
+	g.mutex.Unlock()
+
+	if err := g.repo.DeleteVerification(guildID, userID); err != nil {
+		verificationLog(guildID, userID).With("err", err).
+			Error("unable to delete verification")
+	}
+
+	return pv.Verification, true
+}
+
+// find returns the verification whose challenge was sent in the channel with
+// the passed id, and that belongs to the user with the passed id.
+func (g *VerificationGate) find(
+	channelID discord.ChannelID, userID discord.UserID,
+) (repository.Verification, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	// a user can have pending verifications in multiple guilds, but they
+	// share the same DM channel, which is why the channel must be searched
+	for key, pv := range g.pending {
+		if key.userID == userID && pv.ChannelID == channelID {
+			return pv.Verification, true
+		}
+	}
+
+	return repository.Verification{}, false
+}
+
+// Handlers returns the handlers challenging new members, and tracking their
+// verification.
+func (g *VerificationGate) Handlers() []interface{} {
+	return []interface{}{g.memberAdd, g.memberRemove, g.reactionAdd}
+}
+
+// Middleware returns the message create middleware, that checks the codes
+// sent by members with a captcha challenge.
+// Codes are not routed.
+func (g *VerificationGate) Middleware() func(*state.State, *state.MessageCreateEvent) error {
+	return func(_ *state.State, e *state.MessageCreateEvent) error {
+		if e.Author.Bot || e.WebhookID.IsValid() {
+			return nil
+		}
+
+		v, ok := g.find(e.ChannelID, e.Author.ID)
+		if !ok || v.Challenge != challengeCaptcha {
+			return nil
+		}
+
+		// keep the verification channel clean
+		if e.GuildID.IsValid() {
+			_ = g.s.DeleteMessage(e.ChannelID, e.ID)
+		}
+
+		code := strings.ToUpper(strings.ReplaceAll(e.Content, " ", ""))
+		if code == v.Code {
+			g.pass(v.GuildID, v.UserID)
+			return state.Filtered
+		}
+
+		g.wrongCode(v.GuildID, v.UserID)
+		return state.Filtered
+	}
+}
+
+func (g *VerificationGate) memberAdd(_ *state.State, e *state.GuildMemberAddEvent) {
+	cfg, ok := config.C.Moderation.Verification[e.GuildID]
+	if !ok || e.User.Bot {
+		return
+	}
+
+	log := verificationLog(e.GuildID, e.User.ID)
+
+	challenge := cfg.Challenge
+
+	if g.raids.Active(e.GuildID) {
+		// the member is kicked anyway
+		if strings.EqualFold(config.C.Moderation.Raids[e.GuildID].Action, raidActionKick) {
+			return
+		}
+
+		if len(cfg.RaidChallenge) > 0 {
+			challenge = cfg.RaidChallenge
+		}
+	}
+
+	challenge = strings.ToLower(challenge)
+	if len(challenge) == 0 || challenge == challengeButton {
+		challenge = challengeReaction
+	}
+
+	if err := addRole(g.s, e.GuildID, e.User.ID, cfg.UnverifiedRole, "Verification"); err != nil {
+		log.With("err", err).
+			Error("unable to add unverified role")
+		return
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultVerificationTimeout
+	}
+
+	v := repository.Verification{
+		GuildID:   e.GuildID,
+		UserID:    e.User.ID,
+		Challenge: challenge,
+		ChannelID: cfg.Channel,
+		Expires:   time.Now().Add(timeout),
+	}
+
+	if challenge == challengeCaptcha {
+		code, err := newCaptchaCode()
+		if err != nil {
+			log.With("err", err).
+				Error("unable to generate captcha code")
+			return
+		}
+
+		v.Code = code
+
+		v.Attempts = cfg.Attempts
+		if v.Attempts <= 0 {
+			v.Attempts = defaultCaptchaAttempts
+		}
+	}
+
+	if !v.ChannelID.IsValid() {
+		dm, err := g.s.CreatePrivateChannel(e.User.ID)
+		if err != nil {
+			log.With("err", err).
+				Warn("unable to create DM channel for verification")
+		} else {
+			v.ChannelID = dm.ID
+		}
+	}
+
+	// if the challenge can't be sent, the member is kicked once the
+	// verification times out, just like if they didn't pass it
+	if v.ChannelID.IsValid() {
+		msgID, err := g.sendChallenge(v, timeout)
+		if err != nil {
+			log.With("err", err, "channel_id", v.ChannelID).
+				Warn("unable to send verification challenge")
+		} else {
+			v.MessageID = msgID
+		}
+	}
+
+	if err := g.repo.PutVerification(v); err != nil {
+		log.With("err", err).
+			Error("unable to store verification")
+	}
+
+	g.mutex.Lock()
+	g.schedule(v)
+	g.mutex.Unlock()
+
+	log.With("challenge", challenge).
+		Info("challenged new member")
+}
+
+func (g *VerificationGate) memberRemove(_ *state.State, e *state.GuildMemberRemoveEvent) {
+	if v, ok := g.remove(e.GuildID, e.User.ID); ok {
+		g.deleteChallenge(v)
+	}
+}
+
+func (g *VerificationGate) reactionAdd(_ *state.State, e *state.MessageReactionAddEvent) {
+	if e.Emoji.Name != verificationEmoji {
+		return
+	}
+
+	v, ok := g.find(e.ChannelID, e.UserID)
+	if !ok || v.Challenge != challengeReaction || v.MessageID != e.MessageID {
+		return
+	}
+
+	g.pass(v.GuildID, v.UserID)
+}
+
+// sendChallenge sends the challenge of the passed verification, and returns
+// the id of the challenge message.
+func (g *VerificationGate) sendChallenge(v repository.Verification, timeout time.Duration) (discord.MessageID, error) {
+	l := g.localizer(v.GuildID)
+
+	title, err := l.Localize(verificationTitle.WithPlaceholders(verificationGuildPlaceholders{
+		Guild: guildName(g.s, v.GuildID),
+	}))
+	if err != nil {
+		return 0, err
+	}
+
+	data := api.SendMessageData{
+		Content: v.UserID.Mention(),
+		Embed: &discord.Embed{
+			Title:     title,
+			Color:     0x3498db,
+			Timestamp: discord.NowTimestamp(),
+		},
+		AllowedMentions: &api.AllowedMentions{
+			Parse: []api.AllowedMentionType{},
+			Users: []discord.UserID{v.UserID},
+		},
+	}
+
+	var description *i18n.Config
+
+	switch v.Challenge {
+	case challengeReaction:
+		description = verificationReactionDescription.WithPlaceholders(verificationReactionPlaceholders{
+			Emoji:   verificationEmoji,
+			Timeout: duration.Format(timeout),
+		})
+	case challengeCaptcha:
+		description = verificationCaptchaDescription.WithPlaceholders(verificationCaptchaPlaceholders{
+			Attempts: v.Attempts,
+			Timeout:  duration.Format(timeout),
+		}).WithPlural(v.Attempts)
+
+		img, err := renderCaptcha(v.Code)
+		if err != nil {
+			return 0, err
+		}
+
+		data.Embed.Image = &discord.EmbedImage{URL: "attachment://captcha.png"}
+		data.Files = []sendpart.File{{Name: "captcha.png", Reader: bytes.NewReader(img)}}
+	}
+
+	if data.Embed.Description, err = l.Localize(description); err != nil {
+		return 0, err
+	}
+
+	msg, err := g.s.SendMessageComplex(v.ChannelID, data)
+	if err != nil {
+		return 0, err
+	}
+
+	if v.Challenge == challengeReaction {
+		if err = g.s.React(v.ChannelID, msg.ID, verificationEmoji); err != nil {
+			return 0, err
+		}
+	}
+
+	return msg.ID, nil
+}
+
+// pass verifies the member with the passed id.
+func (g *VerificationGate) pass(guildID discord.GuildID, userID discord.UserID) {
+	v, ok := g.remove(guildID, userID)
+	if !ok {
+		return
+	}
+
+	log := verificationLog(guildID, userID)
+	cfg := config.C.Moderation.Verification[guildID]
+
+	if err := removeRole(g.s, guildID, userID, cfg.UnverifiedRole, "Verification passed"); err != nil {
+		log.With("err", err).
+			Error("unable to remove unverified role")
+	}
+
+	if cfg.VerifiedRole.IsValid() {
+		if err := addRole(g.s, guildID, userID, cfg.VerifiedRole, "Verification passed"); err != nil {
+			log.With("err", err).
+				Error("unable to add verified role")
+		}
+	}
+
+	g.deleteChallenge(v)
+
+	// in the verification channel, the member sees that they were verified
+	// through the channels they gained access to
+	if v.ChannelID != cfg.Channel {
+		g.notify(v, verificationPassed.WithPlaceholders(verificationGuildPlaceholders{
+			Guild: guildName(g.s, guildID),
+		}))
+	}
+
+	log.Info("member passed verification")
+}
+
+// fail kicks the member with the passed id, and records the kick as a case.
+func (g *VerificationGate) fail(guildID discord.GuildID, userID discord.UserID, reason autoReason) {
+	v, ok := g.remove(guildID, userID)
+	if !ok {
+		return
+	}
+
+	log := verificationLog(guildID, userID).With("reason", reason.key)
+
+	g.deleteChallenge(v)
+
+	if err := kick(g.s, guildID, userID, reason.String()); err != nil {
+		log.With("err", err).
+			Error("unable to kick member that failed verification")
+		return
+	}
+
+	log.Info("kicked member that failed verification")
+
+	me, err := g.s.Me()
+	if err != nil {
+		log.With("err", err).
+			Error("unable to get self to record failed verification")
+		return
+	}
+
+	c := &repository.Case{
+		GuildID:     guildID,
+		Type:        repository.CaseKick,
+		UserID:      userID,
+		ModeratorID: me.ID,
+	}
+	reason.apply(c)
+
+	if err := g.cases.Record(c); err != nil {
+		log.With("err", err).
+			Error("unable to record failed verification")
+		sentry.CaptureException(err)
+	}
+}
+
+// wrongCode uses up an attempt of the captcha verification of the user with
+// the passed id, and kicks the member, if they have no attempts left.
+func (g *VerificationGate) wrongCode(guildID discord.GuildID, userID discord.UserID) {
+	g.mutex.Lock()
+
+	pv, ok := g.pending[verificationKey{guildID: guildID, userID: userID}]
+	if !ok {
+		g.mutex.Unlock()
+		return
+	}
+
+	// decrement the pending verification itself, so that concurrent wrong
+	// codes each use up an attempt
+	pv.Attempts--
+	v := pv.Verification
+
+	g.mutex.Unlock()
+
+	if v.Attempts <= 0 {
+		g.fail(guildID, userID, autoReason{key: reasonVerificationFailed})
+		return
+	}
+
+	if err := g.repo.PutVerification(v); err != nil {
+		verificationLog(v.GuildID, v.UserID).With("err", err).
+			Error("unable to store verification")
+	}
+
+	msg := g.notify(v, verificationWrongCode.WithPlaceholders(verificationWrongCodePlaceholders{
+		Attempts: v.Attempts,
+	}).WithPlural(v.Attempts))
+
+	// keep the verification channel clean
+	if msg != nil && v.ChannelID == config.C.Moderation.Verification[v.GuildID].Channel {
+		time.AfterFunc(wrongCodeLifetime, func() { _ = g.s.DeleteMessage(msg.ChannelID, msg.ID) })
+	}
+}
+
+// notify sends the passed message to the member of the passed verification,
+// in the channel the challenge was sent in.
+// It returns the sent message, or nil, if it couldn't be sent.
+func (g *VerificationGate) notify(v repository.Verification, c *i18n.Config) *discord.Message {
+	log := verificationLog(v.GuildID, v.UserID)
+
+	text, err := g.localizer(v.GuildID).Localize(c)
+	if err != nil {
+		log.With("err", err).
+			Error("unable to localize verification message")
+		return nil
+	}
+
+	msg, err := g.s.SendMessageComplex(v.ChannelID, api.SendMessageData{
+		Content: v.UserID.Mention() + " " + text,
+		AllowedMentions: &api.AllowedMentions{
+			Parse: []api.AllowedMentionType{},
+			Users: []discord.UserID{v.UserID},
+		},
+	})
+	if err != nil {
+		log.With("err", err).
+			Warn("unable to send verification message")
+		return nil
+	}
+
+	return msg
+}
+
+// deleteChallenge deletes the challenge message of the passed verification,
+// if it was sent in the verification channel.
+// Challenges sent via DM are kept, so that members know what happened.
+func (g *VerificationGate) deleteChallenge(v repository.Verification) {
+	if !v.MessageID.IsValid() || v.ChannelID != config.C.Moderation.Verification[v.GuildID].Channel {
+		return
+	}
+
+	_ = g.s.DeleteMessage(v.ChannelID, v.MessageID)
+}
+
+// guildName returns the name of the guild with the passed id, or its id, if
+// the guild can't be retrieved.
+func guildName(s *state.State, guildID discord.GuildID) string {
+	g, err := s.Guild(guildID)
+	if err != nil {
+		return guildID.String()
+	}
+
+	return g.Name
+}
@@ -0,0 +1,395 @@
+package moderation
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/mavolin/adam/pkg/errors"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/impl/arg"
+	"github.com/mavolin/adam/pkg/impl/command"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/adam/pkg/utils/duration"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/discordutil"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// activeWarnings returns the warnings that haven't expired under the passed
+// policy.
+func activeWarnings(ws []repository.Warning, p config.WarningPolicy) []repository.Warning {
+	if p.Expiry <= 0 {
+		return ws
+	}
+
+	active := make([]repository.Warning, 0, len(ws))
+
+	for _, w := range ws {
+		if time.Since(w.Time) < p.Expiry {
+			active = append(active, w)
+		}
+	}
+
+	return active
+}
+
+// matchEscalation returns the escalation requiring the most warnings, that
+// was just reached by the passed active warnings, and the number of warnings
+// counted for it.
+// An escalation is only reached, if the warnings counted for it equal its
+// number of warnings, so that it isn't taken again for every further
+// warning.
+// If no escalation is reached, matchEscalation returns nil.
+func matchEscalation(active []repository.Warning, p config.WarningPolicy) (match *config.Escalation, n int) {
+	for i, e := range p.Escalations {
+		count := len(active)
+
+		if e.Period > 0 {
+			count = 0
+
+			for _, w := range active {
+				if time.Since(w.Time) < e.Period {
+					count++
+				}
+			}
+		}
+
+		if count == e.Warnings && (match == nil || e.Warnings > match.Warnings) {
+			match, n = &p.Escalations[i], count
+		}
+	}
+
+	return match, n
+}
+
+// =============================================================================
+// Warn
+// =====================================================================================
+
+// Warn is the warn command.
+type Warn struct {
+	command.LocalizedMeta
+	expirer *Expirer
+	cases   *CaseLog
+}
+
+var _ plugin.Command = new(Warn) // compile-time check
+
+func newWarn(e *Expirer, cl *CaseLog) *Warn {
+	return &Warn{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "warn",
+			ShortDescription: warnShortDescription,
+			LongDescription:  warnLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{
+					{Name: memberArgName, Type: arg.Member},
+					{Name: reasonArgName, Type: arg.SimpleText, Description: warnReasonArgDescription},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages,
+			Restrictions:   moderatorRestriction,
+		},
+		expirer: e,
+		cases:   cl,
+	}
+}
+
+func (w *Warn) Invoke(s *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.Member(0)
+	reason := ctx.Args.String(1)
+
+	if err := checkHierarchy(s, ctx, target); err != nil {
+		return nil, err
+	}
+
+	c, err := w.cases.recordInvoke(ctx, repository.CaseWarn, target.User.ID, reason, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := addWarning(w.cases.repo, *c)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	notifyWarning(s, ctx.Localizer, ctx.GuildID, target.User.ID, reason)
+
+	_, err = ctx.Replyl(warnSuccess.WithPlaceholders(warnPlaceholders{
+		Target:   discordutil.UserTag(target.User),
+		Warnings: len(active),
+		Case:     c.Number,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	e, n := matchEscalation(active, config.C.Moderation.Warnings[ctx.GuildID])
+	if e == nil {
+		return nil, nil
+	}
+
+	self, err := ctx.Self()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ec, err := escalate(s, w.expirer, w.cases, *self, target.User.ID, ctx.GuildID, *e, n)
+	if err != nil || ec == nil {
+		return nil, err
+	}
+
+	action, err := ctx.Localize(caseTypeNames[ec.Type])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if ec.Duration == 0 {
+		return escalationSuccess.WithPlaceholders(escalationPlaceholders{
+			Target:   discordutil.UserTag(target.User),
+			Warnings: n,
+			Action:   action,
+			Case:     ec.Number,
+		}), nil
+	}
+
+	return tempEscalationSuccess.WithPlaceholders(escalationPlaceholders{
+		Target:   discordutil.UserTag(target.User),
+		Warnings: n,
+		Action:   action,
+		Duration: duration.Format(ec.Duration),
+		Case:     ec.Number,
+	}), nil
+}
+
+// addWarning stores a warning for the passed warn case, and returns the
+// active warnings of the warned user.
+func addWarning(repo *repository.Repository, c repository.Case) ([]repository.Warning, error) {
+	err := repo.AddWarning(&repository.Warning{
+		GuildID:            c.GuildID,
+		UserID:             c.UserID,
+		ModeratorID:        c.ModeratorID,
+		Reason:             c.Reason,
+		ReasonKey:          c.ReasonKey,
+		ReasonPlaceholders: c.ReasonPlaceholders,
+		Time:               c.Time,
+		Case:               c.Number,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := repo.Warnings(c.GuildID, c.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return activeWarnings(ws, config.C.Moderation.Warnings[c.GuildID]), nil
+}
+
+// notifyWarning tells the warned user about their warning.
+func notifyWarning(
+	s *state.State, l *i18n.Localizer, guildID discord.GuildID, userID discord.UserID, reason string,
+) {
+	g, err := s.Guild(guildID)
+	if err != nil {
+		return
+	}
+
+	msg, err := l.Localize(warnNotification.WithPlaceholders(warnNotificationPlaceholders{
+		Guild:  g.Name,
+		Reason: reason,
+	}))
+	if err != nil {
+		return
+	}
+
+	dm, err := s.CreatePrivateChannel(userID)
+	if err != nil {
+		return
+	}
+
+	// the user might not accept DMs, which is nothing we need to handle
+	_, _ = s.SendText(dm.ID, msg)
+}
+
+// escalate takes the action of the passed escalation, which was reached
+// with n warnings.
+// If the escalation's action is unknown, escalate returns a nil case.
+func escalate(
+	s *state.State, e *Expirer, cl *CaseLog, self discord.Member, userID discord.UserID, guildID discord.GuildID,
+	esc config.Escalation, n int,
+) (*repository.Case, error) {
+	reason := autoReason{key: reasonEscalation, placeholders: map[string]string{"warnings": strconv.Itoa(n)}}
+	return punish(s, e, cl, self, userID, guildID, esc.Action, esc.Duration, reason)
+}
+
+// =============================================================================
+// Warnings
+// =====================================================================================
+
+// maxWarningEntries is the maximum number of warnings shown by the warnings
+// command.
+const maxWarningEntries = 15
+
+// Warnings is the warnings command.
+type Warnings struct {
+	command.LocalizedMeta
+	cases *CaseLog
+}
+
+var _ plugin.Command = new(Warnings) // compile-time check
+
+func newWarnings(cl *CaseLog) *Warnings {
+	return &Warnings{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "warnings",
+			ShortDescription: warningsShortDescription,
+			LongDescription:  warningsLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages | discord.PermissionEmbedLinks,
+			Restrictions:   moderatorRestriction,
+		},
+		cases: cl,
+	}
+}
+
+func (w *Warnings) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+
+	ws, err := w.cases.repo.Warnings(ctx.GuildID, target.ID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	policy := config.C.Moderation.Warnings[ctx.GuildID]
+	active := activeWarnings(ws, policy)
+
+	if len(active) == 0 {
+		return warningsEmpty.WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}), nil
+	}
+
+	title, err := ctx.Localize(warningsTitle.WithPlaceholders(warningsTitlePlaceholders{
+		Target:   discordutil.UserTag(*target),
+		Warnings: len(active),
+	}))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	shown := active
+	if len(shown) > maxWarningEntries {
+		shown = shown[len(shown)-maxWarningEntries:]
+	}
+
+	entries := make([]string, 0, len(shown))
+
+	// most recent first
+	for i := len(shown) - 1; i >= 0; i-- {
+		w := shown[i]
+
+		reason, err := localizeReason(ctx.Localizer, w.Reason, w.ReasonKey, w.ReasonPlaceholders)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		entry, err := ctx.Localize(warningsEntry.WithPlaceholders(warningsEntryPlaceholders{
+			ID:        w.ID,
+			Date:      w.Time.UTC().Format("2006-01-02"),
+			Moderator: w.ModeratorID.Mention(),
+			Reason:    discordutil.Truncate(reason, maxHistoryReasonLength),
+		}))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	e := discord.Embed{
+		Title:       title,
+		Description: strings.Join(entries, "\n"),
+	}
+
+	if policy.Expiry > 0 {
+		footer, err := ctx.Localize(warningsExpiryFooter.WithPlaceholders(warningsExpiryPlaceholders{
+			Expiry: duration.Format(policy.Expiry),
+		}))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		e.Footer = &discord.EmbedFooter{Text: footer}
+	}
+
+	return e, nil
+}
+
+// =============================================================================
+// ClearWarn
+// =====================================================================================
+
+// ClearWarn is the clearwarn command.
+type ClearWarn struct {
+	command.LocalizedMeta
+	repo *repository.Repository
+}
+
+var _ plugin.Command = new(ClearWarn) // compile-time check
+
+func newClearWarn(repo *repository.Repository) *ClearWarn {
+	return &ClearWarn{
+		LocalizedMeta: command.LocalizedMeta{
+			Name:             "clearwarn",
+			Aliases:          []string{"clearwarnings"},
+			ShortDescription: clearWarnShortDescription,
+			LongDescription:  clearWarnLongDescription,
+			Args: arg.LocalizedCommaConfig{
+				Required: []arg.LocalizedRequiredArg{{Name: userArgName, Type: arg.User}},
+				Optional: []arg.LocalizedOptionalArg{
+					{Name: warningArgName, Type: arg.IntegerWithMin(1), Description: warningArgDescription},
+				},
+			},
+			ChannelTypes:   plugin.GuildChannels,
+			BotPermissions: discord.PermissionSendMessages,
+			Restrictions:   moderatorRestriction,
+		},
+		repo: repo,
+	}
+}
+
+func (cw *ClearWarn) Invoke(_ *state.State, ctx *plugin.Context) (interface{}, error) {
+	target := ctx.Args.User(0)
+
+	// warning ids start at 1, so 0 means no id was passed
+	if id := ctx.Args.Int(1); id > 0 {
+		err := cw.repo.DeleteWarning(ctx.GuildID, target.ID, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.NewUserErrorl(warningNotFoundError.
+				WithPlaceholders(warningPlaceholders{Target: discordutil.UserTag(*target), ID: id}))
+		} else if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return clearWarnSingleSuccess.
+			WithPlaceholders(warningPlaceholders{Target: discordutil.UserTag(*target), ID: id}), nil
+	}
+
+	n, err := cw.repo.ClearWarnings(ctx.GuildID, target.ID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if n == 0 {
+		return nil, errors.NewUserErrorl(noWarningsError.
+			WithPlaceholders(targetPlaceholders{Target: discordutil.UserTag(*target)}))
+	}
+
+	return clearWarnSuccess.WithPlaceholders(clearWarnPlaceholders{Target: discordutil.UserTag(*target), Count: n}), nil
+}
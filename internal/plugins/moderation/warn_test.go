@@ -0,0 +1,151 @@
+package moderation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mavolin/levin/internal/config"
+	"github.com/mavolin/levin/internal/repository"
+)
+
+// warningsAgo returns warnings issued the passed durations ago, numbered by
+// their case, starting at 1.
+func warningsAgo(ago ...time.Duration) []repository.Warning {
+	ws := make([]repository.Warning, len(ago))
+	for i, d := range ago {
+		ws[i] = repository.Warning{Case: i + 1, Time: time.Now().Add(-d)}
+	}
+
+	return ws
+}
+
+func TestActiveWarnings(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ws     []repository.Warning
+		policy config.WarningPolicy
+		expect []int // the cases of the active warnings
+	}{
+		{
+			name:   "no expiry",
+			ws:     warningsAgo(365*24*time.Hour, time.Hour),
+			policy: config.WarningPolicy{},
+			expect: []int{1, 2},
+		},
+		{
+			name:   "expired",
+			ws:     warningsAgo(48*time.Hour, 25*time.Hour, time.Hour),
+			policy: config.WarningPolicy{Expiry: 24 * time.Hour},
+			expect: []int{3},
+		},
+		{
+			name:   "all expired",
+			ws:     warningsAgo(48 * time.Hour),
+			policy: config.WarningPolicy{Expiry: 24 * time.Hour},
+			expect: []int{},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			actual := activeWarnings(c.ws, c.policy)
+			if len(actual) != len(c.expect) {
+				t.Fatalf("expected %d active warnings, but got %d", len(c.expect), len(actual))
+			}
+
+			for i, w := range actual {
+				if w.Case != c.expect[i] {
+					t.Errorf("expected warning %d to be case %d, but got case %d", i, c.expect[i], w.Case)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchEscalation(t *testing.T) {
+	policy := config.WarningPolicy{
+		Escalations: []config.Escalation{
+			{Warnings: 2, Action: "mute", Duration: time.Hour},
+			{Warnings: 3, Period: 24 * time.Hour, Action: "kick"},
+			{Warnings: 5, Action: "ban"},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		active       []repository.Warning
+		expectAction string // empty if no escalation is expected
+		expectN      int
+	}{
+		{
+			name:         "below threshold",
+			active:       warningsAgo(time.Hour),
+			expectAction: "",
+		},
+		{
+			name:         "threshold reached",
+			active:       warningsAgo(time.Hour, time.Minute),
+			expectAction: "mute",
+			expectN:      2,
+		},
+		{
+			name:         "highest escalation reached",
+			active:       warningsAgo(time.Hour, time.Hour, time.Minute),
+			expectAction: "kick",
+			expectN:      3,
+		},
+		{
+			name:         "period not reached",
+			active:       warningsAgo(48*time.Hour, 48*time.Hour, time.Minute),
+			expectAction: "",
+		},
+		{
+			name:         "only warnings in period counted",
+			active:       warningsAgo(48*time.Hour, time.Hour, time.Hour, time.Minute),
+			expectAction: "kick",
+			expectN:      3,
+		},
+		{
+			name:         "threshold exceeded",
+			active:       warningsAgo(48*time.Hour, 48*time.Hour, 48*time.Hour, time.Minute),
+			expectAction: "",
+		},
+		{
+			name:         "every threshold exceeded",
+			active:       warningsAgo(time.Hour, time.Hour, time.Hour, time.Hour, time.Hour, time.Minute),
+			expectAction: "",
+		},
+		{
+			name:         "threshold without period reached",
+			active:       warningsAgo(48*time.Hour, 48*time.Hour, 48*time.Hour, 48*time.Hour, time.Minute),
+			expectAction: "ban",
+			expectN:      5,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			e, n := matchEscalation(c.active, policy)
+			if c.expectAction == "" {
+				if e != nil {
+					t.Fatalf("expected no escalation, but got %+v", *e)
+				}
+
+				return
+			}
+
+			if e == nil {
+				t.Fatalf("expected %s escalation, but got none", c.expectAction)
+			}
+
+			if e.Action != c.expectAction || n != c.expectN {
+				t.Errorf("expected %s escalation after %d warnings, but got %s escalation after %d warnings",
+					c.expectAction, c.expectN, e.Action, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// automodTriggersBucket contains a bucket for every guild, that stores the
+// automod triggers of the guild keyed by their id.
+var automodTriggersBucket = []byte("automod_triggers")
+
+// AutomodTrigger is a record of a message triggering an automod filter.
+type AutomodTrigger struct {
+	// ID is the id of the trigger, unique within the guild.
+	ID        int
+	GuildID   discord.GuildID
+	ChannelID discord.ChannelID
+	UserID    discord.UserID
+	MessageID discord.MessageID
+
+	// Filter is the name of the triggered filter.
+	Filter string
+	// Match is the content that triggered the filter, if any.
+	Match   string   `json:",omitempty"`
+	Actions []string `json:",omitempty"`
+	Time    time.Time
+}
+
+// AddAutomodTrigger stores the passed trigger, assigning it the next
+// trigger id of its guild.
+func (r *Repository) AddAutomodTrigger(t *AutomodTrigger) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.Bucket(automodTriggersBucket).CreateBucketIfNotExists(guildKey(t.GuildID))
+		if err != nil {
+			return err
+		}
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		t.ID = int(id)
+		return put(b, sequenceKey(t.ID), t)
+	})
+}
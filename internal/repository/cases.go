@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// casesBucket contains a bucket for every guild, that stores the cases of
+// the guild keyed by their number.
+var casesBucket = []byte("cases")
+
+// ErrNotFound is the error returned, if the requested item doesn't exist.
+var ErrNotFound = errors.New("repository: not found")
+
+// CaseType is the type of a moderation case.
+type CaseType string
+
+const (
+	// CaseKick is the type of kicks.
+	CaseKick CaseType = "kick"
+	// CaseBan is the type of bans.
+	CaseBan CaseType = "ban"
+	// CaseSoftban is the type of softbans.
+	CaseSoftban CaseType = "softban"
+	// CaseUnban is the type of unbans.
+	CaseUnban CaseType = "unban"
+	// CaseMute is the type of mutes.
+	CaseMute CaseType = "mute"
+	// CaseUnmute is the type of unmutes.
+	CaseUnmute CaseType = "unmute"
+	// CaseWarn is the type of warnings.
+	CaseWarn CaseType = "warn"
+	// CaseQuarantine is the type of members quarantined during raid mode.
+	CaseQuarantine CaseType = "quarantine"
+)
+
+// Case is a moderation case.
+type Case struct {
+	// Number is the number of the case, unique within the guild.
+	Number  int
+	GuildID discord.GuildID
+	Type    CaseType
+
+	UserID      discord.UserID
+	ModeratorID discord.UserID
+	Reason      string `json:",omitempty"`
+	// ReasonKey is the key of the reason of a case created automatically.
+	// If it is set, the reason is localized using ReasonKey and
+	// ReasonPlaceholders when it is displayed, and Reason is only the
+	// English fallback.
+	ReasonKey          string            `json:",omitempty"`
+	ReasonPlaceholders map[string]string `json:",omitempty"`
+	// Duration is the duration of the punishment, if it is temporary.
+	Duration time.Duration `json:",omitempty"`
+	Time     time.Time
+
+	// LogChannelID and LogMessageID are the ids of the mod-log channel and
+	// the message in it, if the case was logged.
+	LogChannelID discord.ChannelID `json:",omitempty"`
+	LogMessageID discord.MessageID `json:",omitempty"`
+}
+
+// sequenceKey returns the key of the item with the passed sequence number.
+func sequenceKey(number int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(number))
+
+	return key
+}
+
+func guildKey(guildID discord.GuildID) []byte {
+	return []byte(guildID.String())
+}
+
+// CreateCase stores the passed case, assigning it the next case number of
+// its guild.
+func (r *Repository) CreateCase(c *Case) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.Bucket(casesBucket).CreateBucketIfNotExists(guildKey(c.GuildID))
+		if err != nil {
+			return err
+		}
+
+		n, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		c.Number = int(n)
+		return put(b, sequenceKey(c.Number), c)
+	})
+}
+
+// UpdateCase updates the passed case.
+// If the case does not exist, UpdateCase returns ErrNotFound.
+func (r *Repository) UpdateCase(c Case) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(casesBucket).Bucket(guildKey(c.GuildID))
+		if b == nil || b.Get(sequenceKey(c.Number)) == nil {
+			return ErrNotFound
+		}
+
+		return put(b, sequenceKey(c.Number), c)
+	})
+}
+
+// Case returns the case with the passed number.
+// If there is no such case, Case returns ErrNotFound.
+func (r *Repository) Case(guildID discord.GuildID, number int) (c *Case, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(casesBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		c = new(Case)
+
+		ok, err := get(b, sequenceKey(number), c)
+		if !ok {
+			return ErrNotFound
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// UserCases returns the cases of the user with the passed id, sorted by
+// their number.
+func (r *Repository) UserCases(guildID discord.GuildID, userID discord.UserID) (cs []Case, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(casesBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var c Case
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+
+			if c.UserID == userID {
+				cs = append(cs, c)
+			}
+
+			return nil
+		})
+	})
+
+	return cs, err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// locksBucket contains a bucket for every guild, that stores the locks of
+// the guild's channels keyed by the channel's id.
+var locksBucket = []byte("locks")
+
+// Lock is the lock of a channel.
+// It remembers the permission overwrites levin changed to lock the channel,
+// so that they can be restored exactly.
+type Lock struct {
+	GuildID   discord.GuildID
+	ChannelID discord.ChannelID
+	// Lockdown specifies whether the channel was locked as part of a
+	// server-wide lockdown.
+	Lockdown bool
+
+	ModeratorID discord.UserID
+	Time        time.Time
+
+	// Previous are the overwrites changed to lock the channel, as they were
+	// before the channel was locked.
+	Previous []discord.Overwrite `json:",omitempty"`
+	// Added are the ids of the overwrites that didn't exist before the
+	// channel was locked.
+	Added []discord.Snowflake `json:",omitempty"`
+}
+
+func channelKey(channelID discord.ChannelID) []byte {
+	return []byte(channelID.String())
+}
+
+// PutLock stores the passed lock, replacing the lock of the same channel, if
+// there is one.
+func (r *Repository) PutLock(l Lock) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.Bucket(locksBucket).CreateBucketIfNotExists(guildKey(l.GuildID))
+		if err != nil {
+			return err
+		}
+
+		return put(b, channelKey(l.ChannelID), l)
+	})
+}
+
+// Lock returns the lock of the channel with the passed id.
+// If the channel is not locked, Lock returns ErrNotFound.
+func (r *Repository) Lock(guildID discord.GuildID, channelID discord.ChannelID) (l Lock, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(locksBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		ok, err := get(b, channelKey(channelID), &l)
+		if err != nil {
+			return err
+		} else if !ok {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+
+	return l, err
+}
+
+// DeleteLock deletes the lock of the channel with the passed id.
+// It is a no-op, if there is no such lock.
+func (r *Repository) DeleteLock(guildID discord.GuildID, channelID discord.ChannelID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(locksBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete(channelKey(channelID))
+	})
+}
+
+// Locks returns the locks of all channels of the guild with the passed id.
+func (r *Repository) Locks(guildID discord.GuildID) (ls []Lock, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(locksBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var l Lock
+			if err := json.Unmarshal(v, &l); err != nil {
+				return err
+			}
+
+			ls = append(ls, l)
+			return nil
+		})
+	})
+
+	return ls, err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+var tempPunishmentsBucket = []byte("temp_punishments")
+
+// permanentMutesBucket stores the permanent mutes, keyed by the guild's and
+// the user's id.
+var permanentMutesBucket = []byte("permanent_mutes")
+
+// PunishmentType is the type of a punishment.
+type PunishmentType string
+
+const (
+	// PunishmentBan is the type of bans.
+	PunishmentBan PunishmentType = "ban"
+	// PunishmentMute is the type of mutes.
+	PunishmentMute PunishmentType = "mute"
+)
+
+// TempPunishment is a punishment, that is lifted once it expires.
+type TempPunishment struct {
+	Type    PunishmentType
+	GuildID discord.GuildID
+	UserID  discord.UserID
+	// RoleID is the id of the mute role, if Type is PunishmentMute.
+	RoleID discord.RoleID `json:",omitempty"`
+	// Expires is the time the punishment expires.
+	Expires time.Time
+}
+
+func tempPunishmentKey(t PunishmentType, guildID discord.GuildID, userID discord.UserID) []byte {
+	return []byte(fmt.Sprintf("%s/%d/%d", t, guildID, userID))
+}
+
+// PutTempPunishment stores the passed punishment, replacing the punishment
+// of the same type for the same user, if there is one.
+func (r *Repository) PutTempPunishment(p TempPunishment) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(tempPunishmentsBucket), tempPunishmentKey(p.Type, p.GuildID, p.UserID), p)
+	})
+}
+
+// TempPunishment returns the punishment of the passed type of the user with
+// the passed id.
+// If there is none, TempPunishment returns nil.
+func (r *Repository) TempPunishment(
+	t PunishmentType, guildID discord.GuildID, userID discord.UserID,
+) (p *TempPunishment, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		p = new(TempPunishment)
+
+		ok, err := get(tx.Bucket(tempPunishmentsBucket), tempPunishmentKey(t, guildID, userID), p)
+		if !ok {
+			p = nil
+		}
+
+		return err
+	})
+
+	return p, err
+}
+
+// DeleteTempPunishment deletes the punishment of the passed type of the user
+// with the passed id.
+// If there is none, DeleteTempPunishment is a no-op.
+func (r *Repository) DeleteTempPunishment(t PunishmentType, guildID discord.GuildID, userID discord.UserID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(tempPunishmentsBucket).Delete(tempPunishmentKey(t, guildID, userID))
+	})
+}
+
+// TempPunishments returns all stored punishments.
+func (r *Repository) TempPunishments() (ps []TempPunishment, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(tempPunishmentsBucket).ForEach(func(_, v []byte) error {
+			var p TempPunishment
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+
+			ps = append(ps, p)
+			return nil
+		})
+	})
+
+	return ps, err
+}
+
+// PermanentMute is a mute, that is not lifted automatically.
+type PermanentMute struct {
+	GuildID discord.GuildID
+	UserID  discord.UserID
+	// RoleID is the id of the mute role.
+	RoleID discord.RoleID
+}
+
+func permanentMuteKey(guildID discord.GuildID, userID discord.UserID) []byte {
+	return []byte(fmt.Sprintf("%d/%d", guildID, userID))
+}
+
+// PutPermanentMute stores the passed mute, replacing the permanent mute of
+// the same user, if there is one.
+func (r *Repository) PutPermanentMute(m PermanentMute) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(permanentMutesBucket), permanentMuteKey(m.GuildID, m.UserID), m)
+	})
+}
+
+// PermanentMute returns the permanent mute of the user with the passed id.
+// If there is none, PermanentMute returns nil.
+func (r *Repository) PermanentMute(guildID discord.GuildID, userID discord.UserID) (m *PermanentMute, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		m = new(PermanentMute)
+
+		ok, err := get(tx.Bucket(permanentMutesBucket), permanentMuteKey(guildID, userID), m)
+		if !ok {
+			m = nil
+		}
+
+		return err
+	})
+
+	return m, err
+}
+
+// DeletePermanentMute deletes the permanent mute of the user with the passed
+// id.
+// If there is none, DeletePermanentMute is a no-op.
+func (r *Repository) DeletePermanentMute(guildID discord.GuildID, userID discord.UserID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(permanentMutesBucket).Delete(permanentMuteKey(guildID, userID))
+	})
+}
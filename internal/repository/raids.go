@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// raidsBucket stores the raid modes of the guilds, keyed by the guild's id.
+var raidsBucket = []byte("raids")
+
+// Raid is the raid mode of a guild.
+type Raid struct {
+	GuildID discord.GuildID
+	Since   time.Time
+	// Automatic specifies whether raid mode was enabled automatically.
+	Automatic bool
+	// Reason is the reason raid mode was enabled for.
+	Reason string `json:",omitempty"`
+	// PreviousVerification is the verification level of the guild before
+	// raid mode was enabled.
+	// It is discord.NullVerification, if the verification level wasn't
+	// raised.
+	PreviousVerification discord.Verification
+}
+
+// PutRaid stores the passed raid mode, replacing the guild's current one.
+func (r *Repository) PutRaid(raid Raid) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(raidsBucket), guildKey(raid.GuildID), raid)
+	})
+}
+
+// DeleteRaid deletes the raid mode of the guild with the passed id.
+// It is a no-op, if there is no such raid mode.
+func (r *Repository) DeleteRaid(guildID discord.GuildID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(raidsBucket).Delete(guildKey(guildID))
+	})
+}
+
+// Raids returns the raid modes of all guilds.
+func (r *Repository) Raids() (raids []Raid, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(raidsBucket).ForEach(func(_, v []byte) error {
+			var raid Raid
+			if err := json.Unmarshal(v, &raid); err != nil {
+				return err
+			}
+
+			raids = append(raids, raid)
+			return nil
+		})
+	})
+
+	return raids, err
+}
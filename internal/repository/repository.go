@@ -0,0 +1,78 @@
+// Package repository provides the persistent storage of levin.
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Repository is a bbolt-backed repository.
+type Repository struct {
+	db *bbolt.DB
+}
+
+// buckets are the top-level buckets used by the repository.
+var buckets = [][]byte{
+	tempPunishmentsBucket,
+	permanentMutesBucket,
+	casesBucket,
+	warningsBucket,
+	automodTriggersBucket,
+	raidsBucket,
+	locksBucket,
+	storedRolesBucket,
+	verificationsBucket,
+}
+
+// Open opens the database at the passed path, and creates it, if it doesn't
+// exist yet.
+func Open(path string) (*Repository, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Repository{db: db}, nil
+}
+
+// Close closes the repository.
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+// put stores the JSON encoding of v under the passed key.
+func put(b *bbolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(key, data)
+}
+
+// get decodes the value stored under the passed key into v.
+// If there is no such value, get returns false.
+func get(b *bbolt.Bucket, key []byte, v interface{}) (bool, error) {
+	data := b.Get(key)
+	if data == nil {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(data, v)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// storedRolesBucket contains a bucket for every guild, that stores the roles
+// of the members that left the guild, keyed by the member's id.
+var storedRolesBucket = []byte("stored_roles")
+
+// StoredRoles are the roles a member had when they left a guild.
+type StoredRoles struct {
+	GuildID discord.GuildID
+	UserID  discord.UserID
+	RoleIDs []discord.RoleID
+	// Time is the time the member left.
+	Time time.Time
+}
+
+func userKey(userID discord.UserID) []byte {
+	return []byte(userID.String())
+}
+
+// PutStoredRoles stores the passed roles, replacing the roles stored for the
+// same member, if there are any.
+func (r *Repository) PutStoredRoles(sr StoredRoles) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.Bucket(storedRolesBucket).CreateBucketIfNotExists(guildKey(sr.GuildID))
+		if err != nil {
+			return err
+		}
+
+		return put(b, userKey(sr.UserID), sr)
+	})
+}
+
+// StoredRoles returns the roles stored for the user with the passed id.
+// If there are none, StoredRoles returns ErrNotFound.
+func (r *Repository) StoredRoles(guildID discord.GuildID, userID discord.UserID) (sr StoredRoles, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(storedRolesBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		ok, err := get(b, userKey(userID), &sr)
+		if err != nil {
+			return err
+		} else if !ok {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+
+	return sr, err
+}
+
+// RemoveStoredRole removes the role with the passed id from the roles stored
+// for the user with the passed id.
+// It is a no-op, if the role is not stored.
+func (r *Repository) RemoveStoredRole(guildID discord.GuildID, userID discord.UserID, roleID discord.RoleID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(storedRolesBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		var sr StoredRoles
+
+		ok, err := get(b, userKey(userID), &sr)
+		if err != nil || !ok {
+			return err
+		}
+
+		for i, id := range sr.RoleIDs {
+			if id == roleID {
+				sr.RoleIDs = append(sr.RoleIDs[:i], sr.RoleIDs[i+1:]...)
+				return put(b, userKey(userID), sr)
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteStoredRoles deletes the roles stored for the user with the passed
+// id.
+// It returns false, if there were none.
+func (r *Repository) DeleteStoredRoles(guildID discord.GuildID, userID discord.UserID) (deleted bool, err error) {
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(storedRolesBucket).Bucket(guildKey(guildID))
+		if b == nil || b.Get(userKey(userID)) == nil {
+			return nil
+		}
+
+		deleted = true
+		return b.Delete(userKey(userID))
+	})
+
+	return deleted, err
+}
+
+// PruneStoredRoles deletes the roles of all members of the guild with the
+// passed id, that left before the passed time.
+func (r *Repository) PruneStoredRoles(guildID discord.GuildID, before time.Time) (n int, err error) {
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(storedRolesBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		var pruned [][]byte
+
+		err := b.ForEach(func(k, v []byte) error {
+			var sr StoredRoles
+			if err := json.Unmarshal(v, &sr); err != nil {
+				return err
+			}
+
+			if sr.Time.Before(before) {
+				pruned = append(pruned, append([]byte(nil), k...))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// keys must not be deleted while iterating
+		for _, k := range pruned {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		n = len(pruned)
+		return nil
+	})
+
+	return n, err
+}
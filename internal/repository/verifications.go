@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+var verificationsBucket = []byte("verifications")
+
+// Verification is the pending verification of a member, that joined a guild
+// with a verification gate.
+type Verification struct {
+	GuildID discord.GuildID
+	UserID  discord.UserID
+	// Challenge is the type of challenge the member must pass.
+	Challenge string
+	// ChannelID is the id of the channel the challenge was sent in, either
+	// the verification channel of the guild or the member's DM channel.
+	ChannelID discord.ChannelID
+	// MessageID is the id of the challenge message.
+	MessageID discord.MessageID
+	// Code is the code of a captcha challenge.
+	Code string `json:",omitempty"`
+	// Attempts are the attempts left to solve a captcha challenge.
+	Attempts int `json:",omitempty"`
+	// Expires is the time the member is kicked, if they haven't passed the
+	// challenge by then.
+	Expires time.Time
+}
+
+func verificationKey(guildID discord.GuildID, userID discord.UserID) []byte {
+	return []byte(fmt.Sprintf("%d/%d", guildID, userID))
+}
+
+// PutVerification stores the passed verification, replacing the
+// verification of the same member, if there is one.
+func (r *Repository) PutVerification(v Verification) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(verificationsBucket), verificationKey(v.GuildID, v.UserID), v)
+	})
+}
+
+// DeleteVerification deletes the verification of the user with the passed
+// id.
+// If there is none, DeleteVerification is a no-op.
+func (r *Repository) DeleteVerification(guildID discord.GuildID, userID discord.UserID) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(verificationsBucket).Delete(verificationKey(guildID, userID))
+	})
+}
+
+// Verifications returns all stored verifications.
+func (r *Repository) Verifications() (vs []Verification, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(verificationsBucket).ForEach(func(_, v []byte) error {
+			var ver Verification
+			if err := json.Unmarshal(v, &ver); err != nil {
+				return err
+			}
+
+			vs = append(vs, ver)
+			return nil
+		})
+	})
+
+	return vs, err
+}
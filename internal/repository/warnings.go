@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"go.etcd.io/bbolt"
+)
+
+// warningsBucket contains a bucket for every guild, that stores the warnings
+// of the guild keyed by their id.
+var warningsBucket = []byte("warnings")
+
+// Warning is a warning issued to a member.
+type Warning struct {
+	// ID is the id of the warning, unique within the guild.
+	ID      int
+	GuildID discord.GuildID
+
+	UserID      discord.UserID
+	ModeratorID discord.UserID
+	Reason      string `json:",omitempty"`
+	// ReasonKey and ReasonPlaceholders are copied from the case of the
+	// warning.
+	ReasonKey          string            `json:",omitempty"`
+	ReasonPlaceholders map[string]string `json:",omitempty"`
+	Time               time.Time
+	// Case is the number of the case of the warning.
+	Case int
+}
+
+// AddWarning stores the passed warning, assigning it the next warning id of
+// its guild.
+func (r *Repository) AddWarning(w *Warning) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.Bucket(warningsBucket).CreateBucketIfNotExists(guildKey(w.GuildID))
+		if err != nil {
+			return err
+		}
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		w.ID = int(id)
+		return put(b, sequenceKey(w.ID), w)
+	})
+}
+
+// Warnings returns the warnings of the user with the passed id, sorted by
+// their id.
+func (r *Repository) Warnings(guildID discord.GuildID, userID discord.UserID) (ws []Warning, err error) {
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(warningsBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var w Warning
+			if err := json.Unmarshal(v, &w); err != nil {
+				return err
+			}
+
+			if w.UserID == userID {
+				ws = append(ws, w)
+			}
+
+			return nil
+		})
+	})
+
+	return ws, err
+}
+
+// DeleteWarning deletes the warning with the passed id, if it was issued to
+// the user with the passed id.
+// If there is no such warning, DeleteWarning returns ErrNotFound.
+func (r *Repository) DeleteWarning(guildID discord.GuildID, userID discord.UserID, id int) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(warningsBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		var w Warning
+
+		ok, err := get(b, sequenceKey(id), &w)
+		if err != nil {
+			return err
+		} else if !ok || w.UserID != userID {
+			return ErrNotFound
+		}
+
+		return b.Delete(sequenceKey(id))
+	})
+}
+
+// ClearWarnings deletes all warnings of the user with the passed id, and
+// returns the number of deleted warnings.
+func (r *Repository) ClearWarnings(guildID discord.GuildID, userID discord.UserID) (n int, err error) {
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(warningsBucket).Bucket(guildKey(guildID))
+		if b == nil {
+			return nil
+		}
+
+		var keys [][]byte
+
+		err := b.ForEach(func(k, v []byte) error {
+			var w Warning
+			if err := json.Unmarshal(v, &w); err != nil {
+				return err
+			}
+
+			if w.UserID == userID {
+				keys = append(keys, k)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// keys must not be deleted while iterating
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		n = len(keys)
+		return nil
+	})
+
+	return n, err
+}
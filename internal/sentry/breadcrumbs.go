@@ -0,0 +1,168 @@
+package sentry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// eventBufferSize is the number of gateway events kept to add them as
+// breadcrumbs to invokes in the same channel.
+const eventBufferSize = 1024
+
+type (
+	// eventBuffer is a ring buffer of the most recent gateway events that
+	// happened in a channel.
+	eventBuffer struct {
+		mutex  sync.Mutex
+		events [eventBufferSize]channelEvent
+		// next is the index the next event will be written to.
+		next int
+	}
+
+	channelEvent struct {
+		channelID  discord.ChannelID
+		breadcrumb sentry.Breadcrumb
+	}
+)
+
+var events eventBuffer
+
+// RecordEvents returns a handler for all events, that records gateway events
+// happening in channels, so that they can be added as breadcrumbs to invokes
+// in the same channel.
+// Additionally, it keeps track of the member counts of guilds, which are
+// added to the guild context.
+func RecordEvents() func(*state.State, interface{}) {
+	return func(_ *state.State, e interface{}) {
+		trackMemberCount(e)
+
+		channelID, category, data := eventData(e)
+		if !channelID.IsValid() {
+			return
+		}
+
+		events.add(channelEvent{
+			channelID: channelID,
+			breadcrumb: sentry.Breadcrumb{
+				Type:      "default",
+				Category:  "gateway." + category,
+				Data:      data,
+				Level:     sentry.LevelInfo,
+				Timestamp: time.Now(),
+			},
+		})
+	}
+}
+
+// eventData returns the channel the passed event happened in, the name of the
+// event and the data stored in the breadcrumb.
+// If the event is not relevant, channelID is 0.
+func eventData(e interface{}) (channelID discord.ChannelID, name string, data map[string]interface{}) {
+	switch e := e.(type) {
+	case *state.MessageCreateEvent:
+		return e.ChannelID, "message_create", map[string]interface{}{
+			"message_id": e.ID,
+			"author_id":  e.Author.ID,
+		}
+	case *state.MessageUpdateEvent:
+		return e.ChannelID, "message_update", map[string]interface{}{
+			"message_id": e.ID,
+			"author_id":  e.Author.ID,
+		}
+	case *state.MessageDeleteEvent:
+		return e.ChannelID, "message_delete", map[string]interface{}{"message_id": e.ID}
+	case *state.MessageDeleteBulkEvent:
+		return e.ChannelID, "message_delete_bulk", map[string]interface{}{"messages": len(e.IDs)}
+	case *state.MessageReactionAddEvent:
+		return e.ChannelID, "message_reaction_add", map[string]interface{}{
+			"message_id": e.MessageID,
+			"user_id":    e.UserID,
+			"emoji":      e.Emoji.APIString(),
+		}
+	case *state.MessageReactionRemoveEvent:
+		return e.ChannelID, "message_reaction_remove", map[string]interface{}{
+			"message_id": e.MessageID,
+			"user_id":    e.UserID,
+			"emoji":      e.Emoji.APIString(),
+		}
+	case *state.TypingStartEvent:
+		return e.ChannelID, "typing_start", map[string]interface{}{"user_id": e.UserID}
+	case *state.ChannelUpdateEvent:
+		return e.ID, "channel_update", nil
+	case *state.ChannelPinsUpdateEvent:
+		return e.ChannelID, "channel_pins_update", nil
+	default:
+		return 0, "", nil
+	}
+}
+
+func (b *eventBuffer) add(e channelEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.events[b.next] = e
+	b.next = (b.next + 1) % eventBufferSize
+}
+
+// recent returns the n most recent events that happened in the channel with
+// the passed id, starting with the oldest.
+func (b *eventBuffer) recent(channelID discord.ChannelID, n int) []sentry.Breadcrumb {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	breadcrumbs := make([]sentry.Breadcrumb, 0, n)
+
+	for i := 1; i <= eventBufferSize && len(breadcrumbs) < n; i++ {
+		e := b.events[(b.next-i+eventBufferSize)%eventBufferSize]
+		if e.channelID == channelID {
+			breadcrumbs = append(breadcrumbs, e.breadcrumb)
+		}
+	}
+
+	// reverse, so that the oldest event comes first
+	for i, j := 0, len(breadcrumbs)-1; i < j; i, j = i+1, j-1 {
+		breadcrumbs[i], breadcrumbs[j] = breadcrumbs[j], breadcrumbs[i]
+	}
+
+	return breadcrumbs
+}
+
+// addBreadcrumb adds the passed breadcrumb to the scope of the passed hub,
+// keeping at most config.C.Sentry.MaxBreadcrumbs breadcrumbs.
+func addBreadcrumb(h *sentry.Hub, b *sentry.Breadcrumb) {
+	if b.Timestamp.IsZero() {
+		b.Timestamp = time.Now()
+	}
+
+	// the client limits the breadcrumbs to its MaxBreadcrumbs
+	h.AddBreadcrumb(b, nil)
+}
+
+// addChannelBreadcrumbs adds the recent gateway events of the channel with
+// the passed id as breadcrumbs to the passed hub.
+func addChannelBreadcrumbs(h *sentry.Hub, channelID discord.ChannelID) {
+	if config.C.Sentry.MaxBreadcrumbs <= 0 {
+		return
+	}
+
+	for _, b := range events.recent(channelID, config.C.Sentry.MaxBreadcrumbs) {
+		b := b
+		addBreadcrumb(h, &b)
+	}
+}
+
+// addMiddlewareBreadcrumb adds a breadcrumb for the passed middleware step.
+func addMiddlewareBreadcrumb(h *sentry.Hub, step string) {
+	addBreadcrumb(h, &sentry.Breadcrumb{
+		Type:     "default",
+		Category: "middleware",
+		Message:  step,
+		Level:    sentry.LevelInfo,
+	})
+}
@@ -0,0 +1,89 @@
+package sentry
+
+import (
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// memberCounts stores the member counts of the guilds, keyed by their
+// discord.GuildID, as the member count is not part of the cached
+// discord.Guild.
+var memberCounts sync.Map
+
+// trackMemberCount updates memberCounts, if the passed event affects the
+// member count of a guild.
+func trackMemberCount(e interface{}) {
+	switch e := e.(type) {
+	case *state.GuildCreateEvent:
+		memberCounts.Store(e.ID, e.MemberCount)
+	case *state.GuildDeleteEvent:
+		memberCounts.Delete(e.ID)
+	case *state.GuildMemberAddEvent:
+		if count, ok := memberCounts.Load(e.GuildID); ok {
+			memberCounts.Store(e.GuildID, count.(uint64)+1)
+		}
+	case *state.GuildMemberRemoveEvent:
+		if count, ok := memberCounts.Load(e.GuildID); ok && count.(uint64) > 0 {
+			memberCounts.Store(e.GuildID, count.(uint64)-1)
+		}
+	}
+}
+
+var channelTypes = map[discord.ChannelType]string{
+	discord.GuildText:     "guild_text",
+	discord.DirectMessage: "direct_message",
+	discord.GuildVoice:    "guild_voice",
+	discord.GroupDM:       "group_dm",
+	discord.GuildCategory: "guild_category",
+	discord.GuildNews:     "guild_news",
+	discord.GuildStore:    "guild_store",
+}
+
+// setInvokeContext sets the user of the passed scope to the author of the
+// invoke, and adds the guild and channel contexts.
+//
+// The username of the author is only sent, if config.C.Sentry.SendUsernames
+// is true.
+func setInvokeContext(scope *sentry.Scope, s *state.State, ctx *plugin.Context) {
+	user := sentry.User{ID: ctx.Author.ID.String()}
+	if config.C.Sentry.SendUsernames {
+		user.Username = ctx.Author.Username + "#" + ctx.Author.Discriminator
+	}
+
+	scope.SetUser(user)
+
+	if ctx.GuildID.IsValid() {
+		guildContext := map[string]interface{}{"id": ctx.GuildID.String()}
+
+		if g, err := ctx.GuildAsync()(); err == nil {
+			guildContext["name"] = g.Name
+		}
+
+		if count, ok := memberCounts.Load(ctx.GuildID); ok {
+			guildContext["member_count"] = count
+		}
+
+		if shard := s.Gateway.Identifier.Shard; shard != nil {
+			guildContext["shard"] = int(uint64(ctx.GuildID)>>22) % shard.NumShards()
+		} else {
+			guildContext["shard"] = 0
+		}
+
+		scope.SetContext("guild", guildContext)
+	}
+
+	channelContext := map[string]interface{}{"id": ctx.ChannelID.String()}
+
+	if c, err := ctx.ChannelAsync()(); err == nil {
+		channelContext["type"] = channelTypes[c.Type]
+		channelContext["nsfw"] = c.NSFW
+	}
+
+	scope.SetContext("channel", channelContext)
+}
@@ -0,0 +1,140 @@
+package sentry
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// eventFilter drops all events matching all of its non-empty criteria.
+type eventFilter struct {
+	exceptionType string
+	message       *regexp.Regexp
+	tags          map[string]string
+}
+
+// newEventFilters compiles the filters from config.C.Sentry.BeforeSend.
+func newEventFilters() ([]eventFilter, error) {
+	filters := make([]eventFilter, len(config.C.Sentry.BeforeSend))
+
+	for i, f := range config.C.Sentry.BeforeSend {
+		filters[i] = eventFilter{exceptionType: f.ExceptionType, tags: f.Tags}
+
+		if len(f.Message) > 0 {
+			var err error
+			if filters[i].message, err = regexp.Compile(f.Message); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return filters, nil
+}
+
+func (f *eventFilter) matches(e *sentry.Event) bool {
+	if len(f.exceptionType) > 0 && !hasExceptionType(e, f.exceptionType) {
+		return false
+	}
+
+	if f.message != nil && !matchesMessage(e, f.message) {
+		return false
+	}
+
+	for k, v := range f.tags {
+		if !hasTag(e, k, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasExceptionType(e *sentry.Event, t string) bool {
+	for _, ex := range e.Exception {
+		if ex.Type == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesMessage(e *sentry.Event, r *regexp.Regexp) bool {
+	if r.MatchString(e.Message) {
+		return true
+	}
+
+	for _, ex := range e.Exception {
+		if r.MatchString(ex.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasTag(e *sentry.Event, key, val string) bool {
+	for k, v := range e.Tags {
+		// viper lowercases all keys, so we can't compare directly
+		if strings.EqualFold(k, key) && v == val {
+			return true
+		}
+	}
+
+	return false
+}
+
+// beforeSend returns the sentry.ClientOptions.BeforeSend func, that drops all
+// events matched by one of the passed filters, and marks only the frames
+// whose module starts with one of config.C.Sentry.InAppInclude as in-app.
+// Errors that are sent are counted as errors of the current session.
+func beforeSend(filters []eventFilter) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(e *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		for i := range filters {
+			if filters[i].matches(e) {
+				return nil
+			}
+		}
+
+		if len(e.Exception) > 0 || e.Level == sentry.LevelError || e.Level == sentry.LevelFatal {
+			currentSession.recordError()
+		}
+
+		if len(config.C.Sentry.InAppInclude) > 0 {
+			for i := range e.Exception {
+				markInApp(e.Exception[i].Stacktrace)
+			}
+
+			for i := range e.Threads {
+				markInApp(e.Threads[i].Stacktrace)
+			}
+		}
+
+		return e
+	}
+}
+
+// markInApp marks the frames of the passed stacktrace as in-app, if their
+// module starts with one of config.C.Sentry.InAppInclude, and all other
+// frames as not in-app.
+// This overrides the SDK's defaults, that consider all frames outside the
+// standard library in-app.
+func markInApp(st *sentry.Stacktrace) {
+	if st == nil {
+		return
+	}
+
+	for i, f := range st.Frames {
+		st.Frames[i].InApp = false
+
+		for _, prefix := range config.C.Sentry.InAppInclude {
+			if strings.HasPrefix(f.Module, prefix) {
+				st.Frames[i].InApp = true
+				break
+			}
+		}
+	}
+}
@@ -2,21 +2,35 @@ package sentry
 
 import (
 	"context"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/mavolin/adam/pkg/bot"
+	"github.com/mavolin/adam/pkg/impl/replier"
 	"github.com/mavolin/adam/pkg/plugin"
 	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
 )
 
 const (
-	hubKey = "sentry.hub"
+	hubKey    = "sentry.hub"
+	invokeKey = "sentry.invoke"
 
 	messageSpanKey     = "sentry.span.message"
-	routeSpanKey       = messageSpanKey + ".route"
 	middlewaresSpanKey = messageSpanKey + ".middlewares"
+	execSpanKey        = messageSpanKey + ".exec"
 )
 
+// invoke is the information about a message, that is stored before routing
+// it.
+type invoke struct {
+	// start is the time the message was received.
+	start time.Time
+	// kind is the kind of the invoke, either create or edit.
+	kind string
+}
+
 // Middlewares is a data struct that contains the middlewares sentry provides.
 type Middlewares struct {
 	MessageCreateMiddleware func(*state.State, *state.MessageCreateEvent)
@@ -31,42 +45,74 @@ type Middlewares struct {
 // proper functionality.
 func NewMiddlewares(h *sentry.Hub) Middlewares {
 	return Middlewares{
-		MessageCreateMiddleware: routeCreateMiddleware(h),
-		MessageUpdateMiddleware: routeUpdateMiddleware(h),
-		Middleware:              middlewaresMiddleware(),
+		MessageCreateMiddleware: routeCreateMiddleware(),
+		MessageUpdateMiddleware: routeUpdateMiddleware(),
+		Middleware:              middlewaresMiddleware(h),
 		PostMiddleware:          execMiddleware(),
 	}
 }
 
-func routeCreateMiddleware(h *sentry.Hub) func(*state.State, *state.MessageCreateEvent) {
+// routeCreateMiddleware records the time the message was received.
+// Since most messages aren't invokes, the hub is only cloned, and the spans
+// are only started, once a command was routed.
+func routeCreateMiddleware() func(*state.State, *state.MessageCreateEvent) {
 	return func(_ *state.State, e *state.MessageCreateEvent) {
-		h := h.Clone()
-		e.Set(hubKey, h)
-
-		ctx := context.WithValue(context.Background(), sentry.HubContextKey, h)
-		msgSpan := sentry.StartSpan(ctx, "message_receive")
-
-		e.Set(messageSpanKey, msgSpan)
-		e.Set(routeSpanKey, msgSpan.StartChild("route"))
+		e.Set(invokeKey, invoke{start: time.Now(), kind: "create"})
 	}
 }
 
-func routeUpdateMiddleware(h *sentry.Hub) func(*state.State, *state.MessageUpdateEvent) {
+func routeUpdateMiddleware() func(*state.State, *state.MessageUpdateEvent) {
 	return func(_ *state.State, e *state.MessageUpdateEvent) {
-		h := h.Clone()
-		e.Set(hubKey, h)
+		// adam only routes edits of messages younger than the edit age
+		if time.Since(e.Timestamp.Time()) > config.C.EditAge {
+			return
+		}
 
-		ctx := context.WithValue(context.Background(), sentry.HubContextKey, h)
-		span := sentry.StartSpan(ctx, messageSpanKey)
+		e.Set(invokeKey, invoke{start: time.Now(), kind: "edit"})
+	}
+}
 
-		e.Set(routeSpanKey, span)
+// startInvoke stores a clone of the passed hub in the passed context, and
+// starts the message span, which, as well as the already finished route span,
+// starts at the time the message was received.
+func startInvoke(h *sentry.Hub, ctx *plugin.Context) *sentry.Hub {
+	inv, ok := ctx.Get(invokeKey).(invoke)
+	if !ok { // the route middlewares weren't added
+		inv = invoke{start: time.Now(), kind: "create"}
+		ctx.Set(invokeKey, inv)
 	}
+
+	h = h.Clone()
+	h.Scope().SetTag("invoke_kind", inv.kind)
+	ctx.Set(hubKey, h)
+
+	addChannelBreadcrumbs(h, ctx.ChannelID)
+	addBreadcrumb(h, &sentry.Breadcrumb{
+		Type:      "default",
+		Category:  "middleware",
+		Message:   "route",
+		Level:     sentry.LevelInfo,
+		Timestamp: inv.start,
+	})
+
+	spanCtx := context.WithValue(context.Background(), sentry.HubContextKey, h)
+	msgSpan := sentry.StartSpan(spanCtx, "message_receive")
+	msgSpan.StartTime = inv.start
+	msgSpan.SetTag("invoke_kind", inv.kind)
+
+	routeSpan := msgSpan.StartChild("route")
+	routeSpan.StartTime = inv.start
+	routeSpan.Finish()
+
+	ctx.Set(messageSpanKey, msgSpan)
+
+	return h
 }
 
-func middlewaresMiddleware() bot.MiddlewareFunc {
+func middlewaresMiddleware(h *sentry.Hub) bot.MiddlewareFunc {
 	return func(next bot.CommandFunc) bot.CommandFunc {
 		return func(s *state.State, ctx *plugin.Context) error {
-			h := GetHub(ctx)
+			h := startInvoke(h, ctx)
 
 			h.Scope().SetTransaction(ctx.InvokedCommand.ProviderName + "/" + string(ctx.InvokedCommand.Identifier))
 			h.Scope().SetTags(map[string]string{
@@ -76,17 +122,18 @@ func middlewaresMiddleware() bot.MiddlewareFunc {
 				"lang":            ctx.Lang,
 			})
 			h.Scope().SetExtra("message", ctx.Message)
+			setInvokeContext(h.Scope(), s, ctx)
 
-			if routeSpan := ctx.Get(routeSpanKey); routeSpan != nil {
-				if routeSpan, ok := routeSpan.(*sentry.Span); ok && routeSpan != nil {
-					routeSpan.Finish()
-				}
-			}
+			addMiddlewareBreadcrumb(h, "middlewares")
 
-			if msgSpan := ctx.Get(messageSpanKey); msgSpan != nil {
-				if msgSpan, ok := msgSpan.(*sentry.Span); ok && msgSpan != nil {
-					ctx.Set(middlewaresSpanKey, msgSpan.StartChild("middlewares"))
-				}
+			// replies are sent using the state of the replier, not s, so it
+			// must use a context containing the hub as well, to record them
+			// as breadcrumbs
+			hubCtx := context.WithValue(context.Background(), sentry.HubContextKey, h)
+			ctx.Replier = replier.WrapState(WithContext(hubCtx, s), false)
+
+			if msgSpan := getSpan(ctx, messageSpanKey); msgSpan != nil {
+				ctx.Set(middlewaresSpanKey, msgSpan.StartChild("middlewares"))
 			}
 
 			return next(s, ctx)
@@ -97,22 +144,96 @@ func middlewaresMiddleware() bot.MiddlewareFunc {
 func execMiddleware() bot.MiddlewareFunc {
 	return func(next bot.CommandFunc) bot.CommandFunc {
 		return func(s *state.State, ctx *plugin.Context) error {
-			if middlewaresSpan := ctx.Get(middlewaresSpanKey); middlewaresSpan != nil {
-				if middlewaresSpan, ok := middlewaresSpan.(*sentry.Span); ok && middlewaresSpan != nil {
-					middlewaresSpan.Finish()
-				}
-			}
+			finishSpan(ctx, middlewaresSpanKey, sentry.SpanStatusUndefined)
 
-			if msgSpan := ctx.Get(messageSpanKey); msgSpan != nil {
-				if msgSpan, ok := msgSpan.(*sentry.Span); ok && msgSpan != nil {
-					defer msgSpan.Finish()
+			h := GetHub(ctx)
+			addMiddlewareBreadcrumb(h, "exec")
 
-					execSpan := msgSpan.StartChild("exec")
-					defer execSpan.Finish()
+			if msgSpan := getSpan(ctx, messageSpanKey); msgSpan != nil {
+				execSpan := msgSpan.StartChild("exec")
+				if inv, ok := ctx.Get(invokeKey).(invoke); ok {
+					execSpan.SetTag("invoke_kind", inv.kind)
 				}
+
+				ctx.Set(execSpanKey, execSpan)
+
+				// the context of the span also contains the hub, so requests
+				// made by the command are recorded as breadcrumbs and as
+				// child spans of exec
+				s = WithContext(execSpan.Context(), s)
+
+				// record replies as child spans of exec as well, but only
+				// while exec is running
+				hubReplier := ctx.Replier
+				ctx.Replier = replier.WrapState(s, false)
+
+				// if the command panics, the spans are marked as failed
+				panicked := true
+
+				defer func() {
+					ctx.Replier = hubReplier
+
+					status := sentry.SpanStatusUndefined
+					if panicked {
+						status = sentry.SpanStatusInternalError
+					}
+
+					finishSpan(ctx, execSpanKey, status)
+					finishSpan(ctx, messageSpanKey, status)
+				}()
+
+				err := next(s, ctx)
+				panicked = false
+
+				return err
 			}
 
+			// use a context containing the hub for all requests made by the
+			// command, so that they are recorded as breadcrumbs
+			s = WithContext(context.WithValue(context.Background(), sentry.HubContextKey, h), s)
+
 			return next(s, ctx)
 		}
 	}
 }
+
+// FinishPanicked finishes all spans of the invoke that are still running,
+// and marks them as failed.
+// It must be called when recovering from a panic during the execution of a
+// command.
+func FinishPanicked(ctx *plugin.Context) {
+	for _, key := range []string{execSpanKey, middlewaresSpanKey, messageSpanKey} {
+		finishSpan(ctx, key, sentry.SpanStatusInternalError)
+	}
+}
+
+// getSpan returns the *sentry.Span stored under the passed key, or nil if
+// there is none.
+func getSpan(ctx *plugin.Context, key string) *sentry.Span {
+	if span := ctx.Get(key); span != nil {
+		if span, ok := span.(*sentry.Span); ok && span != nil {
+			return span
+		}
+	}
+
+	return nil
+}
+
+// finishSpan finishes the span stored under the passed key, if there is one,
+// and removes it, so that it won't be finished twice.
+// If status is not sentry.SpanStatusUndefined, it is set as the status of the
+// span.
+func finishSpan(ctx *plugin.Context, key string, status sentry.SpanStatus) {
+	span := getSpan(ctx, key)
+	if span == nil {
+		return
+	}
+
+	ctx.Set(key, nil)
+
+	if status != sentry.SpanStatusUndefined {
+		span.Status = status
+	}
+
+	span.Finish()
+}
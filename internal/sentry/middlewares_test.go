@@ -0,0 +1,213 @@
+package sentry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/adam/pkg/i18n"
+	"github.com/mavolin/adam/pkg/plugin"
+	"github.com/mavolin/disstate/v3/pkg/state"
+
+	"github.com/mavolin/levin/internal/config"
+)
+
+// recordingTransport is a sentry.Transport that records all events instead
+// of sending them.
+type recordingTransport struct {
+	mutex  sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Flush(time.Duration) bool       { return true }
+func (t *recordingTransport) Configure(sentry.ClientOptions) {}
+
+func (t *recordingTransport) SendEvent(e *sentry.Event) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.events = append(t.events, e)
+}
+
+// transactions returns the recorded transaction events.
+func (t *recordingTransport) transactions() []*sentry.Event {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var transactions []*sentry.Event
+
+	for _, e := range t.events {
+		if e.Type == "transaction" {
+			transactions = append(transactions, e)
+		}
+	}
+
+	return transactions
+}
+
+type dataProvider struct{ channel discord.Channel }
+
+func (p dataProvider) GuildAsync() func() (*discord.Guild, error) {
+	return func() (*discord.Guild, error) { return nil, nil }
+}
+
+func (p dataProvider) ChannelAsync() func() (*discord.Channel, error) {
+	return func() (*discord.Channel, error) { return &p.channel, nil }
+}
+
+func (p dataProvider) SelfAsync() func() (*discord.Member, error) {
+	return func() (*discord.Member, error) { return nil, nil }
+}
+
+// newTestHub returns a hub that samples all transactions, and records them
+// using the returned *recordingTransport.
+func newTestHub(t *testing.T) (*sentry.Hub, *recordingTransport) {
+	transport := new(recordingTransport)
+
+	c, err := sentry.NewClient(sentry.ClientOptions{
+		Transport:        transport,
+		TracesSampleRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	return sentry.NewHub(c, sentry.NewScope()), transport
+}
+
+// newTestContext creates a *plugin.Context for the passed message, using the
+// passed base.
+func newTestContext(msg discord.Message, base *state.Base) *plugin.Context {
+	return &plugin.Context{
+		Message:   msg,
+		Base:      base,
+		Localizer: i18n.NewFallbackLocalizer(),
+		InvokedCommand: &plugin.RegisteredCommand{
+			ProviderName: plugin.BuiltInProvider,
+			Identifier:   ".ping",
+		},
+		DiscordDataProvider: dataProvider{channel: discord.Channel{ID: msg.ChannelID, Type: discord.DirectMessage}},
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	config.C.EditAge = time.Minute
+
+	msg := discord.Message{
+		ID:        123,
+		ChannelID: 456,
+		Author:    discord.User{ID: 789},
+		Timestamp: discord.NewTimestamp(time.Now()),
+	}
+
+	testCases := []struct {
+		name  string
+		kind  string
+		route func(Middlewares, *state.State, *state.Base)
+	}{
+		{
+			name: "create",
+			kind: "create",
+			route: func(m Middlewares, s *state.State, base *state.Base) {
+				m.MessageCreateMiddleware(s, &state.MessageCreateEvent{
+					MessageCreateEvent: &gateway.MessageCreateEvent{Message: msg},
+					Base:               base,
+				})
+			},
+		},
+		{
+			name: "edit",
+			kind: "edit",
+			route: func(m Middlewares, s *state.State, base *state.Base) {
+				m.MessageUpdateMiddleware(s, &state.MessageUpdateEvent{
+					MessageUpdateEvent: &gateway.MessageUpdateEvent{Message: msg},
+					Base:               base,
+				})
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			h, transport := newTestHub(t)
+			m := NewMiddlewares(h)
+
+			_, s := state.NewMocker(t)
+
+			base := state.NewBase()
+			c.route(m, s, base)
+
+			ctx := newTestContext(msg, base)
+
+			var invoked bool
+
+			cmd := func(*state.State, *plugin.Context) error {
+				invoked = true
+				return nil
+			}
+
+			if err := m.Middleware(m.PostMiddleware(cmd))(s, ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !invoked {
+				t.Fatal("command was not invoked")
+			}
+
+			if ctx.Get(hubKey) == nil {
+				t.Fatal("no hub was stored in the context")
+			}
+
+			transactions := transport.transactions()
+			if len(transactions) != 1 {
+				t.Fatalf("expected 1 transaction, but got %d", len(transactions))
+			}
+
+			msgSpan := transactions[0]
+			if actual := msgSpan.Tags["invoke_kind"]; actual != c.kind {
+				t.Errorf("expected message span with invoke_kind=%s, but got %q", c.kind, actual)
+			}
+
+			spans := make(map[string]*sentry.Span)
+			for _, span := range msgSpan.Spans {
+				spans[span.Op] = span
+			}
+
+			for _, op := range []string{"route", "middlewares", "exec"} {
+				if spans[op] == nil {
+					t.Errorf("expected %s span", op)
+				}
+			}
+
+			if exec := spans["exec"]; exec != nil && exec.Tags["invoke_kind"] != c.kind {
+				t.Errorf("expected exec span with invoke_kind=%s, but got %q", c.kind, exec.Tags["invoke_kind"])
+			}
+		})
+	}
+
+	t.Run("old edit", func(t *testing.T) {
+		h, _ := newTestHub(t)
+		m := NewMiddlewares(h)
+
+		_, s := state.NewMocker(t)
+
+		old := msg
+		old.Timestamp = discord.NewTimestamp(time.Now().Add(-2 * config.C.EditAge))
+
+		base := state.NewBase()
+
+		m.MessageUpdateMiddleware(s, &state.MessageUpdateEvent{
+			MessageUpdateEvent: &gateway.MessageUpdateEvent{Message: old},
+			Base:               base,
+		})
+
+		if actual := base.Get(invokeKey); actual != nil {
+			t.Errorf("expected edit to be skipped, but got %+v", actual)
+		}
+	})
+}
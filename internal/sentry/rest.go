@@ -0,0 +1,197 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api/rate"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/arikawa/v2/utils/httputil/httpdriver"
+	"github.com/getsentry/sentry-go"
+	"github.com/mavolin/disstate/v3/pkg/state"
+)
+
+// rateLimitDelayThreshold is the minimum time a request must have waited for
+// the rate limiter, to be considered delayed by a rate limit.
+const rateLimitDelayThreshold = 10 * time.Millisecond
+
+type requestInfo struct {
+	// start is the time the request was started.
+	start time.Time
+	// span is the span of the request, if the request was made during a
+	// transaction.
+	span *sentry.Span
+}
+
+// requests stores the *requestInfos of the running requests, keyed by their
+// httpdriver.Request.
+var requests sync.Map
+
+// InstrumentClient adds hooks to the passed client, that record a breadcrumb
+// for every request made using a context containing a *sentry.Hub.
+// If the context also contains a *sentry.Span, the request is recorded as
+// child span.
+//
+// Use WithContext to obtain a *state.State using such a context.
+func InstrumentClient(c *httputil.Client) {
+	// onRequest is prepended, and onAcquire appended, so that the time
+	// between them is the time spent waiting for the rate limiter
+	c.OnRequest = append([]httputil.RequestOption{onRequest}, c.OnRequest...)
+	c.OnRequest = append(c.OnRequest, onAcquire)
+	c.OnResponse = append(c.OnResponse, onResponse)
+}
+
+// WithContext returns a copy of the passed *state.State, that uses the
+// passed context for all requests.
+//
+// Unlike s.WithContext, it doesn't alter the context of s.
+func WithContext(ctx context.Context, s *state.State) *state.State {
+	cpy := *s
+	cpy.State = s.State.WithContext(ctx)
+
+	return &cpy
+}
+
+func onRequest(r httpdriver.Request) error {
+	if sentry.GetHubFromContext(r.GetContext()) != nil {
+		requests.Store(r, &requestInfo{start: time.Now()})
+	}
+
+	return nil
+}
+
+func onAcquire(r httpdriver.Request) error {
+	v, ok := requests.Load(r)
+	if !ok {
+		return nil
+	}
+
+	info := v.(*requestInfo)
+
+	if sentry.TransactionFromContext(r.GetContext()) == nil {
+		return nil
+	}
+
+	method := requestMethod(r)
+	route := routeTemplate(r.GetPath())
+
+	info.span = sentry.StartSpan(r.GetContext(), "http.client")
+	info.span.StartTime = info.start
+	info.span.Description = method + " " + route
+	info.span.SetTag("http.method", method)
+	info.span.SetTag("http.route", route)
+
+	delay := time.Since(info.start)
+	info.span.SetTag("rate_limit_delayed", strconv.FormatBool(delay >= rateLimitDelayThreshold))
+	info.span.Data = map[string]interface{}{"rate_limit_delay_ms": delay.Milliseconds()}
+
+	return nil
+}
+
+func onResponse(r httpdriver.Request, resp httpdriver.Response) error {
+	v, ok := requests.Load(r)
+	if !ok {
+		return nil
+	}
+
+	requests.Delete(r)
+
+	info := v.(*requestInfo)
+	h := sentry.GetHubFromContext(r.GetContext())
+
+	data := map[string]interface{}{
+		"method":     requestMethod(r),
+		"url":        routeTemplate(r.GetPath()),
+		"latency_ms": time.Since(info.start).Milliseconds(),
+	}
+
+	level := sentry.LevelInfo
+
+	if resp != nil {
+		status := resp.GetStatus()
+		data["status_code"] = status
+
+		if status >= http.StatusBadRequest {
+			level = sentry.LevelWarning
+		}
+	} else {
+		level = sentry.LevelError
+	}
+
+	addBreadcrumb(h, &sentry.Breadcrumb{
+		Type:     "http",
+		Category: "discord.rest",
+		Data:     data,
+		Level:    level,
+	})
+
+	if info.span != nil {
+		finishRequestSpan(info.span, r, resp)
+	}
+
+	return nil
+}
+
+func finishRequestSpan(span *sentry.Span, r httpdriver.Request, resp httpdriver.Response) {
+	bucket := rate.ParseBucketKey(r.GetPath())
+
+	if resp == nil {
+		span.Status = sentry.SpanStatusUnavailable
+	} else {
+		status := resp.GetStatus()
+
+		span.Status = spanStatus(status)
+		span.SetTag("http.status_code", strconv.Itoa(status))
+
+		// prefer the bucket hash sent by Discord
+		if h := resp.GetHeader().Get("X-RateLimit-Bucket"); len(h) > 0 {
+			bucket = h
+		}
+	}
+
+	span.SetTag("rate_limit_bucket", bucket)
+	span.Finish()
+}
+
+// requestMethod returns the HTTP method of the passed request, if it is a
+// *httpdriver.DefaultRequest.
+func requestMethod(r httpdriver.Request) string {
+	if r, ok := r.(*httpdriver.DefaultRequest); ok {
+		return r.Method
+	}
+
+	return ""
+}
+
+var snowflakeRegexp = regexp.MustCompile(`/\d{15,}`)
+
+// routeTemplate replaces all snowflakes in the passed path with ':id'.
+func routeTemplate(path string) string {
+	return snowflakeRegexp.ReplaceAllString(path, "/:id")
+}
+
+// spanStatus returns the sentry.SpanStatus for the passed HTTP status code.
+func spanStatus(code int) sentry.SpanStatus {
+	switch {
+	case code < http.StatusBadRequest:
+		return sentry.SpanStatusOK
+	case code == http.StatusUnauthorized:
+		return sentry.SpanStatusUnauthenticated
+	case code == http.StatusForbidden:
+		return sentry.SpanStatusPermissionDenied
+	case code == http.StatusNotFound:
+		return sentry.SpanStatusNotFound
+	case code == http.StatusTooManyRequests:
+		return sentry.SpanStatusResourceExhausted
+	case code < http.StatusInternalServerError:
+		return sentry.SpanStatusInvalidArgument
+	case code == http.StatusServiceUnavailable:
+		return sentry.SpanStatusUnavailable
+	default:
+		return sentry.SpanStatusInternalError
+	}
+}
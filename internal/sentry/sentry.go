@@ -2,23 +2,72 @@ package sentry
 
 import (
 	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
 
 	"github.com/mavolin/levin/internal/config"
 	"github.com/mavolin/levin/internal/meta"
 )
 
 // Init initializes sentry using config.C.
+//
+// If config.C.Sentry.Sessions is true, a release health session for
+// meta.Version is started, which must be ended using EndSession.
 func Init() error {
-	return sentry.Init(sentry.ClientOptions{
+	filters, err := newEventFilters()
+	if err != nil {
+		return err
+	}
+
+	err = sentry.Init(sentry.ClientOptions{
 		Dsn:              config.C.Sentry.DSN,
-		Debug:            false,
-		AttachStacktrace: false,
+		Debug:            config.C.Sentry.Debug,
+		DebugWriter:      zap.NewStdLog(zap.L().Named("sentry")).Writer(),
+		AttachStacktrace: config.C.Sentry.AttachStacktrace,
 		SampleRate:       config.C.Sentry.SampleRate,
 		TracesSampleRate: config.C.Sentry.TracesSampleRate,
+		IgnoreErrors:     config.C.Sentry.IgnoreErrors,
+		BeforeSend:       beforeSend(filters),
 		ServerName:       config.C.ServerName,
 		Release:          meta.Version,
 		Environment:      config.C.Sentry.Environment,
+		MaxBreadcrumbs:   config.C.Sentry.MaxBreadcrumbs,
 	})
+	if err != nil {
+		return err
+	}
+
+	startSession()
+	return nil
+}
+
+// startSession starts the release health session, if sessions are enabled.
+// Since sessions are not essential, errors are only logged.
+func startSession() {
+	if !config.C.Sentry.Sessions || len(config.C.Sentry.DSN) == 0 {
+		return
+	}
+
+	log := sessionLog()
+
+	if len(meta.Version) == 0 {
+		log.Warn("sessions are enabled, but levin was built without a version, not starting session")
+		return
+	}
+
+	s, err := newSession(config.C.Sentry.DSN, meta.Version, config.C.Sentry.Environment, config.C.ServerName)
+	if err != nil {
+		log.With("err", err).
+			Error("unable to create session")
+		return
+	}
+
+	if err := s.start(); err != nil {
+		log.With("err", err).
+			Warn("unable to start session")
+		return
+	}
+
+	currentSession = s
 }
 
 // GetHub extracts the *sentry.Hub from the passed context.
@@ -0,0 +1,266 @@
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+)
+
+// The statuses of a session, as defined by sentry's release health.
+const (
+	sessionOK      = "ok"
+	sessionExited  = "exited"
+	sessionCrashed = "crashed"
+)
+
+// sessionTimeout is the timeout of requests sending session updates.
+const sessionTimeout = 5 * time.Second
+
+type (
+	// session is a release health session, that spans the runtime of levin.
+	//
+	// The SDK doesn't support sessions, which is why they are sent as
+	// envelopes manually.
+	session struct {
+		client  *http.Client
+		url     string
+		headers map[string]string
+
+		mutex sync.Mutex
+		data  sessionData
+		// sentErrored indicates whether the session was already sent with
+		// errors, so that sentry marks it as errored.
+		sentErrored bool
+		ended       bool
+	}
+
+	sessionData struct {
+		ID         string       `json:"sid"`
+		DistinctID string       `json:"did,omitempty"`
+		Init       bool         `json:"init"`
+		Started    time.Time    `json:"started"`
+		Timestamp  time.Time    `json:"timestamp"`
+		Status     string       `json:"status"`
+		Errors     int          `json:"errors"`
+		Duration   float64      `json:"duration,omitempty"`
+		Attrs      sessionAttrs `json:"attrs"`
+	}
+
+	sessionAttrs struct {
+		Release     string `json:"release"`
+		Environment string `json:"environment,omitempty"`
+	}
+)
+
+// currentSession is the session started by Init, or nil, if sessions are
+// disabled.
+var currentSession *session
+
+func sessionLog() *zap.SugaredLogger {
+	return zap.S().Named("sentry").Named("session")
+}
+
+// newSession creates a new session for the passed release, that is sent
+// using the passed dsn.
+func newSession(dsn, release, environment, serverName string) (*session, error) {
+	d, err := sentry.NewDsn(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	return &session{
+		client:  &http.Client{Timeout: sessionTimeout},
+		url:     d.EnvelopeAPIURL().String(),
+		headers: d.RequestHeaders(),
+		data: sessionData{
+			ID:         id,
+			DistinctID: serverName,
+			Init:       true,
+			Started:    now,
+			Timestamp:  now,
+			Status:     sessionOK,
+			Attrs:      sessionAttrs{Release: release, Environment: environment},
+		},
+	}, nil
+}
+
+// newSessionID generates a random version 4 UUID.
+func newSessionID() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return "", err
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// start sends the initial update of the session.
+func (s *session) start() error {
+	s.mutex.Lock()
+	data := s.data
+	s.data.Init = false
+	s.mutex.Unlock()
+
+	return s.send(data)
+}
+
+// recordError counts an error of the session.
+// The first error is sent immediately, so that the session is marked as
+// errored, even if levin is not shut down gracefully.
+//
+// recordError is a no-op, if s is nil.
+func (s *session) recordError() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.data.Errors++
+
+	if s.sentErrored {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.sentErrored = true
+	data := s.update(sessionOK)
+
+	s.mutex.Unlock()
+
+	go func() {
+		if err := s.send(data); err != nil {
+			sessionLog().With("err", err).
+				Warn("unable to send session update")
+		}
+	}()
+}
+
+// end ends the session with the passed status, and sends the final update.
+//
+// end is a no-op, if s is nil, or if the session already ended.
+func (s *session) end(status string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+
+	if s.ended {
+		s.mutex.Unlock()
+		return nil
+	}
+
+	s.ended = true
+	data := s.update(status)
+
+	s.mutex.Unlock()
+
+	return s.send(data)
+}
+
+// update returns the current data of the session with the passed status.
+// The caller must hold the mutex.
+func (s *session) update(status string) sessionData {
+	s.data.Timestamp = time.Now().UTC()
+	s.data.Status = status
+	s.data.Duration = s.data.Timestamp.Sub(s.data.Started).Seconds()
+
+	data := s.data
+	s.data.Init = false
+
+	return data
+}
+
+// send sends the passed session data as envelope.
+func (s *session) send(data sessionData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+
+	err = enc.Encode(struct {
+		SentAt time.Time `json:"sent_at"`
+	}{SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	err = enc.Encode(struct {
+		Type   string `json:"type"`
+		Length int    `json:"length"`
+	}{Type: "session", Length: len(payload)})
+	if err != nil {
+		return err
+	}
+
+	b.Write(payload)
+	b.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, s.url, &b)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry: unexpected status %s when sending session", resp.Status)
+	}
+
+	return nil
+}
+
+// EndSession ends the release health session started by Init.
+// If crashed is true, the session is marked as crashed, otherwise as exited.
+//
+// It is a no-op, if sessions are disabled.
+func EndSession(crashed bool) {
+	status := sessionExited
+	if crashed {
+		status = sessionCrashed
+	}
+
+	if err := currentSession.end(status); err != nil {
+		sessionLog().With("err", err).
+			Warn("unable to end session")
+	}
+}
@@ -0,0 +1,147 @@
+package sentry
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sessionRecorder is a http.Handler that records the session updates sent
+// to it.
+type sessionRecorder struct {
+	t *testing.T
+
+	mutex   sync.Mutex
+	updates []sessionData
+}
+
+func (r *sessionRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !strings.HasPrefix(req.Header.Get("X-Sentry-Auth"), "Sentry ") {
+		r.t.Errorf("expected sentry auth header, but got %q", req.Header.Get("X-Sentry-Auth"))
+	}
+
+	scanner := bufio.NewScanner(req.Body)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(lines) != 3 {
+		r.t.Errorf("expected envelope with header, item header and payload, but got %q", lines)
+		return
+	}
+
+	var item struct{ Type string }
+	if err := json.Unmarshal([]byte(lines[1]), &item); err != nil || item.Type != "session" {
+		r.t.Errorf("expected session item, but got %s", lines[1])
+	}
+
+	var data sessionData
+	if err := json.Unmarshal([]byte(lines[2]), &data); err != nil {
+		r.t.Errorf("unable to decode session: %v", err)
+		return
+	}
+
+	r.mutex.Lock()
+	r.updates = append(r.updates, data)
+	r.mutex.Unlock()
+}
+
+// waitUpdates waits until the passed number of updates was received, and
+// returns them.
+func (r *sessionRecorder) waitUpdates(n int) []sessionData {
+	for i := 0; i < 100; i++ {
+		r.mutex.Lock()
+		if len(r.updates) >= n {
+			updates := r.updates
+			r.mutex.Unlock()
+			return updates
+		}
+		r.mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	r.t.Fatalf("expected %d session updates", n)
+	return nil
+}
+
+func TestSession(t *testing.T) {
+	rec := &sessionRecorder{t: t}
+
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/1"
+
+	s, err := newSession(dsn, "1.2.3", "production", "levin-1")
+	if err != nil {
+		t.Fatalf("unable to create session: %v", err)
+	}
+
+	if err = s.start(); err != nil {
+		t.Fatalf("unable to start session: %v", err)
+	}
+
+	s.recordError()
+	rec.waitUpdates(2)
+
+	s.recordError()
+
+	if err = s.end(sessionExited); err != nil {
+		t.Fatalf("unable to end session: %v", err)
+	}
+
+	// updates after the end must not be sent
+	s.recordError()
+
+	if err = s.end(sessionCrashed); err != nil {
+		t.Fatalf("unable to end session twice: %v", err)
+	}
+
+	updates := rec.waitUpdates(3)
+	if len(updates) != 3 {
+		t.Fatalf("expected 3 session updates, but got %d", len(updates))
+	}
+
+	expect := []struct {
+		init   bool
+		status string
+		errors int
+	}{
+		{init: true, status: sessionOK, errors: 0},
+		{init: false, status: sessionOK, errors: 1},
+		{init: false, status: sessionExited, errors: 2},
+	}
+
+	for i, e := range expect {
+		u := updates[i]
+
+		if u.ID != updates[0].ID {
+			t.Errorf("update %d: expected session id %s, but got %s", i, updates[0].ID, u.ID)
+		}
+
+		if u.Init != e.init || u.Status != e.status || u.Errors != e.errors {
+			t.Errorf("update %d: expected init=%t status=%s errors=%d, but got init=%t status=%s errors=%d",
+				i, e.init, e.status, e.errors, u.Init, u.Status, u.Errors)
+		}
+
+		if u.Attrs.Release != "1.2.3" || u.Attrs.Environment != "production" {
+			t.Errorf("update %d: expected release 1.2.3 in production, but got %+v", i, u.Attrs)
+		}
+	}
+}
+
+func TestEndSession_Disabled(t *testing.T) {
+	currentSession = nil
+
+	// must not panic
+	currentSession.recordError()
+	EndSession(false)
+}
@@ -2,6 +2,7 @@
 package zaplog
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/mavolin/adam/pkg/bot"
@@ -14,24 +15,41 @@ import (
 
 const loggerKey = "logger"
 
-// Init initializes the global zap logger.
-func Init(debug bool) {
-	if debug {
-		l, err := zap.NewDevelopment()
-		if err != nil {
-			panic(err)
-		}
+// Format is the format of the logs.
+type Format string
 
-		zap.ReplaceGlobals(l)
-	} else {
-		l, err := zap.NewProduction()
-		if err != nil {
-			panic(err)
-		}
+const (
+	// JSONFormat logs in JSON.
+	JSONFormat Format = "json"
+	// ConsoleFormat logs in a human-readable format.
+	ConsoleFormat Format = "console"
+)
+
+// Init initializes the global zap logger, using the passed format and the
+// passed minimum level.
+func Init(format Format, level string) error {
+	var cfg zap.Config
+
+	switch format {
+	case JSONFormat:
+		cfg = zap.NewProductionConfig()
+	case ConsoleFormat:
+		cfg = zap.NewDevelopmentConfig()
+	default:
+		return fmt.Errorf("zaplog: unknown log format %q", format)
+	}
 
-		zap.ReplaceGlobals(l)
+	if err := cfg.Level.UnmarshalText([]byte(level)); err != nil {
+		return err
 	}
 
+	l, err := cfg.Build()
+	if err != nil {
+		return err
+	}
+
+	zap.ReplaceGlobals(l)
+
 	jww.TRACE = mustStdLogAt(zap.L(), zapcore.DebugLevel)
 	jww.DEBUG = mustStdLogAt(zap.L(), zapcore.DebugLevel)
 	// viper's info logs are very verbose, using debug for this
@@ -41,6 +59,8 @@ func Init(debug bool) {
 	jww.CRITICAL = mustStdLogAt(zap.L(), zapcore.ErrorLevel)
 	jww.FATAL = mustStdLogAt(zap.L(), zapcore.FatalLevel)
 	jww.LOG = mustStdLogAt(zap.L(), zapcore.InfoLevel)
+
+	return nil
 }
 
 func mustStdLogAt(l *zap.Logger, lvl zapcore.Level) *log.Logger {